@@ -7,18 +7,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"Laman/internal/auth"
+	"Laman/internal/cart"
 	"Laman/internal/catalog"
 	"Laman/internal/config"
 	"Laman/internal/database"
 	"Laman/internal/delivery"
+	"Laman/internal/events"
 	"Laman/internal/middleware"
 	"Laman/internal/observability"
 	"Laman/internal/orders"
 	"Laman/internal/payments"
+	"Laman/internal/pricing"
+	"Laman/internal/receipts"
+	"Laman/internal/reviews"
+	"Laman/internal/scheduling"
+	"Laman/internal/search"
 	"Laman/internal/users"
 
 	"github.com/gin-gonic/gin"
@@ -81,31 +89,70 @@ func main() {
 	orderItemRepo := orders.NewPostgresOrderItemRepository(db)
 	paymentRepo := payments.NewPostgresPaymentRepository(db)
 	deliveryRepo := delivery.NewPostgresDeliveryRepository(db)
+	idempotencyRepo := orders.NewPostgresIdempotencyRepository(db)
+	orderEventRepo := orders.NewPostgresOrderStatusEventRepository(db)
+	checkoutRepo := orders.NewPostgresCheckoutGroupRepository(db)
+	orderTemplateRepo := orders.NewPostgresOrderTemplateRepository(db)
+	cartRepo := cart.NewPostgresCartRepository(db)
+	cartItemRepo := cart.NewPostgresCartItemRepository(db)
+	pricingRuleRepo := pricing.NewPostgresRuleRepository(db)
+	storeHoursRepo := scheduling.NewPostgresHoursRepository(db)
+	deliverySlotRepo := scheduling.NewPostgresSlotRepository(db)
+	reviewRepo := reviews.NewPostgresReviewRepository(db)
+	searchRepo := search.NewPostgresSearchRepository(db)
+	uow := database.NewUnitOfWork(db)
+
+	// Брокер событий заказов для потоковой передачи статусов клиентам
+	orderBroker := events.NewMemoryBroker()
 
 	// Инициализация сервисов
-	authService := auth.NewAuthService(authRepo, userRepo, cfg.JWT.Secret)
+	authService := auth.NewAuthService(authRepo, userRepo, cfg.JWT.Secret, logger)
 	userService := users.NewUserService(userRepo)
-	catalogService := catalog.NewCatalogService(categoryRepo, subcategoryRepo, productRepo, storeRepo)
+	catalogService := catalog.NewCatalogService(uow, categoryRepo, subcategoryRepo, productRepo, storeRepo)
+	queryLog := search.NewQueryLog(searchRepo, cfg.Search.QueryLogFlushInterval, logger)
+	catalogService.SetSearchLogger(queryLog)
+	suggestService := search.NewSuggestService(searchRepo, queryLog)
+	pricingService := pricing.NewPricingService(pricingRuleRepo, storeRepo)
+	slotService := scheduling.NewSlotService(uow, storeHoursRepo, deliverySlotRepo)
+	trackingSigner := orders.NewTrackingSigner(cfg.JWT.Secret, cfg.Orders.TrackingTTL)
 	orderService := orders.NewOrderService(
+		uow,
 		orderRepo,
 		orderItemRepo,
 		productRepo,
 		deliveryRepo,
 		paymentRepo,
-		5.0,   // 5% сервисный сбор
-		200.0, // 200 руб. стоимость доставки
+		idempotencyRepo,
+		orderEventRepo,
+		checkoutRepo,
+		orderTemplateRepo,
+		pricingService,
+		slotService,
+		orderBroker,
+		cfg.Orders.SelfCancelWindow,
+		trackingSigner,
+		cfg.Orders.DeliveryETA,
 		telegramNotifier,
 		logger,
 	)
+	authService.SetGuestOrderClaimer(orderService)
+	cartService := cart.NewCartService(cartRepo, cartItemRepo, productRepo, orderService, logger)
+	reviewService := reviews.NewReviewService(uow, reviewRepo, orderRepo, orderItemRepo)
+	receiptService := receipts.NewReceiptService(orderRepo, orderItemRepo, orderEventRepo, productRepo, storeRepo, userRepo, trackingSigner)
 
 	// Инициализация обработчиков
 	authHandler := auth.NewHandler(authService)
 	userHandler := users.NewHandler(userService, authService)
-	catalogHandler := catalog.NewHandler(catalogService)
-	orderHandler := orders.NewHandler(orderService, authService)
+	catalogHandler := catalog.NewHandler(catalogService, authService, userService)
+	orderHandler := orders.NewHandler(orderService, authService, userService)
+	cartHandler := cart.NewHandler(cartService, authService)
+	schedulingHandler := scheduling.NewHandler(slotService, authService, userService)
+	reviewHandler := reviews.NewHandler(reviewService, authService, userService)
+	receiptHandler := receipts.NewHandler(receiptService, authService, userService)
+	searchHandler := search.NewHandler(suggestService)
 
 	// Настройка роутера
-	router := setupRouter(logger, authHandler, userHandler, catalogHandler, orderHandler)
+	router := setupRouter(logger, authHandler, userHandler, catalogHandler, orderHandler, cartHandler, schedulingHandler, reviewHandler, receiptHandler, searchHandler)
 
 	// Настройка эндпоинта метрик
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -121,6 +168,32 @@ func main() {
 		Handler: router,
 	}
 
+	// Запуск фоновых задач: планировщика запланированных заказов,
+	// обработчика заказов, которые магазин не подтверждает, и сброса журнала поиска
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	scheduler := orders.NewScheduler(orderService, cfg.Orders.SchedulerInterval, cfg.Orders.ScheduleLead, logger)
+	staleWorker := orders.NewStaleOrderWorker(
+		orderService,
+		cfg.Orders.StaleCheckInterval,
+		cfg.Orders.EscalateAfter,
+		cfg.Orders.AutoCancelAfter,
+		logger,
+	)
+	workers.Add(3)
+	go func() {
+		defer workers.Done()
+		scheduler.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		staleWorker.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		queryLog.Run(workersCtx)
+	}()
+
 	// Запуск сервера в горутине
 	go func() {
 		logger.Info("Запуск сервера", zap.String("address", srv.Addr))
@@ -136,6 +209,16 @@ func main() {
 
 	logger.Info("Остановка сервера...")
 
+	// Фоновые задачи останавливаются первыми, чтобы не менять заказы во время остановки
+	stopWorkers()
+	workers.Wait()
+
+	// Закрытие брокера завершает открытые потоки событий, иначе
+	// долгоживущие SSE соединения задержат graceful shutdown
+	if err := orderBroker.Close(); err != nil {
+		logger.Warn("Не удалось остановить брокер событий", zap.Error(err))
+	}
+
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -153,6 +236,11 @@ func setupRouter(
 	userHandler *users.Handler,
 	catalogHandler *catalog.Handler,
 	orderHandler *orders.Handler,
+	cartHandler *cart.Handler,
+	schedulingHandler *scheduling.Handler,
+	reviewHandler *reviews.Handler,
+	receiptHandler *receipts.Handler,
+	searchHandler *search.Handler,
 ) *gin.Engine {
 	router := gin.New()
 
@@ -169,6 +257,11 @@ func setupRouter(
 		userHandler.RegisterRoutes(v1)
 		catalogHandler.RegisterRoutes(v1)
 		orderHandler.RegisterRoutes(v1)
+		cartHandler.RegisterRoutes(v1)
+		schedulingHandler.RegisterRoutes(v1)
+		reviewHandler.RegisterRoutes(v1)
+		receiptHandler.RegisterRoutes(v1)
+		searchHandler.RegisterRoutes(v1)
 	}
 
 	return router
@@ -7,18 +7,23 @@ import (
 	"fmt"
 	"math/big"
 	"time"
+
 	"Laman/internal/models"
 	"Laman/internal/users"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 // AuthService обрабатывает бизнес-логику, связанную с аутентификацией,
 // включая верификацию телефона и генерацию JWT токенов.
 type AuthService struct {
-	authRepo AuthRepository
-	userRepo UserRepository
-	jwtSecret string
+	authRepo    AuthRepository
+	userRepo    UserRepository
+	guestOrders GuestOrderClaimer
+	jwtSecret   string
+	logger      *zap.Logger
 }
 
 // UserRepository определяет интерфейс, необходимый из модуля users.
@@ -27,15 +32,29 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 }
 
+// GuestOrderClaimer определяет интерфейс, необходимый из модуля orders,
+// для привязки гостевых заказов к пользователю, подтвердившему телефон.
+type GuestOrderClaimer interface {
+	ClaimGuestOrders(ctx context.Context, userID uuid.UUID, phone string) (int, error)
+}
+
 // NewAuthService создает новый сервис аутентификации.
-func NewAuthService(authRepo AuthRepository, userRepo UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(authRepo AuthRepository, userRepo UserRepository, jwtSecret string, logger *zap.Logger) *AuthService {
 	return &AuthService{
 		authRepo:  authRepo,
 		userRepo:  userRepo,
 		jwtSecret: jwtSecret,
+		logger:    logger,
 	}
 }
 
+// SetGuestOrderClaimer подключает привязку гостевых заказов при входе.
+// Сервис заказов создается позже сервиса аутентификации, поэтому
+// зависимость передается отдельно.
+func (s *AuthService) SetGuestOrderClaimer(claimer GuestOrderClaimer) {
+	s.guestOrders = claimer
+}
+
 // SendCodeRequest представляет запрос на отправку кода верификации.
 type SendCodeRequest struct {
 	Phone string `json:"phone" binding:"required"`
@@ -49,8 +68,10 @@ type VerifyCodeRequest struct {
 
 // AuthResponse представляет ответ аутентификации.
 type AuthResponse struct {
-	Token string      `json:"token"`
+	Token string       `json:"token"`
 	User  *models.User `json:"user"`
+	// ClaimedOrders — число гостевых заказов, привязанных к пользователю при этом входе.
+	ClaimedOrders int `json:"claimed_orders,omitempty"`
 }
 
 // SendCode отправляет код верификации на номер телефона.
@@ -106,6 +127,7 @@ func (s *AuthService) VerifyCode(ctx context.Context, req VerifyCodeRequest) (*A
 		user = &models.User{
 			ID:        uuid.New(),
 			Phone:     req.Phone,
+			Role:      models.UserRoleCustomer,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
@@ -120,9 +142,28 @@ func (s *AuthService) VerifyCode(ctx context.Context, req VerifyCodeRequest) (*A
 		return nil, fmt.Errorf("не удалось сгенерировать токен: %w", err)
 	}
 
+	// Телефон подтвержден кодом, поэтому гостевые заказы с этим номером
+	// переходят пользователю. Ошибка привязки не мешает входу:
+	// заказы привяжутся при следующей верификации.
+	var claimed int
+	if s.guestOrders != nil {
+		n, err := s.guestOrders.ClaimGuestOrders(ctx, user.ID, user.Phone)
+		if err != nil {
+			if s.logger != nil {
+				s.logger.Error("Не удалось привязать гостевые заказы",
+					zap.String("user_id", user.ID.String()),
+					zap.Error(err),
+				)
+			}
+		} else {
+			claimed = n
+		}
+	}
+
 	return &AuthResponse{
-		Token: token,
-		User:  user,
+		Token:         token,
+		User:          user,
+		ClaimedOrders: claimed,
 	}, nil
 }
 
@@ -130,8 +171,8 @@ func (s *AuthService) VerifyCode(ctx context.Context, req VerifyCodeRequest) (*A
 func (s *AuthService) generateToken(userID uuid.UUID) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID.String(),
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-		"iat":      time.Now().Unix(),
+		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"iat":     time.Now().Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -170,12 +211,12 @@ func (s *AuthService) ValidateToken(tokenString string) (uuid.UUID, error) {
 func generateCode(length int) (string, error) {
 	max := big.NewInt(10)
 	max.Exp(max, big.NewInt(int64(length)), nil)
-	
+
 	n, err := rand.Int(rand.Reader, max)
 	if err != nil {
 		return "", err
 	}
-	
+
 	code := fmt.Sprintf("%0*d", length, n)
 	return code, nil
 }
@@ -0,0 +1,180 @@
+package cart
+
+import (
+	"errors"
+	"net/http"
+
+	"Laman/internal/orders"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// guestTokenHeader передает токен гостевой корзины между клиентом и сервером.
+const guestTokenHeader = "X-Cart-Token"
+
+// Handler обрабатывает HTTP запросы для корзины.
+type Handler struct {
+	cartService *CartService
+	authService AuthService
+}
+
+// AuthService определяет интерфейс, необходимый из модуля auth.
+type AuthService interface {
+	ValidateToken(token string) (uuid.UUID, error)
+}
+
+// NewHandler создает новый обработчик корзины.
+func NewHandler(cartService *CartService, authService AuthService) *Handler {
+	return &Handler{
+		cartService: cartService,
+		authService: authService,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты корзины.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	cart := router.Group("/cart")
+	{
+		cart.GET("", h.GetCart)
+		cart.DELETE("", h.ClearCart)
+		cart.POST("/items", h.AddItem)
+		cart.PUT("/items/:product_id", h.UpdateItem)
+		cart.DELETE("/items/:product_id", h.RemoveItem)
+		cart.POST("/checkout", h.Checkout)
+	}
+}
+
+// GetCart обрабатывает GET /cart
+func (h *Handler) GetCart(c *gin.Context) {
+	view, err := h.cartService.GetCart(c.Request.Context(), h.owner(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, view)
+}
+
+// AddItem обрабатывает POST /cart/items
+func (h *Handler) AddItem(c *gin.Context) {
+	var req AddItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view, err := h.cartService.AddItem(c.Request.Context(), h.owner(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, view)
+}
+
+// UpdateItem обрабатывает PUT /cart/items/:product_id
+func (h *Handler) UpdateItem(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+		return
+	}
+
+	var req UpdateItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view, err := h.cartService.UpdateItem(c.Request.Context(), h.owner(c), productID, req)
+	if errors.Is(err, ErrCartNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, view)
+}
+
+// RemoveItem обрабатывает DELETE /cart/items/:product_id
+func (h *Handler) RemoveItem(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+		return
+	}
+
+	view, err := h.cartService.RemoveItem(c.Request.Context(), h.owner(c), productID)
+	if errors.Is(err, ErrCartNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, view)
+}
+
+// ClearCart обрабатывает DELETE /cart
+func (h *Handler) ClearCart(c *gin.Context) {
+	if err := h.cartService.Clear(c.Request.Context(), h.owner(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "корзина очищена"})
+}
+
+// Checkout обрабатывает POST /cart/checkout
+func (h *Handler) Checkout(c *gin.Context) {
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkout, err := h.cartService.Checkout(c.Request.Context(), h.owner(c), req)
+	if errors.Is(err, ErrCartNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, orders.ErrEvaluationFailed) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkout)
+}
+
+// owner определяет владельца корзины: аутентифицированного пользователя
+// или гостя по заголовку X-Cart-Token.
+func (h *Handler) owner(c *gin.Context) Owner {
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			userID, err := h.authService.ValidateToken(authHeader[7:])
+			if err == nil {
+				return Owner{UserID: &userID}
+			}
+		}
+	}
+
+	return Owner{GuestToken: c.GetHeader(guestTokenHeader)}
+}
+
+// respond отправляет корзину и возвращает токен гостевой корзины в заголовке.
+func (h *Handler) respond(c *gin.Context, status int, view *CartView) {
+	if view.GuestToken != nil {
+		c.Header(guestTokenHeader, *view.GuestToken)
+	}
+	c.JSON(status, view)
+}
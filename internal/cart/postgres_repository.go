@@ -0,0 +1,103 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// postgresCartRepository реализует CartRepository используя PostgreSQL.
+type postgresCartRepository struct {
+	db *database.DB
+}
+
+// NewPostgresCartRepository создает новый PostgreSQL репозиторий корзин.
+func NewPostgresCartRepository(db *database.DB) CartRepository {
+	return &postgresCartRepository{db: db}
+}
+
+func (r *postgresCartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	query := `
+		INSERT INTO carts (id, user_id, guest_token, created_at, updated_at)
+		VALUES (:id, :user_id, :guest_token, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, cart)
+	return err
+}
+
+func (r *postgresCartRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	query := `SELECT id, user_id, guest_token, created_at, updated_at FROM carts WHERE user_id = $1`
+	err := r.db.Conn(ctx).GetContext(ctx, &cart, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrCartNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *postgresCartRepository) GetByGuestToken(ctx context.Context, token string) (*models.Cart, error) {
+	var cart models.Cart
+	query := `SELECT id, user_id, guest_token, created_at, updated_at FROM carts WHERE guest_token = $1`
+	err := r.db.Conn(ctx).GetContext(ctx, &cart, query, token)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrCartNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *postgresCartRepository) Touch(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE carts SET updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+// postgresCartItemRepository реализует CartItemRepository используя PostgreSQL.
+type postgresCartItemRepository struct {
+	db *database.DB
+}
+
+// NewPostgresCartItemRepository создает новый PostgreSQL репозиторий позиций корзины.
+func NewPostgresCartItemRepository(db *database.DB) CartItemRepository {
+	return &postgresCartItemRepository{db: db}
+}
+
+func (r *postgresCartItemRepository) GetByCartID(ctx context.Context, cartID uuid.UUID) ([]models.CartItem, error) {
+	var items []models.CartItem
+	query := `SELECT id, cart_id, product_id, quantity, created_at, updated_at FROM cart_items WHERE cart_id = $1 ORDER BY created_at`
+	err := r.db.Conn(ctx).SelectContext(ctx, &items, query, cartID)
+	return items, err
+}
+
+func (r *postgresCartItemRepository) Upsert(ctx context.Context, item *models.CartItem) error {
+	query := `
+		INSERT INTO cart_items (id, cart_id, product_id, quantity, created_at, updated_at)
+		VALUES (:id, :cart_id, :product_id, :quantity, :created_at, :updated_at)
+		ON CONFLICT (cart_id, product_id)
+		DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, item)
+	return err
+}
+
+func (r *postgresCartItemRepository) Delete(ctx context.Context, cartID, productID uuid.UUID) error {
+	query := `DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, cartID, productID)
+	return err
+}
+
+func (r *postgresCartItemRepository) DeleteByCartID(ctx context.Context, cartID uuid.UUID) error {
+	query := `DELETE FROM cart_items WHERE cart_id = $1`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, cartID)
+	return err
+}
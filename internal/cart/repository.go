@@ -0,0 +1,44 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCartNotFound = errors.New("cart not found")
+)
+
+// CartRepository определяет интерфейс для доступа к данным корзин.
+type CartRepository interface {
+	// Create создает новую корзину.
+	Create(ctx context.Context, cart *models.Cart) error
+
+	// GetByUserID получает корзину пользователя.
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error)
+
+	// GetByGuestToken получает гостевую корзину по токену.
+	GetByGuestToken(ctx context.Context, token string) (*models.Cart, error)
+
+	// Touch обновляет время последнего изменения корзины.
+	Touch(ctx context.Context, id uuid.UUID) error
+}
+
+// CartItemRepository определяет интерфейс для доступа к позициям корзины.
+type CartItemRepository interface {
+	// GetByCartID получает все позиции корзины.
+	GetByCartID(ctx context.Context, cartID uuid.UUID) ([]models.CartItem, error)
+
+	// Upsert добавляет позицию или заменяет количество существующей.
+	Upsert(ctx context.Context, item *models.CartItem) error
+
+	// Delete удаляет товар из корзины.
+	Delete(ctx context.Context, cartID, productID uuid.UUID) error
+
+	// DeleteByCartID удаляет все позиции корзины.
+	DeleteByCartID(ctx context.Context, cartID uuid.UUID) error
+}
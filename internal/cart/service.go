@@ -0,0 +1,424 @@
+package cart
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"Laman/internal/orders"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CartService обрабатывает бизнес-логику корзины: изменение позиций,
+// пересчет цен по каталогу и оформление заказа из корзины.
+type CartService struct {
+	cartRepo     CartRepository
+	cartItemRepo CartItemRepository
+	productRepo  ProductRepository
+	orderCreator OrderCreator
+	logger       *zap.Logger
+}
+
+// ProductRepository определяет интерфейс, необходимый из модуля catalog.
+type ProductRepository interface {
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Product, error)
+}
+
+// OrderCreator определяет интерфейс, необходимый из модуля orders.
+type OrderCreator interface {
+	CreateCheckout(ctx context.Context, req orders.CreateCheckoutRequest) (*models.CheckoutGroupWithOrders, error)
+}
+
+// NewCartService создает новый сервис корзины.
+func NewCartService(
+	cartRepo CartRepository,
+	cartItemRepo CartItemRepository,
+	productRepo ProductRepository,
+	orderCreator OrderCreator,
+	logger *zap.Logger,
+) *CartService {
+	return &CartService{
+		cartRepo:     cartRepo,
+		cartItemRepo: cartItemRepo,
+		productRepo:  productRepo,
+		orderCreator: orderCreator,
+		logger:       logger,
+	}
+}
+
+// Owner идентифицирует владельца корзины: пользователя или гостя по токену.
+type Owner struct {
+	UserID     *uuid.UUID
+	GuestToken string
+}
+
+// CartView представляет корзину с актуальными ценами из каталога.
+// StoreID заполнен, только если все товары корзины из одного магазина.
+type CartView struct {
+	ID         *uuid.UUID   `json:"id,omitempty"`
+	GuestToken *string      `json:"guest_token,omitempty"`
+	StoreID    *uuid.UUID   `json:"store_id,omitempty"`
+	Items      []CartLine   `json:"items"`
+	ItemsTotal models.Money `json:"items_total"`
+	UpdatedAt  *time.Time   `json:"updated_at,omitempty"`
+}
+
+// CartLine представляет позицию корзины с текущей ценой товара.
+type CartLine struct {
+	ProductID   uuid.UUID    `json:"product_id"`
+	Name        string       `json:"name"`
+	Price       models.Money `json:"price"`
+	Quantity    int          `json:"quantity"`
+	LineTotal   models.Money `json:"line_total"`
+	IsAvailable bool         `json:"is_available"`
+}
+
+// AddItemRequest представляет запрос на добавление товара в корзину.
+type AddItemRequest struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,min=1"`
+}
+
+// UpdateItemRequest представляет запрос на изменение количества товара.
+// Нулевое количество удаляет товар из корзины.
+type UpdateItemRequest struct {
+	Quantity int `json:"quantity" binding:"min=0"`
+}
+
+// CheckoutRequest представляет запрос на оформление заказа из корзины.
+type CheckoutRequest struct {
+	GuestName       *string                     `json:"guest_name,omitempty"`
+	GuestPhone      *string                     `json:"guest_phone,omitempty"`
+	GuestAddress    *string                     `json:"guest_address,omitempty"`
+	Comment         *string                     `json:"comment,omitempty"`
+	PaymentMethod   models.PaymentMethod        `json:"payment_method" binding:"required"`
+	DeliveryAddress string                      `json:"delivery_address" binding:"required"`
+	DeliveryMode    models.CheckoutDeliveryMode `json:"delivery_mode,omitempty" binding:"omitempty,oneof=PER_ORDER COMBINED"`
+}
+
+// GetCart возвращает корзину владельца. Если корзины нет, возвращается пустая.
+func (s *CartService) GetCart(ctx context.Context, owner Owner) (*CartView, error) {
+	cart, err := s.findCart(ctx, owner)
+	if errors.Is(err, ErrCartNotFound) {
+		return &CartView{Items: []CartLine{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	return s.buildView(ctx, cart)
+}
+
+// AddItem добавляет товар в корзину, увеличивая количество, если он уже есть.
+func (s *CartService) AddItem(ctx context.Context, owner Owner, req AddItemRequest) (*CartView, error) {
+	cart, err := s.getOrCreateCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.cartItemRepo.GetByCartID(ctx, cart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары корзины: %w", err)
+	}
+
+	quantity := req.Quantity
+	for _, item := range items {
+		if item.ProductID == req.ProductID {
+			quantity += item.Quantity
+		}
+	}
+
+	if err := s.validateProduct(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	if err := s.setQuantity(ctx, cart.ID, req.ProductID, quantity); err != nil {
+		return nil, err
+	}
+
+	return s.buildView(ctx, cart)
+}
+
+// UpdateItem задает количество товара в корзине.
+func (s *CartService) UpdateItem(ctx context.Context, owner Owner, productID uuid.UUID, req UpdateItemRequest) (*CartView, error) {
+	if req.Quantity == 0 {
+		return s.RemoveItem(ctx, owner, productID)
+	}
+
+	cart, err := s.findCart(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	items, err := s.cartItemRepo.GetByCartID(ctx, cart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары корзины: %w", err)
+	}
+
+	found := false
+	for _, item := range items {
+		if item.ProductID == productID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("товар отсутствует в корзине")
+	}
+
+	if err := s.setQuantity(ctx, cart.ID, productID, req.Quantity); err != nil {
+		return nil, err
+	}
+
+	return s.buildView(ctx, cart)
+}
+
+// RemoveItem удаляет товар из корзины.
+func (s *CartService) RemoveItem(ctx context.Context, owner Owner, productID uuid.UUID) (*CartView, error) {
+	cart, err := s.findCart(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	if err := s.cartItemRepo.Delete(ctx, cart.ID, productID); err != nil {
+		return nil, fmt.Errorf("не удалось удалить товар из корзины: %w", err)
+	}
+	if err := s.cartRepo.Touch(ctx, cart.ID); err != nil {
+		return nil, fmt.Errorf("не удалось обновить корзину: %w", err)
+	}
+
+	return s.buildView(ctx, cart)
+}
+
+// Clear удаляет все товары из корзины.
+func (s *CartService) Clear(ctx context.Context, owner Owner) error {
+	cart, err := s.findCart(ctx, owner)
+	if errors.Is(err, ErrCartNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	if err := s.cartItemRepo.DeleteByCartID(ctx, cart.ID); err != nil {
+		return fmt.Errorf("не удалось очистить корзину: %w", err)
+	}
+	return s.cartRepo.Touch(ctx, cart.ID)
+}
+
+// Checkout оформляет содержимое корзины и очищает ее. Товары разных
+// магазинов оформляются одним чекаутом с подзаказом на каждый магазин.
+func (s *CartService) Checkout(ctx context.Context, owner Owner, req CheckoutRequest) (*models.CheckoutGroupWithOrders, error) {
+	cart, err := s.findCart(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	items, err := s.cartItemRepo.GetByCartID(ctx, cart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары корзины: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("корзина пуста")
+	}
+
+	checkout, err := s.orderCreator.CreateCheckout(ctx, buildCheckoutRequest(owner, items, req))
+	if err != nil {
+		return nil, err
+	}
+
+	// Заказ уже создан, поэтому ошибка очистки корзины не должна его скрывать
+	if err := s.cartItemRepo.DeleteByCartID(ctx, cart.ID); err != nil && s.logger != nil {
+		s.logger.Warn("Не удалось очистить корзину после оформления заказа",
+			zap.String("cart_id", cart.ID.String()), zap.Error(err))
+	}
+
+	return checkout, nil
+}
+
+// buildCheckoutRequest превращает содержимое корзины в запрос на оформление.
+func buildCheckoutRequest(owner Owner, items []models.CartItem, req CheckoutRequest) orders.CreateCheckoutRequest {
+	orderItems := make([]orders.CreateOrderItemRequest, 0, len(items))
+	for _, item := range items {
+		orderItems = append(orderItems, orders.CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	return orders.CreateCheckoutRequest{
+		CreateOrderRequest: orders.CreateOrderRequest{
+			UserID:          owner.UserID,
+			GuestName:       req.GuestName,
+			GuestPhone:      req.GuestPhone,
+			GuestAddress:    req.GuestAddress,
+			Comment:         req.Comment,
+			Items:           orderItems,
+			PaymentMethod:   req.PaymentMethod,
+			DeliveryAddress: req.DeliveryAddress,
+		},
+		DeliveryMode: req.DeliveryMode,
+	}
+}
+
+// validateProduct проверяет, что товар существует и доступен.
+// Товары разных магазинов допускаются: при оформлении они разбиваются на подзаказы.
+func (s *CartService) validateProduct(ctx context.Context, productID uuid.UUID) error {
+	products, err := s.productRepo.GetByIDs(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("товар не найден: %s", productID)
+	}
+	if !products[0].IsAvailable {
+		return fmt.Errorf("товар недоступен: %s", products[0].Name)
+	}
+
+	return nil
+}
+
+func (s *CartService) setQuantity(ctx context.Context, cartID, productID uuid.UUID, quantity int) error {
+	now := time.Now()
+	item := &models.CartItem{
+		ID:        uuid.New(),
+		CartID:    cartID,
+		ProductID: productID,
+		Quantity:  quantity,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.cartItemRepo.Upsert(ctx, item); err != nil {
+		return fmt.Errorf("не удалось сохранить товар корзины: %w", err)
+	}
+	if err := s.cartRepo.Touch(ctx, cartID); err != nil {
+		return fmt.Errorf("не удалось обновить корзину: %w", err)
+	}
+	return nil
+}
+
+// buildView пересчитывает корзину по текущим ценам и доступности товаров.
+func (s *CartService) buildView(ctx context.Context, cart *models.Cart) (*CartView, error) {
+	items, err := s.cartItemRepo.GetByCartID(ctx, cart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары корзины: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+
+	productMap := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	view := &CartView{
+		ID:         &cart.ID,
+		GuestToken: cart.GuestToken,
+		Items:      make([]CartLine, 0, len(items)),
+		UpdatedAt:  &cart.UpdatedAt,
+	}
+
+	mixedStores := false
+	for _, item := range items {
+		product, ok := productMap[item.ProductID]
+		if !ok {
+			continue
+		}
+
+		if view.StoreID == nil {
+			storeID := product.StoreID
+			view.StoreID = &storeID
+		} else if *view.StoreID != product.StoreID {
+			mixedStores = true
+		}
+
+		line := CartLine{
+			ProductID:   product.ID,
+			Name:        product.Name,
+			Price:       product.Price,
+			Quantity:    item.Quantity,
+			LineTotal:   product.Price.Mul(item.Quantity),
+			IsAvailable: product.IsAvailable,
+		}
+		if line.IsAvailable {
+			view.ItemsTotal += line.LineTotal
+		}
+		view.Items = append(view.Items, line)
+	}
+	if mixedStores {
+		view.StoreID = nil
+	}
+
+	return view, nil
+}
+
+func (s *CartService) findCart(ctx context.Context, owner Owner) (*models.Cart, error) {
+	if owner.UserID != nil {
+		return s.cartRepo.GetByUserID(ctx, *owner.UserID)
+	}
+	if owner.GuestToken == "" {
+		return nil, fmt.Errorf("%w", ErrCartNotFound)
+	}
+	return s.cartRepo.GetByGuestToken(ctx, owner.GuestToken)
+}
+
+// getOrCreateCart возвращает корзину владельца, создавая ее при первом обращении.
+// Для гостя без токена генерируется новый токен.
+func (s *CartService) getOrCreateCart(ctx context.Context, owner Owner) (*models.Cart, error) {
+	cart, err := s.findCart(ctx, owner)
+	if err == nil {
+		return cart, nil
+	}
+	if !errors.Is(err, ErrCartNotFound) {
+		return nil, fmt.Errorf("не удалось получить корзину: %w", err)
+	}
+
+	now := time.Now()
+	cart = &models.Cart{
+		ID:        uuid.New(),
+		UserID:    owner.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if owner.UserID == nil {
+		token, err := generateGuestToken()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось сгенерировать токен корзины: %w", err)
+		}
+		cart.GuestToken = &token
+	}
+
+	if err := s.cartRepo.Create(ctx, cart); err != nil {
+		// Параллельный запрос мог создать корзину пользователя раньше
+		if existing, findErr := s.findCart(ctx, owner); findErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("не удалось создать корзину: %w", err)
+	}
+
+	return cart, nil
+}
+
+// generateGuestToken генерирует случайный токен гостевой корзины.
+func generateGuestToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -1,23 +1,40 @@
 package catalog
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
+	"Laman/internal/middleware"
 	"Laman/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// maxImportFileSize ограничивает размер загружаемого прайс-листа.
+const maxImportFileSize = 10 << 20
+
 // Handler обрабатывает HTTP запросы для каталога.
 type Handler struct {
 	catalogService *CatalogService
+	authService    AuthService
+	userLoader     middleware.UserLoader
+}
+
+// AuthService определяет интерфейс, необходимый из модуля auth.
+type AuthService interface {
+	ValidateToken(token string) (uuid.UUID, error)
 }
 
 // NewHandler создает новый обработчик каталога.
-func NewHandler(catalogService *CatalogService) *Handler {
+func NewHandler(catalogService *CatalogService, authService AuthService, userLoader middleware.UserLoader) *Handler {
 	return &Handler{
 		catalogService: catalogService,
+		authService:    authService,
+		userLoader:     userLoader,
 	}
 }
 
@@ -38,6 +55,30 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		stores.GET("/:id/subcategories", h.GetStoreSubcategories)
 		stores.GET("/:id/products", h.GetStoreProducts)
 	}
+
+	auth := []gin.HandlerFunc{middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader)}
+
+	manage := router.Group("/catalog", auth...)
+	{
+		manage.POST("/categories", h.CreateCategory)
+		manage.PUT("/categories/:id", h.UpdateCategory)
+		manage.DELETE("/categories/:id", h.DeleteCategory)
+		manage.POST("/subcategories", h.CreateSubcategory)
+		manage.PUT("/subcategories/:id", h.UpdateSubcategory)
+		manage.DELETE("/subcategories/:id", h.DeleteSubcategory)
+		manage.POST("/products", h.CreateProduct)
+		manage.PUT("/products/:id", h.UpdateProduct)
+		manage.DELETE("/products/:id", h.DeleteProduct)
+	}
+
+	manageStores := router.Group("/stores", auth...)
+	{
+		manageStores.POST("", h.CreateStore)
+		manageStores.PUT("/:id", h.UpdateStore)
+		manageStores.DELETE("/:id", h.DeleteStore)
+		manageStores.POST("/:id/products/import", h.ImportProducts)
+		manageStores.GET("/:id/products/export", h.ExportProducts)
+	}
 }
 
 // GetCategories обрабатывает GET /catalog/categories
@@ -53,34 +94,33 @@ func (h *Handler) GetCategories(c *gin.Context) {
 
 // GetProducts обрабатывает GET /catalog/products
 func (h *Handler) GetProducts(c *gin.Context) {
-	var categoryID *uuid.UUID
-	if catIDStr := c.Query("category_id"); catIDStr != "" {
-		if catID, err := uuid.Parse(catIDStr); err == nil {
-			categoryID = &catID
-		}
+	req, err := parseListProductsRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	var subcategoryID *uuid.UUID
-	if subIDStr := c.Query("subcategory_id"); subIDStr != "" {
-		if subID, err := uuid.Parse(subIDStr); err == nil {
-			subcategoryID = &subID
+	if value := c.Query("store_id"); value != "" {
+		storeID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр store_id"})
+			return
 		}
+		req.StoreID = &storeID
 	}
 
-	var search *string
-	if searchStr := c.Query("search"); searchStr != "" {
-		search = &searchStr
+	// Без limit и cursor отдается массив всех товаров, как до постраничной выдачи
+	var page interface{}
+	if req.Cursor == "" && req.Limit == 0 {
+		page, err = h.catalogService.ListAllProducts(c.Request.Context(), req)
+	} else {
+		page, err = h.catalogService.ListProducts(c.Request.Context(), req)
 	}
-
-	availableOnly := c.Query("available_only") == "true"
-
-	products, err := h.catalogService.GetProductsWithFilters(c.Request.Context(), categoryID, subcategoryID, search, availableOnly)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, page)
 }
 
 // GetSubcategories обрабатывает GET /catalog/subcategories
@@ -123,7 +163,7 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
-// GetStores обрабатывает GET /stores
+// GetStores обрабатывает GET /stores?category_type=&search=&sort=name|rating
 func (h *Handler) GetStores(c *gin.Context) {
 	var categoryType *models.StoreCategoryType
 	if typeStr := c.Query("category_type"); typeStr != "" {
@@ -136,7 +176,13 @@ func (h *Handler) GetStores(c *gin.Context) {
 		search = &searchStr
 	}
 
-	stores, err := h.catalogService.GetStores(c.Request.Context(), categoryType, search)
+	sort := StoreSort(c.Query("sort"))
+
+	stores, err := h.catalogService.GetStores(c.Request.Context(), categoryType, search, sort)
+	if errors.Is(err, ErrInvalidStoreSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -187,25 +233,466 @@ func (h *Handler) GetStoreProducts(c *gin.Context) {
 		return
 	}
 
-	var subcategoryID *uuid.UUID
-	if subIDStr := c.Query("subcategory_id"); subIDStr != "" {
-		if subID, err := uuid.Parse(subIDStr); err == nil {
-			subcategoryID = &subID
+	req, err := parseListProductsRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var page interface{}
+	if req.Cursor == "" && req.Limit == 0 {
+		page, err = h.catalogService.ListAllStoreProducts(c.Request.Context(), storeID, req)
+	} else {
+		page, err = h.catalogService.ListStoreProducts(c.Request.Context(), storeID, req)
+	}
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseListProductsRequest разбирает общие параметры списков товаров.
+// Неверные ID категории и подкатегории по-прежнему игнорируются.
+func parseListProductsRequest(c *gin.Context) (ListProductsRequest, error) {
+	req := ListProductsRequest{
+		Sort:          ProductSort(c.Query("sort")),
+		Cursor:        c.Query("cursor"),
+		AvailableOnly: c.Query("available_only") == "true",
+	}
+
+	if value := c.Query("category_id"); value != "" {
+		if categoryID, err := uuid.Parse(value); err == nil {
+			req.CategoryID = &categoryID
+		}
+	}
+	if value := c.Query("subcategory_id"); value != "" {
+		if subcategoryID, err := uuid.Parse(value); err == nil {
+			req.SubcategoryID = &subcategoryID
 		}
 	}
+	if value := c.Query("search"); value != "" {
+		req.Search = &value
+	}
+	if value := c.Query("min_price"); value != "" {
+		price, err := models.ParseMoney(value)
+		if err != nil {
+			return req, errors.New("неверный параметр min_price")
+		}
+		req.MinPrice = &price
+	}
+	if value := c.Query("max_price"); value != "" {
+		price, err := models.ParseMoney(value)
+		if err != nil {
+			return req, errors.New("неверный параметр max_price")
+		}
+		req.MaxPrice = &price
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return req, errors.New("неверный параметр limit")
+		}
+		req.Limit = limit
+	}
+	return req, nil
+}
 
-	var search *string
-	if searchStr := c.Query("search"); searchStr != "" {
-		search = &searchStr
+// respondListError отвечает 400 на неверные параметры списка товаров и 500 на остальные ошибки.
+func respondListError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCursor), errors.Is(err, ErrInvalidProductSort),
+		errors.Is(err, ErrInvalidPriceRange), errors.Is(err, ErrRelevanceWithoutSearch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateCategory обрабатывает POST /catalog/categories
+func (h *Handler) CreateCategory(c *gin.Context) {
+	actor, ok := requireActor(c)
+	if !ok {
+		return
 	}
 
-	availableOnly := c.Query("available_only") == "true"
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	products, err := h.catalogService.GetStoreProducts(c.Request.Context(), storeID, subcategoryID, search, availableOnly)
+	category, err := h.catalogService.CreateCategory(c.Request.Context(), req, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory обрабатывает PUT /catalog/categories/:id
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID категории"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.catalogService.UpdateCategory(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory обрабатывает DELETE /catalog/categories/:id
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID категории"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.catalogService.DeleteCategory(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateSubcategory обрабатывает POST /catalog/subcategories
+func (h *Handler) CreateSubcategory(c *gin.Context) {
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req SubcategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subcategory, err := h.catalogService.CreateSubcategory(c.Request.Context(), req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subcategory)
+}
+
+// UpdateSubcategory обрабатывает PUT /catalog/subcategories/:id
+func (h *Handler) UpdateSubcategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID подкатегории"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req SubcategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subcategory, err := h.catalogService.UpdateSubcategory(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subcategory)
+}
+
+// DeleteSubcategory обрабатывает DELETE /catalog/subcategories/:id
+func (h *Handler) DeleteSubcategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID подкатегории"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.catalogService.DeleteSubcategory(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateProduct обрабатывает POST /catalog/products
+func (h *Handler) CreateProduct(c *gin.Context) {
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.catalogService.CreateProduct(c.Request.Context(), req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// UpdateProduct обрабатывает PUT /catalog/products/:id
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.catalogService.UpdateProduct(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// DeleteProduct обрабатывает DELETE /catalog/products/:id
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.catalogService.DeleteProduct(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateStore обрабатывает POST /stores
+func (h *Handler) CreateStore(c *gin.Context) {
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	store, err := h.catalogService.CreateStore(c.Request.Context(), req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, store)
+}
+
+// UpdateStore обрабатывает PUT /stores/:id
+func (h *Handler) UpdateStore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	var req StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	store, err := h.catalogService.UpdateStore(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, store)
+}
+
+// DeleteStore обрабатывает DELETE /stores/:id
+func (h *Handler) DeleteStore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.catalogService.DeleteStore(c.Request.Context(), id, actor); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ImportProducts обрабатывает POST /stores/:id/products/import?dry_run=true
+// Файл CSV или XLSX передается в поле file формы multipart/form-data.
+func (h *Handler) ImportProducts(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не передан файл или он больше 10 МБ"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.catalogService.ImportProducts(c.Request.Context(), storeID, data, dryRun, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.DryRun && !report.Applied {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, report)
+}
+
+// ExportProducts обрабатывает GET /stores/:id/products/export?format=csv|xlsx
+func (h *Handler) ExportProducts(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	format := SpreadsheetFormat(c.DefaultQuery("format", string(SpreadsheetCSV)))
+	if !format.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр format"})
+		return
+	}
+
+	actor, ok := requireActor(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.catalogService.ExportProducts(c.Request.Context(), storeID, format, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products-%s.%s"`, storeID.String()[:8], format))
+	c.Data(http.StatusOK, format.ContentType(), data)
+}
+
+// requireActor возвращает пользователя запроса или отвечает 401, если его нет.
+func requireActor(c *gin.Context) (models.Actor, bool) {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+	}
+	return actor, ok
+}
+
+// respondError переводит ошибку управления каталогом в HTTP-ответ. Ошибка,
+// которая не относится ни к одной известной категории, считается внутренней.
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCategoryNotFound), errors.Is(err, ErrSubcategoryNotFound),
+		errors.Is(err, ErrProductNotFound), errors.Is(err, ErrStoreNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCategoryInUse), errors.Is(err, ErrStoreInUse), errors.Is(err, ErrDuplicateSKU):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidSpreadsheet):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,645 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Столбцы файла товаров. Экспорт пишет их в этом порядке, импорт находит
+// по заголовку в любом порядке.
+const (
+	columnSKU         = "sku"
+	columnName        = "name"
+	columnCategory    = "category"
+	columnSubcategory = "subcategory"
+	columnPrice       = "price"
+	columnWeight      = "weight"
+	columnAvailable   = "available"
+	columnStock       = "stock"
+	columnDescription = "description"
+)
+
+var productColumns = []string{
+	columnSKU, columnName, columnCategory, columnSubcategory, columnPrice,
+	columnWeight, columnAvailable, columnStock, columnDescription,
+}
+
+// productColumnAliases — русские заголовки, которые встречаются в прайс-листах магазинов.
+var productColumnAliases = map[string]string{
+	"артикул":      columnSKU,
+	"название":     columnName,
+	"наименование": columnName,
+	"категория":    columnCategory,
+	"подкатегория": columnSubcategory,
+	"цена":         columnPrice,
+	"вес":          columnWeight,
+	"доступен":     columnAvailable,
+	"в наличии":    columnAvailable,
+	"остаток":      columnStock,
+	"описание":     columnDescription,
+}
+
+// numericProductColumns — столбцы, которые в XLSX записываются числами.
+var numericProductColumns = map[int]bool{4: true, 5: true, 7: true}
+
+// maxImportRows ограничивает число товаров в одном файле: импорт идет
+// одной транзакцией и блокирует обновляемые товары.
+const maxImportRows = 5000
+
+// maxImportColumns ограничивает число столбцов: кроме столбцов товара в
+// прайс-листе бывают служебные, которые импорт пропускает.
+var maxImportColumns = len(productColumns) + 50
+
+// ImportAction — что импорт делает со строкой файла.
+type ImportAction string
+
+const (
+	ImportActionCreate    ImportAction = "create"
+	ImportActionUpdate    ImportAction = "update"
+	ImportActionUnchanged ImportAction = "unchanged"
+	ImportActionError     ImportAction = "error"
+)
+
+// FieldChange представляет изменение столбца в формате файла.
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ImportRowResult представляет результат разбора строки файла.
+type ImportRowResult struct {
+	Row       int                    `json:"row"` // номер строки в файле, заголовок — строка 1
+	SKU       string                 `json:"sku"`
+	Action    ImportAction           `json:"action"`
+	ProductID *uuid.UUID             `json:"product_id,omitempty"`
+	Changes   map[string]FieldChange `json:"changes,omitempty"`
+	Errors    []string               `json:"errors,omitempty"`
+}
+
+// ImportReport представляет отчет об импорте товаров.
+type ImportReport struct {
+	DryRun         bool              `json:"dry_run"`
+	Applied        bool              `json:"applied"`
+	Created        int               `json:"created"`
+	Updated        int               `json:"updated"`
+	Unchanged      int               `json:"unchanged"`
+	Failed         int               `json:"failed"`
+	IgnoredColumns []string          `json:"ignored_columns,omitempty"`
+	Rows           []ImportRowResult `json:"rows"`
+}
+
+// importPlan — товар, который будет создан или обновлен.
+type importPlan struct {
+	product *models.Product
+	create  bool
+}
+
+// ImportProducts загружает прайс-лист магазина из CSV или XLSX. Товары
+// находятся по артикулу: существующие обновляются, новые создаются, товары,
+// которых нет в файле, не меняются. Отсутствующий столбец сохраняет текущее
+// значение, пустая ячейка очищает необязательное поле.
+//
+// Файл применяется целиком или не применяется совсем: если хотя бы в одной
+// строке ошибка, отчет возвращается с Applied = false. При dryRun отчет
+// только показывает изменения.
+func (s *CatalogService) ImportProducts(ctx context.Context, storeID uuid.UUID, data []byte, dryRun bool, actor models.Actor) (*ImportReport, error) {
+	if !canManageStore(actor, storeID) {
+		return nil, ErrForbidden
+	}
+
+	rows, err := readSpreadsheet(data)
+	if err != nil {
+		return nil, err
+	}
+	columns, ignored, err := parseImportHeader(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Errorf("%w: в файле больше %d строк", ErrInvalidSpreadsheet, maxImportRows)
+	}
+
+	report := &ImportReport{DryRun: dryRun, IgnoredColumns: ignored, Rows: []ImportRowResult{}}
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.storeRepo.GetByIDForUpdate(ctx, storeID, true); err != nil {
+			return err
+		}
+
+		// Сначала находим все артикулы файла, чтобы заблокировать
+		// существующие товары одним запросом
+		skus := make([]string, 0, len(rows)-1)
+		for _, record := range rows[1:] {
+			if sku := cellValue(record, columns, columnSKU); sku != "" {
+				skus = append(skus, sku)
+			}
+		}
+		existing, err := s.productRepo.GetBySKUsForUpdate(ctx, storeID, skus)
+		if err != nil {
+			return fmt.Errorf("не удалось получить товары магазина: %w", err)
+		}
+		bySKU := make(map[string]*models.Product, len(existing))
+		for i := range existing {
+			bySKU[*existing[i].SKU] = &existing[i]
+		}
+
+		lookup := newCatalogLookup(s)
+		seen := make(map[string]int)
+		plans := make([]importPlan, 0, len(rows)-1)
+		for i, record := range rows[1:] {
+			if isEmptyRecord(record) {
+				continue
+			}
+			line := i + 2
+			sku := cellValue(record, columns, columnSKU)
+
+			result := ImportRowResult{Row: line, SKU: sku}
+			var plan *importPlan
+			switch first, duplicate := seen[sku]; {
+			case sku == "":
+				result.Errors = []string{"не указан артикул"}
+			case utf8.RuneCountInString(sku) > maxSKULength:
+				result.Errors = []string{fmt.Sprintf("артикул длиннее %d символов", maxSKULength)}
+			case duplicate:
+				result.Errors = []string{fmt.Sprintf("артикул уже встречался в строке %d", first)}
+			default:
+				seen[sku] = line
+				plan, err = s.planImportRow(ctx, lookup, storeID, columns, record, bySKU[sku], &result)
+				if err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case len(result.Errors) > 0:
+				result.Action = ImportActionError
+				report.Failed++
+			case plan == nil:
+				result.Action = ImportActionUnchanged
+				report.Unchanged++
+			case plan.create:
+				result.Action = ImportActionCreate
+				report.Created++
+				plans = append(plans, *plan)
+			default:
+				result.Action = ImportActionUpdate
+				report.Updated++
+				plans = append(plans, *plan)
+			}
+			report.Rows = append(report.Rows, result)
+		}
+
+		if dryRun || report.Failed > 0 {
+			return nil
+		}
+		for _, plan := range plans {
+			if plan.create {
+				err = s.productRepo.Create(ctx, plan.product)
+			} else {
+				err = s.productRepo.Update(ctx, plan.product)
+			}
+			if err != nil {
+				return fmt.Errorf("не удалось сохранить товар с артикулом %s: %w", *plan.product.SKU, err)
+			}
+		}
+		report.Applied = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// planImportRow сопоставляет строку файла с товаром. Ошибки данных
+// записываются в result, возвращается только ошибка базы данных.
+// Если товар не меняется, план не возвращается.
+func (s *CatalogService) planImportRow(
+	ctx context.Context,
+	lookup *catalogLookup,
+	storeID uuid.UUID,
+	columns map[string]int,
+	record []string,
+	existing *models.Product,
+	result *ImportRowResult,
+) (*importPlan, error) {
+	req := ProductRequest{StoreID: storeID, SKU: &result.SKU}
+	if existing != nil {
+		available := existing.IsAvailable
+		req.CategoryID = existing.CategoryID
+		req.SubcategoryID = existing.SubcategoryID
+		req.Name = existing.Name
+		req.Description = existing.Description
+		req.Price = existing.Price
+		req.Weight = existing.Weight
+		req.IsAvailable = &available
+		req.Stock = existing.Stock
+	}
+
+	var errs []string
+	cell := func(column string) (string, bool) {
+		_, ok := columns[column]
+		return cellValue(record, columns, column), ok
+	}
+
+	if value, ok := cell(columnName); ok || existing == nil {
+		req.Name = value
+	}
+
+	categoryValue, ok := cell(columnCategory)
+	switch {
+	case ok && categoryValue != "":
+		category, err := lookup.category(ctx, categoryValue)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			errs = append(errs, fmt.Sprintf("категория %q не найдена", categoryValue))
+			break
+		}
+		if category.ID != req.CategoryID {
+			// Подкатегория прежней категории к новой не относится
+			req.SubcategoryID = nil
+		}
+		req.CategoryID = category.ID
+	case ok || existing == nil:
+		errs = append(errs, "не указана категория")
+	}
+
+	if value, ok := cell(columnSubcategory); ok {
+		req.SubcategoryID = nil
+		if value != "" && req.CategoryID != uuid.Nil {
+			subcategory, err := lookup.subcategory(ctx, req.CategoryID, value)
+			if err != nil {
+				return nil, err
+			}
+			if subcategory == nil {
+				errs = append(errs, fmt.Sprintf("подкатегория %q не найдена в категории товара", value))
+			} else {
+				req.SubcategoryID = &subcategory.ID
+			}
+		}
+	}
+
+	priceValue, ok := cell(columnPrice)
+	switch {
+	case ok && priceValue != "":
+		price, err := models.ParseMoney(normalizeDecimal(priceValue))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("неверная цена %q", priceValue))
+		}
+		req.Price = price
+	case ok || existing == nil:
+		errs = append(errs, "не указана цена")
+	}
+
+	if value, ok := cell(columnWeight); ok {
+		req.Weight = nil
+		if value != "" {
+			weight, err := strconv.ParseFloat(normalizeDecimal(value), 64)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("неверный вес %q", value))
+			}
+			req.Weight = &weight
+		}
+	}
+
+	if value, ok := cell(columnAvailable); ok {
+		req.IsAvailable = nil
+		if value != "" {
+			available, valid := parseAvailability(value)
+			if !valid {
+				errs = append(errs, fmt.Sprintf("неверное значение доступности %q", value))
+			}
+			req.IsAvailable = &available
+		}
+	}
+
+	if value, ok := cell(columnStock); ok {
+		req.Stock = nil
+		if value != "" {
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("неверный остаток %q", value))
+			}
+			req.Stock = &stock
+		}
+	}
+
+	if value, ok := cell(columnDescription); ok {
+		req.Description = &value
+	}
+
+	// Пополнение закончившегося остатка возвращает товар в продажу, как ReleaseStock
+	if _, ok := columns[columnAvailable]; !ok && existing != nil &&
+		existing.Stock != nil && *existing.Stock == 0 && req.Stock != nil && *req.Stock > 0 {
+		available := true
+		req.IsAvailable = &available
+	}
+
+	if len(errs) > 0 {
+		result.Errors = errs
+		return nil, nil
+	}
+
+	now := time.Now()
+	product := &models.Product{ID: uuid.New(), StoreID: storeID, CreatedAt: now}
+	if existing != nil {
+		copied := *existing
+		product = &copied
+	}
+	if err := applyProductRequest(product, req, now); err != nil {
+		result.Errors = []string{err.Error()}
+		return nil, nil
+	}
+	result.ProductID = &product.ID
+
+	// Категория и подкатегория не должны удалиться до конца импорта
+	if err := lookup.lock(ctx, product.CategoryID, product.SubcategoryID); err != nil {
+		result.Errors = []string{err.Error()}
+		return nil, nil
+	}
+
+	after, err := lookup.row(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	before := make([]string, len(productColumns))
+	if existing != nil {
+		if before, err = lookup.row(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+	for i, column := range productColumns {
+		if before[i] == after[i] {
+			continue
+		}
+		if result.Changes == nil {
+			result.Changes = make(map[string]FieldChange)
+		}
+		result.Changes[column] = FieldChange{From: before[i], To: after[i]}
+	}
+
+	if existing != nil && result.Changes == nil {
+		return nil, nil
+	}
+	return &importPlan{product: product, create: existing == nil}, nil
+}
+
+// ExportProducts выгружает неудаленные товары магазина в том же формате,
+// который принимает ImportProducts.
+func (s *CatalogService) ExportProducts(ctx context.Context, storeID uuid.UUID, format SpreadsheetFormat, actor models.Actor) ([]byte, error) {
+	if !canManageStore(actor, storeID) {
+		return nil, ErrForbidden
+	}
+	if _, err := s.storeRepo.GetByID(ctx, storeID); err != nil {
+		return nil, err
+	}
+
+	products, err := s.productRepo.List(ctx, ProductFilter{StoreID: &storeID, Sort: ProductSortName})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+
+	lookup := newCatalogLookup(s)
+	rows := make([][]string, 0, len(products)+1)
+	rows = append(rows, productColumns)
+	for i := range products {
+		row, err := lookup.row(ctx, &products[i])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	if err := writeSpreadsheet(&buf, format, rows, numericProductColumns); err != nil {
+		return nil, fmt.Errorf("не удалось сформировать файл: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// catalogLookup находит категории и подкатегории по названию и обратно.
+// Справочники загружаются один раз на импорт или экспорт.
+type catalogLookup struct {
+	service       *CatalogService
+	categories    []models.Category
+	loaded        bool
+	subcategories map[uuid.UUID][]models.Subcategory
+	locked        map[uuid.UUID]bool
+}
+
+func newCatalogLookup(service *CatalogService) *catalogLookup {
+	return &catalogLookup{
+		service:       service,
+		subcategories: make(map[uuid.UUID][]models.Subcategory),
+		locked:        make(map[uuid.UUID]bool),
+	}
+}
+
+func (l *catalogLookup) loadCategories(ctx context.Context) error {
+	if l.loaded {
+		return nil
+	}
+	categories, err := l.service.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось получить категории: %w", err)
+	}
+	l.categories = categories
+	l.loaded = true
+	return nil
+}
+
+func (l *catalogLookup) loadSubcategories(ctx context.Context, categoryID uuid.UUID) ([]models.Subcategory, error) {
+	if subcategories, ok := l.subcategories[categoryID]; ok {
+		return subcategories, nil
+	}
+	subcategories, err := l.service.subcategoryRepo.GetByCategoryID(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить подкатегории: %w", err)
+	}
+	l.subcategories[categoryID] = subcategories
+	return subcategories, nil
+}
+
+// category находит категорию по названию без учета регистра.
+func (l *catalogLookup) category(ctx context.Context, name string) (*models.Category, error) {
+	if err := l.loadCategories(ctx); err != nil {
+		return nil, err
+	}
+	for i := range l.categories {
+		if strings.EqualFold(l.categories[i].Name, name) {
+			return &l.categories[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// subcategory находит подкатегорию категории по названию без учета регистра.
+func (l *catalogLookup) subcategory(ctx context.Context, categoryID uuid.UUID, name string) (*models.Subcategory, error) {
+	subcategories, err := l.loadSubcategories(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subcategories {
+		if strings.EqualFold(subcategories[i].Name, name) {
+			return &subcategories[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// lock берет разделяемые блокировки категории и подкатегории, по одной на импорт.
+func (l *catalogLookup) lock(ctx context.Context, categoryID uuid.UUID, subcategoryID *uuid.UUID) error {
+	if !l.locked[categoryID] {
+		if _, err := l.service.categoryRepo.GetByIDForUpdate(ctx, categoryID, true); err != nil {
+			return err
+		}
+		l.locked[categoryID] = true
+	}
+	if subcategoryID != nil && !l.locked[*subcategoryID] {
+		if _, err := l.service.subcategoryRepo.GetByIDForUpdate(ctx, *subcategoryID, true); err != nil {
+			return err
+		}
+		l.locked[*subcategoryID] = true
+	}
+	return nil
+}
+
+// row возвращает товар строкой файла в порядке productColumns.
+func (l *catalogLookup) row(ctx context.Context, product *models.Product) ([]string, error) {
+	if err := l.loadCategories(ctx); err != nil {
+		return nil, err
+	}
+
+	var category, subcategory string
+	for _, c := range l.categories {
+		if c.ID == product.CategoryID {
+			category = c.Name
+			break
+		}
+	}
+	if product.SubcategoryID != nil {
+		subcategories, err := l.loadSubcategories(ctx, product.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subcategories {
+			if sub.ID == *product.SubcategoryID {
+				subcategory = sub.Name
+				break
+			}
+		}
+	}
+
+	var sku, weight, stock, description string
+	if product.SKU != nil {
+		sku = *product.SKU
+	}
+	if product.Weight != nil {
+		weight = strconv.FormatFloat(*product.Weight, 'f', -1, 64)
+	}
+	if product.Stock != nil {
+		stock = strconv.Itoa(*product.Stock)
+	}
+	if product.Description != nil {
+		description = *product.Description
+	}
+
+	return []string{
+		sku,
+		product.Name,
+		category,
+		subcategory,
+		product.Price.String(),
+		weight,
+		strconv.FormatBool(product.IsAvailable),
+		stock,
+		description,
+	}, nil
+}
+
+// parseImportHeader находит столбцы по первой строке файла. Неизвестные
+// столбцы пропускаются и возвращаются отдельно.
+func parseImportHeader(rows [][]string) (map[string]int, []string, error) {
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%w: файл пустой", ErrInvalidSpreadsheet)
+	}
+
+	known := make(map[string]bool, len(productColumns))
+	for _, column := range productColumns {
+		known[column] = true
+	}
+
+	columns := make(map[string]int)
+	var ignored []string
+	for i, title := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(title))
+		if alias, ok := productColumnAliases[name]; ok {
+			name = alias
+		}
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			ignored = append(ignored, strings.TrimSpace(title))
+			continue
+		}
+		if _, ok := columns[name]; ok {
+			return nil, nil, fmt.Errorf("%w: столбец %s указан дважды", ErrInvalidSpreadsheet, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns[columnSKU]; !ok {
+		return nil, nil, fmt.Errorf("%w: нет столбца %s", ErrInvalidSpreadsheet, columnSKU)
+	}
+	return columns, ignored, nil
+}
+
+func cellValue(record []string, columns map[string]int, column string) string {
+	i, ok := columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeDecimal приводит число из прайс-листа к виду "1299.90":
+// убирает пробелы между разрядами и заменяет десятичную запятую точкой.
+func normalizeDecimal(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' || r == '\u202f' {
+			return -1
+		}
+		return r
+	}, value)
+	return strings.Replace(value, ",", ".", 1)
+}
+
+func parseAvailability(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "да", "+":
+		return true, true
+	case "false", "0", "no", "нет", "-":
+		return false, true
+	default:
+		return false, false
+	}
+}
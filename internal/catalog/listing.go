@@ -0,0 +1,297 @@
+package catalog
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultProductsPageSize — размер страницы списка товаров по умолчанию.
+	defaultProductsPageSize = 20
+	// maxProductsPageSize — максимальный размер страницы списка товаров.
+	maxProductsPageSize = 100
+)
+
+var (
+	// ErrInvalidCursor возвращается при поврежденном курсоре страницы или
+	// курсоре, выданном для другого порядка сортировки.
+	ErrInvalidCursor = errors.New("неверный курсор страницы")
+	// ErrInvalidPriceRange возвращается при отрицательной цене или минимальной
+	// цене больше максимальной.
+	ErrInvalidPriceRange = errors.New("неверный диапазон цен")
+	// ErrRelevanceWithoutSearch возвращается при сортировке по релевантности без поиска.
+	ErrRelevanceWithoutSearch = errors.New("сортировка по релевантности доступна только при поиске")
+)
+
+// ListProductsRequest представляет фильтры и параметры страницы списка товаров.
+type ListProductsRequest struct {
+	CategoryID    *uuid.UUID
+	SubcategoryID *uuid.UUID
+	StoreID       *uuid.UUID
+	Search        *string
+	AvailableOnly bool
+	MinPrice      *models.Money
+	MaxPrice      *models.Money
+	// Sort — порядок списка; пустой означает релевантность при поиске
+	// и название без него.
+	Sort   ProductSort
+	Cursor string
+	Limit  int
+}
+
+// ProductFacets представляет счетчики товаров для фильтров списка. Каждый
+// счетчик учитывает все фильтры, кроме своего собственного.
+type ProductFacets struct {
+	Subcategories []models.FacetCount `json:"subcategories"`
+	Stores        []models.FacetCount `json:"stores,omitempty"`
+}
+
+// ProductPage представляет страницу списка товаров. NextCursor пуст на
+// последней странице, Facets заполняются только на первой.
+type ProductPage struct {
+	Products   []models.Product `json:"products"`
+	NextCursor *string          `json:"next_cursor,omitempty"`
+	Facets     *ProductFacets   `json:"facets,omitempty"`
+}
+
+// ListProducts возвращает страницу товаров каталога. Первая страница
+// содержит счетчики по подкатегориям и магазинам.
+func (s *CatalogService) ListProducts(ctx context.Context, req ListProductsRequest) (*ProductPage, error) {
+	return s.listProducts(ctx, req, true)
+}
+
+// ListStoreProducts возвращает страницу товаров магазина. Первая страница
+// содержит счетчики по подкатегориям.
+func (s *CatalogService) ListStoreProducts(ctx context.Context, storeID uuid.UUID, req ListProductsRequest) (*ProductPage, error) {
+	req.StoreID = &storeID
+	return s.listProducts(ctx, req, false)
+}
+
+func (s *CatalogService) listProducts(ctx context.Context, req ListProductsRequest, storeFacets bool) (*ProductPage, error) {
+	filter, err := newProductFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeProductCursor(req.Cursor, filter.Sort)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	}
+
+	filter.Limit = req.Limit
+	if filter.Limit <= 0 {
+		filter.Limit = defaultProductsPageSize
+	}
+	if filter.Limit > maxProductsPageSize {
+		filter.Limit = maxProductsPageSize
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	products, err := s.productRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+
+	page := &ProductPage{Products: products}
+	if page.Products == nil {
+		page.Products = []models.Product{}
+	}
+	if len(products) > limit {
+		page.Products = products[:limit]
+		cursor := encodeProductCursor(filter.Sort, page.Products[limit-1])
+		page.NextCursor = &cursor
+	}
+
+	// Счетчики не зависят от страницы, поэтому считаются один раз
+	if filter.After == nil {
+		facets := &ProductFacets{}
+		facets.Subcategories, err = s.productRepo.SubcategoryFacets(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось посчитать товары по подкатегориям: %w", err)
+		}
+		if storeFacets {
+			facets.Stores, err = s.productRepo.StoreFacets(ctx, filter)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось посчитать товары по магазинам: %w", err)
+			}
+		}
+		page.Facets = facets
+		s.logSearch(filter.Search, len(page.Products))
+	}
+	return page, nil
+}
+
+// ListAllProducts возвращает все подходящие товары каталога одним списком.
+// Нужен клиентам, которые получали список товаров до постраничной выдачи.
+func (s *CatalogService) ListAllProducts(ctx context.Context, req ListProductsRequest) ([]models.Product, error) {
+	return s.listAllProducts(ctx, req)
+}
+
+// ListAllStoreProducts возвращает все подходящие товары магазина одним списком.
+func (s *CatalogService) ListAllStoreProducts(ctx context.Context, storeID uuid.UUID, req ListProductsRequest) ([]models.Product, error) {
+	req.StoreID = &storeID
+	return s.listAllProducts(ctx, req)
+}
+
+func (s *CatalogService) listAllProducts(ctx context.Context, req ListProductsRequest) ([]models.Product, error) {
+	filter, err := newProductFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.productRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+	s.logSearch(filter.Search, len(products))
+	return products, nil
+}
+
+// newProductFilter проверяет запрос и переносит его в фильтр репозитория.
+func newProductFilter(req ListProductsRequest) (ProductFilter, error) {
+	filter := ProductFilter{
+		CategoryID:    req.CategoryID,
+		SubcategoryID: req.SubcategoryID,
+		StoreID:       req.StoreID,
+		AvailableOnly: req.AvailableOnly,
+		MinPrice:      req.MinPrice,
+		MaxPrice:      req.MaxPrice,
+		Sort:          req.Sort,
+	}
+	if req.Search != nil && strings.TrimSpace(*req.Search) != "" {
+		search := strings.TrimSpace(*req.Search)
+		filter.Search = &search
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = ProductSortName
+		if filter.Search != nil {
+			filter.Sort = ProductSortRelevance
+		}
+	}
+	if !filter.Sort.Valid() {
+		return filter, ErrInvalidProductSort
+	}
+	if filter.Sort == ProductSortRelevance && filter.Search == nil {
+		return filter, ErrRelevanceWithoutSearch
+	}
+
+	if (req.MinPrice != nil && *req.MinPrice < 0) || (req.MaxPrice != nil && *req.MaxPrice < 0) {
+		return filter, ErrInvalidPriceRange
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return filter, ErrInvalidPriceRange
+	}
+	return filter, nil
+}
+
+// productCursorValues возвращает значения ключей сортировки товара в порядке
+// productSortKeys.
+func productCursorValues(sort ProductSort, product models.Product) []string {
+	switch sort {
+	case ProductSortRelevance:
+		rank := 0.0
+		if product.SearchRank != nil {
+			rank = *product.SearchRank
+		}
+		return []string{strconv.FormatFloat(rank, 'g', -1, 64)}
+	case ProductSortPriceAsc, ProductSortPriceDesc:
+		return []string{product.Price.String()}
+	case ProductSortNewest:
+		return []string{product.CreatedAt.Format(time.RFC3339Nano)}
+	case ProductSortPopularity:
+		return []string{strconv.Itoa(product.SoldCount)}
+	case ProductSortRating:
+		return []string{strconv.FormatFloat(product.Rating, 'f', -1, 64), strconv.Itoa(product.RatingCount)}
+	default:
+		return []string{product.Name}
+	}
+}
+
+// validCursorValues проверяет, что значения курсора подходят ключам сортировки,
+// иначе поврежденный курсор дошел бы до базы.
+func validCursorValues(sort ProductSort, values []string) bool {
+	parseFloat := func(value string) bool {
+		f, err := strconv.ParseFloat(value, 64)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	parseInt := func(value string) bool {
+		_, err := strconv.Atoi(value)
+		return err == nil
+	}
+
+	var parsers []func(string) bool
+	switch sort {
+	case ProductSortRelevance:
+		parsers = append(parsers, parseFloat)
+	case ProductSortPriceAsc, ProductSortPriceDesc:
+		parsers = append(parsers, func(value string) bool {
+			_, err := models.ParseMoney(value)
+			return err == nil
+		})
+	case ProductSortNewest:
+		parsers = append(parsers, func(value string) bool {
+			_, err := time.Parse(time.RFC3339Nano, value)
+			return err == nil
+		})
+	case ProductSortPopularity:
+		parsers = append(parsers, parseInt)
+	case ProductSortRating:
+		parsers = append(parsers, parseFloat, parseInt)
+	default:
+		parsers = append(parsers, func(string) bool { return true })
+	}
+
+	if len(values) != len(parsers) {
+		return false
+	}
+	for i, parse := range parsers {
+		if !parse(values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeProductCursor кодирует позицию последнего товара страницы.
+func encodeProductCursor(sort ProductSort, product models.Product) string {
+	raw, _ := json.Marshal(ProductCursor{Sort: sort, Values: productCursorValues(sort, product), ID: product.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeProductCursor разбирает курсор, выданный encodeProductCursor для того
+// же порядка сортировки.
+func decodeProductCursor(value string, sort ProductSort) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor ProductCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.ID == uuid.Nil || !validCursorValues(sort, cursor.Values) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,505 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Ошибки управления каталогом.
+var (
+	ErrForbidden     = errors.New("недостаточно прав для управления каталогом")
+	ErrCategoryInUse = errors.New("в категории есть товары")
+	ErrStoreInUse    = errors.New("у магазина есть товары или незавершенные заказы")
+	ErrInvalidInput  = errors.New("неверные данные")
+)
+
+// Ограничения длины, как у столбцов VARCHAR.
+const (
+	maxNameLength = 255
+	maxSKULength  = 64
+)
+
+// CategoryRequest представляет данные категории.
+type CategoryRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+}
+
+// SubcategoryRequest представляет данные подкатегории.
+type SubcategoryRequest struct {
+	CategoryID uuid.UUID `json:"category_id" binding:"required"`
+	Name       string    `json:"name" binding:"required"`
+}
+
+// ProductRequest представляет данные товара. Магазин товара задается
+// при создании и не меняется. Без IsAvailable товар доступен, без Stock
+// остатки не отслеживаются.
+type ProductRequest struct {
+	CategoryID    uuid.UUID    `json:"category_id" binding:"required"`
+	SubcategoryID *uuid.UUID   `json:"subcategory_id"`
+	StoreID       uuid.UUID    `json:"store_id"`
+	SKU           *string      `json:"sku"`
+	Name          string       `json:"name" binding:"required"`
+	Description   *string      `json:"description"`
+	Price         models.Money `json:"price" binding:"required"`
+	Weight        *float64     `json:"weight"`
+	IsAvailable   *bool        `json:"is_available"`
+	Stock         *int         `json:"stock"`
+}
+
+// StoreRequest представляет данные магазина.
+type StoreRequest struct {
+	Name         string                   `json:"name" binding:"required"`
+	Address      string                   `json:"address" binding:"required"`
+	Phone        *string                  `json:"phone"`
+	Description  *string                  `json:"description"`
+	ImageURL     *string                  `json:"image_url"`
+	CategoryType models.StoreCategoryType `json:"category_type" binding:"required"`
+}
+
+// CreateCategory создает категорию. Доступно только администратору.
+func (s *CatalogService) CreateCategory(ctx context.Context, req CategoryRequest, actor models.Actor) (*models.Category, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	name, err := validateName(req.Name, "категории")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	category := &models.Category{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: optionalText(req.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return nil, fmt.Errorf("не удалось создать категорию: %w", err)
+	}
+	return category, nil
+}
+
+// UpdateCategory обновляет категорию. Доступно только администратору.
+func (s *CatalogService) UpdateCategory(ctx context.Context, id uuid.UUID, req CategoryRequest, actor models.Actor) (*models.Category, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	name, err := validateName(req.Name, "категории")
+	if err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		category, err = s.categoryRepo.GetByIDForUpdate(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		category.Name = name
+		category.Description = optionalText(req.Description)
+		category.UpdatedAt = time.Now()
+		return s.categoryRepo.Update(ctx, category)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory мягко удаляет категорию вместе с подкатегориями.
+// Категорию с неудаленными товарами удалить нельзя, как и при ON DELETE RESTRICT.
+func (s *CatalogService) DeleteCategory(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+	if actor.Role != models.UserRoleAdmin {
+		return ErrForbidden
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.categoryRepo.GetByIDForUpdate(ctx, id, false); err != nil {
+			return err
+		}
+		count, err := s.productRepo.CountByCategoryID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить товары категории: %w", err)
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+		if err := s.subcategoryRepo.SoftDeleteByCategoryID(ctx, id); err != nil {
+			return fmt.Errorf("не удалось удалить подкатегории: %w", err)
+		}
+		return s.categoryRepo.SoftDelete(ctx, id)
+	})
+}
+
+// CreateSubcategory создает подкатегорию. Доступно только администратору.
+func (s *CatalogService) CreateSubcategory(ctx context.Context, req SubcategoryRequest, actor models.Actor) (*models.Subcategory, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	name, err := validateName(req.Name, "подкатегории")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	subcategory := &models.Subcategory{
+		ID:         uuid.New(),
+		CategoryID: req.CategoryID,
+		Name:       name,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.categoryRepo.GetByIDForUpdate(ctx, req.CategoryID, true); err != nil {
+			return err
+		}
+		return s.subcategoryRepo.Create(ctx, subcategory)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subcategory, nil
+}
+
+// UpdateSubcategory обновляет подкатегорию. Подкатегорию можно перенести
+// в другую категорию только без товаров другой категории.
+func (s *CatalogService) UpdateSubcategory(ctx context.Context, id uuid.UUID, req SubcategoryRequest, actor models.Actor) (*models.Subcategory, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	name, err := validateName(req.Name, "подкатегории")
+	if err != nil {
+		return nil, err
+	}
+
+	var subcategory *models.Subcategory
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		subcategory, err = s.subcategoryRepo.GetByIDForUpdate(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		if subcategory.CategoryID != req.CategoryID {
+			if _, err := s.categoryRepo.GetByIDForUpdate(ctx, req.CategoryID, true); err != nil {
+				return err
+			}
+			// Товары подкатегории остаются в прежней категории, поэтому связь с ними снимается
+			if err := s.productRepo.ClearSubcategory(ctx, id); err != nil {
+				return fmt.Errorf("не удалось отвязать товары подкатегории: %w", err)
+			}
+		}
+		subcategory.CategoryID = req.CategoryID
+		subcategory.Name = name
+		subcategory.UpdatedAt = time.Now()
+		return s.subcategoryRepo.Update(ctx, subcategory)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subcategory, nil
+}
+
+// DeleteSubcategory мягко удаляет подкатегорию. Товары остаются в категории
+// без подкатегории, как при ON DELETE SET NULL.
+func (s *CatalogService) DeleteSubcategory(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+	if actor.Role != models.UserRoleAdmin {
+		return ErrForbidden
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.subcategoryRepo.GetByIDForUpdate(ctx, id, false); err != nil {
+			return err
+		}
+		if err := s.productRepo.ClearSubcategory(ctx, id); err != nil {
+			return fmt.Errorf("не удалось отвязать товары подкатегории: %w", err)
+		}
+		return s.subcategoryRepo.SoftDelete(ctx, id)
+	})
+}
+
+// CreateProduct создает товар. Магазин создает товары только у себя,
+// администратор — в любом магазине.
+func (s *CatalogService) CreateProduct(ctx context.Context, req ProductRequest, actor models.Actor) (*models.Product, error) {
+	if actor.Role == models.UserRoleStore && req.StoreID == uuid.Nil && actor.StoreID != nil {
+		req.StoreID = *actor.StoreID
+	}
+	if !canManageStore(actor, req.StoreID) {
+		return nil, ErrForbidden
+	}
+	if req.StoreID == uuid.Nil {
+		return nil, fmt.Errorf("%w: не указан магазин товара", ErrInvalidInput)
+	}
+
+	now := time.Now()
+	product := &models.Product{
+		ID:        uuid.New(),
+		StoreID:   req.StoreID,
+		CreatedAt: now,
+	}
+	if err := applyProductRequest(product, req, now); err != nil {
+		return nil, err
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.storeRepo.GetByIDForUpdate(ctx, req.StoreID, true); err != nil {
+			return err
+		}
+		if err := s.lockProductCategory(ctx, req); err != nil {
+			return err
+		}
+		return s.productRepo.Create(ctx, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpdateProduct обновляет товар. Магазин товара не меняется.
+func (s *CatalogService) UpdateProduct(ctx context.Context, id uuid.UUID, req ProductRequest, actor models.Actor) (*models.Product, error) {
+	var product *models.Product
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = s.productRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !canManageStore(actor, product.StoreID) {
+			return ErrForbidden
+		}
+		if req.StoreID != uuid.Nil && req.StoreID != product.StoreID {
+			return fmt.Errorf("%w: магазин товара нельзя изменить", ErrInvalidInput)
+		}
+		if err := applyProductRequest(product, req, time.Now()); err != nil {
+			return err
+		}
+		if err := s.lockProductCategory(ctx, req); err != nil {
+			return err
+		}
+		return s.productRepo.Update(ctx, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// DeleteProduct мягко удаляет товар: он пропадает из каталога и становится
+// недоступным для заказа, а позиции прошлых заказов сохраняют ссылку на него.
+func (s *CatalogService) DeleteProduct(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !canManageStore(actor, product.StoreID) {
+			return ErrForbidden
+		}
+		return s.productRepo.SoftDelete(ctx, id)
+	})
+}
+
+// CreateStore создает магазин. Доступно только администратору.
+func (s *CatalogService) CreateStore(ctx context.Context, req StoreRequest, actor models.Actor) (*models.Store, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now()
+	store := &models.Store{ID: uuid.New(), CreatedAt: now}
+	if err := applyStoreRequest(store, req, now); err != nil {
+		return nil, err
+	}
+	if err := s.storeRepo.Create(ctx, store); err != nil {
+		return nil, fmt.Errorf("не удалось создать магазин: %w", err)
+	}
+	return store, nil
+}
+
+// UpdateStore обновляет данные магазина. Магазин меняет только себя,
+// администратор — любой магазин.
+func (s *CatalogService) UpdateStore(ctx context.Context, id uuid.UUID, req StoreRequest, actor models.Actor) (*models.Store, error) {
+	if !canManageStore(actor, id) {
+		return nil, ErrForbidden
+	}
+
+	var store *models.Store
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		store, err = s.storeRepo.GetByIDForUpdate(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		if err := applyStoreRequest(store, req, time.Now()); err != nil {
+			return err
+		}
+		return s.storeRepo.Update(ctx, store)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// DeleteStore мягко удаляет магазин. Как и при ON DELETE RESTRICT, магазин
+// с неудаленными товарами или незавершенными заказами удалить нельзя.
+func (s *CatalogService) DeleteStore(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+	if actor.Role != models.UserRoleAdmin {
+		return ErrForbidden
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.storeRepo.GetByIDForUpdate(ctx, id, false); err != nil {
+			return err
+		}
+		count, err := s.productRepo.CountByStoreID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить товары магазина: %w", err)
+		}
+		open, err := s.storeRepo.HasOpenOrders(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить заказы магазина: %w", err)
+		}
+		if count > 0 || open {
+			return ErrStoreInUse
+		}
+		return s.storeRepo.SoftDelete(ctx, id)
+	})
+}
+
+// lockProductCategory проверяет категорию и подкатегорию товара и защищает
+// их от удаления до конца транзакции.
+func (s *CatalogService) lockProductCategory(ctx context.Context, req ProductRequest) error {
+	if _, err := s.categoryRepo.GetByIDForUpdate(ctx, req.CategoryID, true); err != nil {
+		return err
+	}
+	if req.SubcategoryID == nil {
+		return nil
+	}
+	subcategory, err := s.subcategoryRepo.GetByIDForUpdate(ctx, *req.SubcategoryID, true)
+	if err != nil {
+		return err
+	}
+	if subcategory.CategoryID != req.CategoryID {
+		return fmt.Errorf("%w: подкатегория не относится к категории товара", ErrInvalidInput)
+	}
+	return nil
+}
+
+// canManageStore проверяет, может ли участник менять магазин и его товары.
+func canManageStore(actor models.Actor, storeID uuid.UUID) bool {
+	switch actor.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleStore:
+		return actor.StoreID != nil && *actor.StoreID == storeID
+	default:
+		return false
+	}
+}
+
+func applyProductRequest(product *models.Product, req ProductRequest, now time.Time) error {
+	name, err := validateName(req.Name, "товара")
+	if err != nil {
+		return err
+	}
+	if req.Price <= 0 {
+		return fmt.Errorf("%w: цена товара должна быть больше нуля", ErrInvalidInput)
+	}
+	if req.Weight != nil && *req.Weight < 0 {
+		return fmt.Errorf("%w: вес товара не может быть отрицательным", ErrInvalidInput)
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return fmt.Errorf("%w: остаток товара не может быть отрицательным", ErrInvalidInput)
+	}
+	sku := optionalText(req.SKU)
+	if sku != nil && utf8.RuneCountInString(*sku) > maxSKULength {
+		return fmt.Errorf("%w: артикул длиннее %d символов", ErrInvalidInput, maxSKULength)
+	}
+
+	product.CategoryID = req.CategoryID
+	product.SubcategoryID = req.SubcategoryID
+	product.SKU = sku
+	product.Name = name
+	product.Description = optionalText(req.Description)
+	product.Price = req.Price
+	product.Weight = req.Weight
+	product.Stock = req.Stock
+	product.IsAvailable = req.IsAvailable == nil || *req.IsAvailable
+	// Товар без остатка нельзя заказать, как после списания последней единицы
+	if product.Stock != nil && *product.Stock == 0 {
+		product.IsAvailable = false
+	}
+	product.UpdatedAt = now
+	return nil
+}
+
+func applyStoreRequest(store *models.Store, req StoreRequest, now time.Time) error {
+	name, err := validateName(req.Name, "магазина")
+	if err != nil {
+		return err
+	}
+	address := strings.TrimSpace(req.Address)
+	if address == "" {
+		return fmt.Errorf("%w: не указан адрес магазина", ErrInvalidInput)
+	}
+	if !req.CategoryType.Valid() {
+		return fmt.Errorf("%w: неизвестный тип магазина: %s", ErrInvalidInput, req.CategoryType)
+	}
+	phone := optionalText(req.Phone)
+	if phone != nil && utf8.RuneCountInString(*phone) > 20 {
+		return fmt.Errorf("%w: телефон магазина длиннее 20 символов", ErrInvalidInput)
+	}
+	imageURL := optionalText(req.ImageURL)
+	if imageURL != nil {
+		parsed, err := url.Parse(*imageURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: ссылка на изображение должна быть адресом http или https", ErrInvalidInput)
+		}
+	}
+
+	store.Name = name
+	store.Address = address
+	store.Phone = phone
+	store.Description = optionalText(req.Description)
+	store.ImageURL = imageURL
+	store.CategoryType = req.CategoryType
+	store.UpdatedAt = now
+	return nil
+}
+
+// validateName обрезает пробелы и проверяет, что название не пустое и влезает в столбец.
+func validateName(value, entity string) (string, error) {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return "", fmt.Errorf("%w: не указано название %s", ErrInvalidInput, entity)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", fmt.Errorf("%w: название %s длиннее %d символов", ErrInvalidInput, entity, maxNameLength)
+	}
+	return name, nil
+}
+
+// optionalText обрезает пробелы и превращает пустую строку в nil.
+func optionalText(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
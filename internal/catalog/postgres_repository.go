@@ -5,9 +5,12 @@ import (
 	"Laman/internal/models"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // postgresCategoryRepository реализует CategoryRepository используя PostgreSQL.
@@ -22,17 +25,17 @@ func NewPostgresCategoryRepository(db *database.DB) CategoryRepository {
 
 func (r *postgresCategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
 	var categories []models.Category
-	query := `SELECT id, name, description, created_at, updated_at FROM categories ORDER BY name`
+	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE deleted_at IS NULL ORDER BY name`
 	err := r.db.SelectContext(ctx, &categories, query)
 	return categories, err
 }
 
 func (r *postgresCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
 	var category models.Category
-	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1`
+	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &category, query, id)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("категория не найдена")
+		return nil, fmt.Errorf("%w", ErrCategoryNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -40,6 +43,43 @@ func (r *postgresCategoryRepository) GetByID(ctx context.Context, id uuid.UUID)
 	return &category, nil
 }
 
+func (r *postgresCategoryRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Category, error) {
+	var category models.Category
+	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1 AND deleted_at IS NULL ` + lockClause(shared)
+	err := r.db.Conn(ctx).GetContext(ctx, &category, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrCategoryNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *postgresCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	query := `
+		INSERT INTO categories (id, name, description, created_at, updated_at)
+		VALUES (:id, :name, :description, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, category)
+	return err
+}
+
+func (r *postgresCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	query := `
+		UPDATE categories SET name = :name, description = :description, updated_at = :updated_at
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, category)
+	return err
+}
+
+func (r *postgresCategoryRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE categories SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
 // postgresSubcategoryRepository реализует SubcategoryRepository используя PostgreSQL.
 type postgresSubcategoryRepository struct {
 	db *database.DB
@@ -52,7 +92,7 @@ func NewPostgresSubcategoryRepository(db *database.DB) SubcategoryRepository {
 
 func (r *postgresSubcategoryRepository) GetByCategoryID(ctx context.Context, categoryID uuid.UUID) ([]models.Subcategory, error) {
 	var subcategories []models.Subcategory
-	query := `SELECT id, category_id, name, created_at, updated_at FROM subcategories WHERE category_id = $1 ORDER BY name`
+	query := `SELECT id, category_id, name, created_at, updated_at FROM subcategories WHERE category_id = $1 AND deleted_at IS NULL ORDER BY name`
 	err := r.db.SelectContext(ctx, &subcategories, query, categoryID)
 	return subcategories, err
 }
@@ -63,13 +103,56 @@ func (r *postgresSubcategoryRepository) GetByStoreID(ctx context.Context, storeI
 SELECT DISTINCT s.id, s.category_id, s.name, s.created_at, s.updated_at
 FROM subcategories s
 JOIN products p ON p.subcategory_id = s.id
-WHERE p.store_id = $1
+WHERE p.store_id = $1 AND p.deleted_at IS NULL AND s.deleted_at IS NULL
 ORDER BY s.name
 `
 	err := r.db.SelectContext(ctx, &subcategories, query, storeID)
 	return subcategories, err
 }
 
+func (r *postgresSubcategoryRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Subcategory, error) {
+	var subcategory models.Subcategory
+	query := `SELECT id, category_id, name, created_at, updated_at FROM subcategories WHERE id = $1 AND deleted_at IS NULL ` + lockClause(shared)
+	err := r.db.Conn(ctx).GetContext(ctx, &subcategory, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrSubcategoryNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subcategory, nil
+}
+
+func (r *postgresSubcategoryRepository) Create(ctx context.Context, subcategory *models.Subcategory) error {
+	query := `
+		INSERT INTO subcategories (id, category_id, name, created_at, updated_at)
+		VALUES (:id, :category_id, :name, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, subcategory)
+	return err
+}
+
+func (r *postgresSubcategoryRepository) Update(ctx context.Context, subcategory *models.Subcategory) error {
+	query := `
+		UPDATE subcategories SET category_id = :category_id, name = :name, updated_at = :updated_at
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, subcategory)
+	return err
+}
+
+func (r *postgresSubcategoryRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE subcategories SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *postgresSubcategoryRepository) SoftDeleteByCategoryID(ctx context.Context, categoryID uuid.UUID) error {
+	query := `UPDATE subcategories SET deleted_at = NOW(), updated_at = NOW() WHERE category_id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, categoryID)
+	return err
+}
+
 // postgresProductRepository реализует ProductRepository используя PostgreSQL.
 type postgresProductRepository struct {
 	db *database.DB
@@ -80,78 +163,193 @@ func NewPostgresProductRepository(db *database.DB) ProductRepository {
 	return &postgresProductRepository{db: db}
 }
 
-func (r *postgresProductRepository) GetAll(ctx context.Context, categoryID *uuid.UUID, subcategoryID *uuid.UUID, search *string, availableOnly bool) ([]models.Product, error) {
-	var products []models.Product
-	query := `SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, created_at, updated_at FROM products WHERE 1=1`
-	args := []interface{}{}
-	argPos := 1
+// productListColumns — колонки товара в списках каталога.
+const productListColumns = `id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, stock, sku, rating, rating_count, sold_count, created_at, updated_at`
 
-	if categoryID != nil {
-		query += fmt.Sprintf(" AND category_id = $%d", argPos)
-		args = append(args, *categoryID)
-		argPos++
-	}
+// Параметры ts_headline: название подсвечивается целиком, из описания
+// берутся короткие фрагменты вокруг совпадений.
+const (
+	nameHeadlineOptions        = "HighlightAll=true, StartSel=<mark>, StopSel=</mark>"
+	descriptionHeadlineOptions = "MaxFragments=2, MinWords=5, MaxWords=20, FragmentDelimiter=\" … \", StartSel=<mark>, StopSel=</mark>"
+)
 
-	if subcategoryID != nil {
-		query += fmt.Sprintf(" AND subcategory_id = $%d", argPos)
-		args = append(args, *subcategoryID)
-		argPos++
-	}
+// escapeHTMLExpr оборачивает SQL выражение в экранирование HTML. Подсветка
+// строится уже по экранированному тексту, поэтому теги <mark> — единственная
+// разметка в ней. Парсер полнотекстового поиска считает сущности вроде &lt;
+// отдельными лексемами, и они не мешают совпадениям.
+func escapeHTMLExpr(expr string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`, expr)
+}
 
-	if search != nil && *search != "" {
-		query += fmt.Sprintf(" AND (name ILIKE $%d OR COALESCE(description, '') ILIKE $%d)", argPos, argPos)
-		args = append(args, "%"+*search+"%")
-		argPos++
-	}
+// productQuery собирает FROM и WHERE выборки товаров по фильтру.
+type productQuery struct {
+	from       string
+	conditions []string
+	args       []interface{}
+	// search — плейсхолдер поискового запроса, пуст без поиска.
+	search string
+}
 
-	if availableOnly {
-		query += fmt.Sprintf(" AND is_available = $%d", argPos)
-		args = append(args, true)
-		argPos++
-	}
+func (q *productQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
 
-	query += " ORDER BY name"
+func (q *productQuery) where() string {
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
 
-	err := r.db.SelectContext(ctx, &products, query, args...)
-	return products, err
+// searchRank — выражение релевантности товара. Совпадения по словоформам
+// получают +2: ts_rank_cd с нормализацией 32 и word_similarity не больше 1,
+// поэтому они всегда выше совпадений по триграммам, а порядок задается
+// одним ключом, по которому можно листать курсором.
+func (q *productQuery) searchRank() string {
+	return fmt.Sprintf("(CASE WHEN search_vector @@ query THEN 2 ELSE 0 END + ts_rank_cd(search_vector, query, 32) + word_similarity(%s, name))::float8", q.search)
 }
 
-func (r *postgresProductRepository) GetByStoreID(ctx context.Context, storeID uuid.UUID, subcategoryID *uuid.UUID, search *string, availableOnly bool) ([]models.Product, error) {
-	var products []models.Product
-	query := `SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, created_at, updated_at FROM products WHERE store_id = $1`
-	args := []interface{}{storeID}
-	argPos := 2
+// newProductQuery переносит условия фильтра, кроме позиции и размера страницы.
+// С поиском подходят товары, совпавшие по словоформам (индекс search_vector),
+// или, если в запросе опечатка, похожие по триграммам на слово из названия
+// (индекс idx_products_name_trgm).
+func newProductQuery(filter ProductFilter) *productQuery {
+	q := &productQuery{from: "products", conditions: []string{"deleted_at IS NULL"}}
 
-	if subcategoryID != nil {
-		query += fmt.Sprintf(" AND subcategory_id = $%d", argPos)
-		args = append(args, *subcategoryID)
-		argPos++
+	if filter.CategoryID != nil {
+		q.conditions = append(q.conditions, "category_id = "+q.arg(*filter.CategoryID))
+	}
+	if filter.SubcategoryID != nil {
+		q.conditions = append(q.conditions, "subcategory_id = "+q.arg(*filter.SubcategoryID))
+	}
+	if filter.StoreID != nil {
+		q.conditions = append(q.conditions, "store_id = "+q.arg(*filter.StoreID))
+	}
+	if filter.AvailableOnly {
+		q.conditions = append(q.conditions, "is_available")
+	}
+	if filter.MinPrice != nil {
+		q.conditions = append(q.conditions, "price >= "+q.arg(*filter.MinPrice))
 	}
+	if filter.MaxPrice != nil {
+		q.conditions = append(q.conditions, "price <= "+q.arg(*filter.MaxPrice))
+	}
+	if filter.Search != nil && strings.TrimSpace(*filter.Search) != "" {
+		q.search = q.arg(strings.TrimSpace(*filter.Search))
+		q.from = fmt.Sprintf("products, websearch_to_tsquery('russian', %s) AS query", q.search)
+		q.conditions = append(q.conditions, fmt.Sprintf("(search_vector @@ query OR %s <%% name)", q.search))
+	}
+	return q
+}
 
-	if search != nil && *search != "" {
-		query += fmt.Sprintf(" AND (name ILIKE $%d OR COALESCE(description, '') ILIKE $%d)", argPos, argPos)
-		args = append(args, "%"+*search+"%")
-		argPos++
+// productSortKeys возвращает выражения ключей сортировки (без id, который
+// всегда замыкает порядок) и направление.
+func productSortKeys(sort ProductSort, q *productQuery) ([]string, bool) {
+	switch sort {
+	case ProductSortRelevance:
+		return []string{q.searchRank()}, true
+	case ProductSortPriceAsc:
+		return []string{"price"}, false
+	case ProductSortPriceDesc:
+		return []string{"price"}, true
+	case ProductSortNewest:
+		return []string{"created_at"}, true
+	case ProductSortPopularity:
+		return []string{"sold_count"}, true
+	case ProductSortRating:
+		return []string{"rating", "rating_count"}, true
+	default:
+		return []string{"name"}, false
 	}
+}
 
-	if availableOnly {
-		query += fmt.Sprintf(" AND is_available = $%d", argPos)
-		args = append(args, true)
-		argPos++
+func (r *postgresProductRepository) List(ctx context.Context, filter ProductFilter) ([]models.Product, error) {
+	q := newProductQuery(filter)
+	if filter.Sort == ProductSortRelevance && q.search == "" {
+		return nil, fmt.Errorf("%w", ErrInvalidProductSort)
 	}
 
-	query += " ORDER BY name"
+	columns := productListColumns
+	if q.search != "" {
+		columns += fmt.Sprintf(`,
+			%s AS search_rank,
+			ts_headline('russian', %s, query, '%s') AS name_highlight,
+			CASE WHEN description IS NOT NULL AND search_vector @@ query
+				THEN ts_headline('russian', %s, query, '%s') END AS description_highlight`,
+			q.searchRank(), escapeHTMLExpr("name"), nameHeadlineOptions, escapeHTMLExpr("description"), descriptionHeadlineOptions)
+	}
+
+	keys, desc := productSortKeys(filter.Sort, q)
+	direction, compare := "", ">"
+	if desc {
+		direction, compare = " DESC", "<"
+	}
 
-	err := r.db.SelectContext(ctx, &products, query, args...)
+	if filter.After != nil {
+		if len(filter.After.Values) != len(keys) {
+			return nil, fmt.Errorf("%w", ErrInvalidProductSort)
+		}
+		// Сравнение кортежей использует индексы (ключ, id) из миграции 000027
+		values := make([]string, 0, len(keys)+1)
+		for _, value := range filter.After.Values {
+			values = append(values, q.arg(value))
+		}
+		values = append(values, q.arg(filter.After.ID))
+		q.conditions = append(q.conditions, fmt.Sprintf("(%s, id) %s (%s)",
+			strings.Join(keys, ", "), compare, strings.Join(values, ", ")))
+	}
+
+	order := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		order = append(order, key+direction)
+	}
+	order = append(order, "id"+direction)
+
+	query := "SELECT " + columns + " FROM " + q.from + q.where() + " ORDER BY " + strings.Join(order, ", ")
+	if filter.Limit > 0 {
+		query += " LIMIT " + q.arg(filter.Limit)
+	}
+
+	var products []models.Product
+	err := r.db.SelectContext(ctx, &products, query, q.args...)
 	return products, err
 }
 
+func (r *postgresProductRepository) SubcategoryFacets(ctx context.Context, filter ProductFilter) ([]models.FacetCount, error) {
+	filter.SubcategoryID = nil
+	q := newProductQuery(filter)
+	q.conditions = append(q.conditions, "subcategory_id IS NOT NULL")
+
+	query := `
+		SELECT s.id, s.name, f.count
+		FROM (SELECT subcategory_id AS id, COUNT(*) AS count FROM ` + q.from + q.where() + ` GROUP BY subcategory_id) f
+		JOIN subcategories s ON s.id = f.id AND s.deleted_at IS NULL
+		ORDER BY f.count DESC, s.name
+	`
+	facets := []models.FacetCount{}
+	err := r.db.SelectContext(ctx, &facets, query, q.args...)
+	return facets, err
+}
+
+func (r *postgresProductRepository) StoreFacets(ctx context.Context, filter ProductFilter) ([]models.FacetCount, error) {
+	filter.StoreID = nil
+	q := newProductQuery(filter)
+
+	query := `
+		SELECT s.id, s.name, f.count
+		FROM (SELECT store_id AS id, COUNT(*) AS count FROM ` + q.from + q.where() + ` GROUP BY store_id) f
+		JOIN stores s ON s.id = f.id AND s.deleted_at IS NULL
+		ORDER BY f.count DESC, s.name
+	`
+	facets := []models.FacetCount{}
+	err := r.db.SelectContext(ctx, &facets, query, q.args...)
+	return facets, err
+}
+
 func (r *postgresProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	query := `SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, created_at, updated_at FROM products WHERE id = $1`
+	query := `SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, stock, sku, rating, rating_count, created_at, updated_at FROM products WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &product, query, id)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("товар не найден")
+		return nil, fmt.Errorf("%w", ErrProductNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -165,15 +363,150 @@ func (r *postgresProductRepository) GetByIDs(ctx context.Context, ids []uuid.UUI
 	}
 
 	var products []models.Product
-	query, args, err := sqlx.In(`SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, created_at, updated_at FROM products WHERE id IN (?)`, ids)
+	query, args, err := sqlx.In(`SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, stock, sku, rating, rating_count, created_at, updated_at FROM products WHERE id IN (?)`, ids)
 	if err != nil {
 		return nil, err
 	}
 	query = r.db.Rebind(query)
-	err = r.db.SelectContext(ctx, &products, query, args...)
+	err = r.db.Conn(ctx).SelectContext(ctx, &products, query, args...)
 	return products, err
 }
 
+func (r *postgresProductRepository) ReserveStock(ctx context.Context, id uuid.UUID, quantity int) error {
+	// UPDATE блокирует строку товара, поэтому параллельные оформления
+	// проверяют остаток последовательно и не могут уйти в минус.
+	query := `
+		UPDATE products
+		SET stock = stock - $2,
+		    sold_count = sold_count + $2,
+		    is_available = CASE WHEN stock IS NULL THEN is_available ELSE stock - $2 > 0 END,
+		    updated_at = NOW()
+		WHERE id = $1 AND is_available AND (stock IS NULL OR stock >= $2)
+	`
+	result, err := r.db.Conn(ctx).ExecContext(ctx, query, id, quantity)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w", ErrInsufficientStock)
+	}
+	return nil
+}
+
+func (r *postgresProductRepository) ReleaseStock(ctx context.Context, id uuid.UUID, quantity int) error {
+	query := `
+		UPDATE products
+		SET stock = stock + $2,
+		    sold_count = GREATEST(sold_count - $2, 0),
+		    is_available = CASE WHEN stock = 0 AND deleted_at IS NULL THEN TRUE ELSE is_available END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id, quantity)
+	return err
+}
+
+func (r *postgresProductRepository) DiscardReservation(ctx context.Context, id uuid.UUID, quantity int) error {
+	query := `
+		UPDATE products
+		SET sold_count = GREATEST(sold_count - $2, 0),
+		    stock = CASE WHEN stock IS NULL THEN NULL ELSE 0 END,
+		    is_available = FALSE,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id, quantity)
+	return err
+}
+
+func (r *postgresProductRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	var product models.Product
+	query := `SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, stock, sku, rating, rating_count, created_at, updated_at FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err := r.db.Conn(ctx).GetContext(ctx, &product, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrProductNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *postgresProductRepository) Create(ctx context.Context, product *models.Product) error {
+	query := `
+		INSERT INTO products (id, category_id, subcategory_id, store_id, sku, name, description, price, weight, is_available, stock, created_at, updated_at)
+		VALUES (:id, :category_id, :subcategory_id, :store_id, :sku, :name, :description, :price, :weight, :is_available, :stock, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, product)
+	return mapSKUConflict(err)
+}
+
+func (r *postgresProductRepository) Update(ctx context.Context, product *models.Product) error {
+	query := `
+		UPDATE products
+		SET category_id = :category_id, subcategory_id = :subcategory_id, sku = :sku, name = :name,
+		    description = :description, price = :price, weight = :weight,
+		    is_available = :is_available, stock = :stock, updated_at = :updated_at
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, product)
+	return mapSKUConflict(err)
+}
+
+func (r *postgresProductRepository) GetBySKUsForUpdate(ctx context.Context, storeID uuid.UUID, skus []string) ([]models.Product, error) {
+	if len(skus) == 0 {
+		return []models.Product{}, nil
+	}
+
+	var products []models.Product
+	query, args, err := sqlx.In(`SELECT id, category_id, subcategory_id, store_id, name, description, price, weight, is_available, stock, sku, rating, rating_count, created_at, updated_at FROM products WHERE store_id = ? AND sku IN (?) AND deleted_at IS NULL FOR UPDATE`, storeID, skus)
+	if err != nil {
+		return nil, err
+	}
+	query = r.db.Rebind(query)
+	err = r.db.Conn(ctx).SelectContext(ctx, &products, query, args...)
+	return products, err
+}
+
+// mapSKUConflict превращает нарушение уникальности артикула в ErrDuplicateSKU.
+func mapSKUConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w", ErrDuplicateSKU)
+	}
+	return err
+}
+
+func (r *postgresProductRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE products SET deleted_at = NOW(), is_available = FALSE, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *postgresProductRepository) CountByCategoryID(ctx context.Context, categoryID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL`
+	err := r.db.Conn(ctx).GetContext(ctx, &count, query, categoryID)
+	return count, err
+}
+
+func (r *postgresProductRepository) CountByStoreID(ctx context.Context, storeID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM products WHERE store_id = $1 AND deleted_at IS NULL`
+	err := r.db.Conn(ctx).GetContext(ctx, &count, query, storeID)
+	return count, err
+}
+
+func (r *postgresProductRepository) ClearSubcategory(ctx context.Context, subcategoryID uuid.UUID) error {
+	query := `UPDATE products SET subcategory_id = NULL, updated_at = NOW() WHERE subcategory_id = $1`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, subcategoryID)
+	return err
+}
+
 // postgresStoreRepository реализует StoreRepository используя PostgreSQL.
 type postgresStoreRepository struct {
 	db *database.DB
@@ -184,9 +517,9 @@ func NewPostgresStoreRepository(db *database.DB) StoreRepository {
 	return &postgresStoreRepository{db: db}
 }
 
-func (r *postgresStoreRepository) GetAll(ctx context.Context, categoryType *models.StoreCategoryType, search *string) ([]models.Store, error) {
+func (r *postgresStoreRepository) GetAll(ctx context.Context, categoryType *models.StoreCategoryType, search *string, sort StoreSort) ([]models.Store, error) {
 	var stores []models.Store
-	query := `SELECT id, name, address, phone, description, image_url, rating, category_type, created_at, updated_at FROM stores WHERE 1=1`
+	query := `SELECT id, name, address, phone, description, image_url, rating, rating_count, category_type, created_at, updated_at FROM stores WHERE deleted_at IS NULL`
 	args := []interface{}{}
 	argPos := 1
 
@@ -202,7 +535,11 @@ func (r *postgresStoreRepository) GetAll(ctx context.Context, categoryType *mode
 		argPos++
 	}
 
-	query += " ORDER BY name"
+	if sort == StoreSortRating {
+		query += " ORDER BY rating DESC, rating_count DESC, name"
+	} else {
+		query += " ORDER BY name"
+	}
 
 	err := r.db.SelectContext(ctx, &stores, query, args...)
 	return stores, err
@@ -210,13 +547,80 @@ func (r *postgresStoreRepository) GetAll(ctx context.Context, categoryType *mode
 
 func (r *postgresStoreRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Store, error) {
 	var store models.Store
-	query := `SELECT id, name, address, phone, description, image_url, rating, category_type, created_at, updated_at FROM stores WHERE id = $1`
+	query := `SELECT id, name, address, phone, description, image_url, rating, rating_count, category_type, created_at, updated_at FROM stores WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &store, query, id)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("магазин не найден")
+		return nil, fmt.Errorf("%w", ErrStoreNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *postgresStoreRepository) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.Store, error) {
+	var store models.Store
+	query := `SELECT id, name, address, phone, description, image_url, rating, rating_count, category_type, created_at, updated_at FROM stores WHERE id = $1`
+	err := r.db.GetContext(ctx, &store, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrStoreNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *postgresStoreRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Store, error) {
+	var store models.Store
+	query := `SELECT id, name, address, phone, description, image_url, rating, rating_count, category_type, created_at, updated_at FROM stores WHERE id = $1 AND deleted_at IS NULL ` + lockClause(shared)
+	err := r.db.Conn(ctx).GetContext(ctx, &store, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrStoreNotFound)
 	}
 	if err != nil {
 		return nil, err
 	}
 	return &store, nil
 }
+
+func (r *postgresStoreRepository) Create(ctx context.Context, store *models.Store) error {
+	query := `
+		INSERT INTO stores (id, name, address, phone, description, image_url, category_type, created_at, updated_at)
+		VALUES (:id, :name, :address, :phone, :description, :image_url, :category_type, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, store)
+	return err
+}
+
+func (r *postgresStoreRepository) Update(ctx context.Context, store *models.Store) error {
+	query := `
+		UPDATE stores
+		SET name = :name, address = :address, phone = :phone, description = :description,
+		    image_url = :image_url, category_type = :category_type, updated_at = :updated_at
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, store)
+	return err
+}
+
+func (r *postgresStoreRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE stores SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *postgresStoreRepository) HasOpenOrders(ctx context.Context, storeID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM orders WHERE store_id = $1 AND status NOT IN ('DELIVERED', 'CANCELLED'))`
+	err := r.db.Conn(ctx).GetContext(ctx, &exists, query, storeID)
+	return exists, err
+}
+
+// lockClause возвращает блокировку строки для SELECT: разделяемую или эксклюзивную.
+func lockClause(shared bool) string {
+	if shared {
+		return "FOR SHARE"
+	}
+	return "FOR UPDATE"
+}
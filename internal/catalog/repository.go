@@ -3,9 +3,93 @@ package catalog
 import (
 	"Laman/internal/models"
 	"context"
+	"errors"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrInvalidStoreSort    = errors.New("неизвестный порядок сортировки магазинов")
+	ErrInvalidProductSort  = errors.New("неизвестный порядок сортировки товаров")
+	ErrCategoryNotFound    = errors.New("категория не найдена")
+	ErrSubcategoryNotFound = errors.New("подкатегория не найдена")
+	ErrProductNotFound     = errors.New("товар не найден")
+	ErrStoreNotFound       = errors.New("магазин не найден")
+	ErrDuplicateSKU        = errors.New("товар с таким артикулом уже есть в магазине")
+)
+
+// StoreSort задает порядок списка магазинов.
+type StoreSort string
+
+const (
+	// StoreSortName сортирует магазины по названию.
+	StoreSortName StoreSort = "name"
+	// StoreSortRating сортирует магазины по убыванию рейтинга, при равном
+	// рейтинге выше магазин с большим числом оценок.
+	StoreSortRating StoreSort = "rating"
+)
+
+// Valid проверяет, что порядок сортировки поддерживается.
+func (s StoreSort) Valid() bool {
+	return s == StoreSortName || s == StoreSortRating
+}
+
+// ProductSort задает порядок списка товаров.
+type ProductSort string
+
+const (
+	// ProductSortName сортирует товары по названию.
+	ProductSortName ProductSort = "name"
+	// ProductSortRelevance сортирует результаты поиска по убыванию релевантности.
+	ProductSortRelevance ProductSort = "relevance"
+	// ProductSortPriceAsc сортирует товары от дешевых к дорогим.
+	ProductSortPriceAsc ProductSort = "price_asc"
+	// ProductSortPriceDesc сортирует товары от дорогих к дешевым.
+	ProductSortPriceDesc ProductSort = "price_desc"
+	// ProductSortNewest сортирует товары от новых к старым.
+	ProductSortNewest ProductSort = "newest"
+	// ProductSortPopularity сортирует товары по убыванию числа проданных единиц.
+	ProductSortPopularity ProductSort = "popularity"
+	// ProductSortRating сортирует товары по убыванию рейтинга, при равном
+	// рейтинге выше товар с большим числом оценок.
+	ProductSortRating ProductSort = "rating"
+)
+
+// Valid проверяет, что порядок сортировки поддерживается.
+func (s ProductSort) Valid() bool {
+	switch s {
+	case ProductSortName, ProductSortRelevance, ProductSortPriceAsc, ProductSortPriceDesc,
+		ProductSortNewest, ProductSortPopularity, ProductSortRating:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProductFilter задает условия выборки списка товаров. Пустые поля не ограничивают выборку.
+type ProductFilter struct {
+	CategoryID    *uuid.UUID
+	SubcategoryID *uuid.UUID
+	StoreID       *uuid.UUID
+	Search        *string
+	AvailableOnly bool
+	MinPrice      *models.Money
+	MaxPrice      *models.Money
+	Sort          ProductSort
+	// After — позиция последнего товара предыдущей страницы.
+	After *ProductCursor
+	// Limit — размер страницы, 0 — без ограничения.
+	Limit int
+}
+
+// ProductCursor задает позицию в списке товаров: значения ключей сортировки
+// Sort последнего товара страницы и его ID.
+type ProductCursor struct {
+	Sort   ProductSort `json:"s"`
+	Values []string    `json:"v"`
+	ID     uuid.UUID   `json:"id"`
+}
+
 // CategoryRepository определяет интерфейс для доступа к данным категорий.
 type CategoryRepository interface {
 	// GetAll получает все категории.
@@ -13,6 +97,20 @@ type CategoryRepository interface {
 
 	// GetByID получает категорию по ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error)
+
+	// GetByIDForUpdate получает категорию по ID и блокирует ее строку до конца
+	// транзакции. При shared блокировка разделяемая: она защищает категорию
+	// от удаления, не мешая другим транзакциям ссылаться на нее.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Category, error)
+
+	// Create создает категорию.
+	Create(ctx context.Context, category *models.Category) error
+
+	// Update обновляет категорию.
+	Update(ctx context.Context, category *models.Category) error
+
+	// SoftDelete помечает категорию удаленной.
+	SoftDelete(ctx context.Context, id uuid.UUID) error
 }
 
 // SubcategoryRepository определяет интерфейс для доступа к подкатегориям.
@@ -22,28 +120,108 @@ type SubcategoryRepository interface {
 
 	// GetByStoreID получает подкатегории товаров конкретного магазина.
 	GetByStoreID(ctx context.Context, storeID uuid.UUID) ([]models.Subcategory, error)
+
+	// GetByIDForUpdate получает подкатегорию по ID и блокирует ее строку до конца
+	// транзакции; shared означает разделяемую блокировку.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Subcategory, error)
+
+	// Create создает подкатегорию.
+	Create(ctx context.Context, subcategory *models.Subcategory) error
+
+	// Update обновляет подкатегорию.
+	Update(ctx context.Context, subcategory *models.Subcategory) error
+
+	// SoftDelete помечает подкатегорию удаленной.
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+
+	// SoftDeleteByCategoryID помечает удаленными подкатегории категории.
+	SoftDeleteByCategoryID(ctx context.Context, categoryID uuid.UUID) error
 }
 
 // ProductRepository определяет интерфейс для доступа к данным товаров.
 type ProductRepository interface {
-	// GetAll получает все товары с опциональными фильтрами.
-	GetAll(ctx context.Context, categoryID *uuid.UUID, subcategoryID *uuid.UUID, search *string, availableOnly bool) ([]models.Product, error)
+	// List получает неудаленные товары по фильтру в порядке filter.Sort.
+	List(ctx context.Context, filter ProductFilter) ([]models.Product, error)
 
-	// GetByStoreID получает товары конкретного магазина с фильтрами.
-	GetByStoreID(ctx context.Context, storeID uuid.UUID, subcategoryID *uuid.UUID, search *string, availableOnly bool) ([]models.Product, error)
+	// SubcategoryFacets считает товары по подкатегориям. Фильтр по подкатегории
+	// не применяется, чтобы были видны соседние значения.
+	SubcategoryFacets(ctx context.Context, filter ProductFilter) ([]models.FacetCount, error)
+
+	// StoreFacets считает товары по магазинам. Фильтр по магазину не применяется.
+	StoreFacets(ctx context.Context, filter ProductFilter) ([]models.FacetCount, error)
 
 	// GetByID получает товар по ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
 
-	// GetByIDs получает несколько товаров по их ID.
+	// GetByIDs получает несколько товаров по их ID, включая удаленные,
+	// чтобы заказы и корзины могли показать их названия.
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Product, error)
+
+	// GetByIDForUpdate получает товар по ID и блокирует его строку до конца транзакции.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error)
+
+	// GetBySKUsForUpdate получает неудаленные товары магазина по артикулам
+	// и блокирует их строки до конца транзакции.
+	GetBySKUsForUpdate(ctx context.Context, storeID uuid.UUID, skus []string) ([]models.Product, error)
+
+	// Create создает товар. Если артикул уже занят в магазине, возвращает ErrDuplicateSKU.
+	Create(ctx context.Context, product *models.Product) error
+
+	// Update обновляет товар. Если артикул уже занят в магазине, возвращает ErrDuplicateSKU.
+	Update(ctx context.Context, product *models.Product) error
+
+	// SoftDelete помечает товар удаленным и недоступным. Строка остается,
+	// потому что на нее ссылаются позиции заказов.
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+
+	// CountByCategoryID считает неудаленные товары категории.
+	CountByCategoryID(ctx context.Context, categoryID uuid.UUID) (int, error)
+
+	// CountByStoreID считает неудаленные товары магазина.
+	CountByStoreID(ctx context.Context, storeID uuid.UUID) (int, error)
+
+	// ClearSubcategory убирает подкатегорию у товаров.
+	ClearSubcategory(ctx context.Context, subcategoryID uuid.UUID) error
+
+	// ReserveStock списывает количество товара с остатка и учитывает его
+	// в продажах. Если остатка не хватает или товар недоступен, возвращает
+	// ErrInsufficientStock. При обнулении остатка товар становится недоступным.
+	ReserveStock(ctx context.Context, id uuid.UUID, quantity int) error
+
+	// ReleaseStock возвращает количество товара на остаток и вычитает его из продаж.
+	ReleaseStock(ctx context.Context, id uuid.UUID, quantity int) error
+
+	// DiscardReservation вычитает количество товара из продаж, не возвращая его
+	// на остаток, обнуляет остаток и снимает товар с продажи: товара
+	// фактически нет в магазине.
+	DiscardReservation(ctx context.Context, id uuid.UUID, quantity int) error
 }
 
 // StoreRepository определяет интерфейс для доступа к данным магазинов.
 type StoreRepository interface {
-	// GetAll получает все магазины.
-	GetAll(ctx context.Context, categoryType *models.StoreCategoryType, search *string) ([]models.Store, error)
+	// GetAll получает все магазины в порядке sort.
+	GetAll(ctx context.Context, categoryType *models.StoreCategoryType, search *string, sort StoreSort) ([]models.Store, error)
 
 	// GetByID получает магазин по ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Store, error)
+
+	// GetByIDIncludingDeleted получает магазин по ID, даже если он удален.
+	// Нужен для истории: чеков и отзывов по прошлым заказам.
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.Store, error)
+
+	// GetByIDForUpdate получает магазин по ID и блокирует его строку до конца
+	// транзакции; shared означает разделяемую блокировку.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID, shared bool) (*models.Store, error)
+
+	// Create создает магазин.
+	Create(ctx context.Context, store *models.Store) error
+
+	// Update обновляет магазин.
+	Update(ctx context.Context, store *models.Store) error
+
+	// SoftDelete помечает магазин удаленным.
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+
+	// HasOpenOrders проверяет, есть ли у магазина незавершенные заказы.
+	HasOpenOrders(ctx context.Context, storeID uuid.UUID) (bool, error)
 }
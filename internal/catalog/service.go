@@ -1,6 +1,7 @@
 package catalog
 
 import (
+	"Laman/internal/database"
 	"Laman/internal/models"
 	"context"
 	"fmt"
@@ -10,20 +11,30 @@ import (
 // CatalogService обрабатывает бизнес-логику, связанную с каталогом,
 // включая категории, товары и магазины.
 type CatalogService struct {
+	uow             database.UnitOfWork
 	categoryRepo    CategoryRepository
 	subcategoryRepo SubcategoryRepository
 	productRepo     ProductRepository
 	storeRepo       StoreRepository
+	searchLogger    SearchLogger
+}
+
+// SearchLogger определяет интерфейс, необходимый из модуля search.
+type SearchLogger interface {
+	// LogSearch учитывает поисковый запрос, который нашел результаты.
+	LogSearch(query string)
 }
 
 // NewCatalogService создает новый сервис каталога.
 func NewCatalogService(
+	uow database.UnitOfWork,
 	categoryRepo CategoryRepository,
 	subcategoryRepo SubcategoryRepository,
 	productRepo ProductRepository,
 	storeRepo StoreRepository,
 ) *CatalogService {
 	return &CatalogService{
+		uow:             uow,
 		categoryRepo:    categoryRepo,
 		subcategoryRepo: subcategoryRepo,
 		productRepo:     productRepo,
@@ -31,52 +42,26 @@ func NewCatalogService(
 	}
 }
 
-// GetCategories получает все категории.
-func (s *CatalogService) GetCategories(ctx context.Context) ([]models.Category, error) {
-	categories, err := s.categoryRepo.GetAll(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить категории: %w", err)
-	}
-	return categories, nil
-}
-
-// GetProducts получает товары с опциональными фильтрами.
-func (s *CatalogService) GetProducts(ctx context.Context, categoryID *uuid.UUID, availableOnly bool) ([]models.Product, error) {
-	products, err := s.productRepo.GetAll(ctx, categoryID, nil, nil, availableOnly)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить товары: %w", err)
-	}
-	return products, nil
+// SetSearchLogger подключает журнал поисковых запросов: запросы, которые
+// нашли товары или магазины, поднимают соответствующие подсказки.
+func (s *CatalogService) SetSearchLogger(logger SearchLogger) {
+	s.searchLogger = logger
 }
 
-// GetProductsWithFilters получает товары с расширенными фильтрами.
-func (s *CatalogService) GetProductsWithFilters(
-	ctx context.Context,
-	categoryID *uuid.UUID,
-	subcategoryID *uuid.UUID,
-	search *string,
-	availableOnly bool,
-) ([]models.Product, error) {
-	products, err := s.productRepo.GetAll(ctx, categoryID, subcategoryID, search, availableOnly)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+// logSearch учитывает запрос в журнале, если он что-то нашел.
+func (s *CatalogService) logSearch(search *string, found int) {
+	if s.searchLogger != nil && search != nil && found > 0 {
+		s.searchLogger.LogSearch(*search)
 	}
-	return products, nil
 }
 
-// GetStoreProducts получает товары конкретного магазина.
-func (s *CatalogService) GetStoreProducts(
-	ctx context.Context,
-	storeID uuid.UUID,
-	subcategoryID *uuid.UUID,
-	search *string,
-	availableOnly bool,
-) ([]models.Product, error) {
-	products, err := s.productRepo.GetByStoreID(ctx, storeID, subcategoryID, search, availableOnly)
+// GetCategories получает все категории.
+func (s *CatalogService) GetCategories(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.categoryRepo.GetAll(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить товары магазина: %w", err)
+		return nil, fmt.Errorf("не удалось получить категории: %w", err)
 	}
-	return products, nil
+	return categories, nil
 }
 
 // GetSubcategories получает подкатегории по ID категории.
@@ -98,11 +83,20 @@ func (s *CatalogService) GetStoreSubcategories(ctx context.Context, storeID uuid
 }
 
 // GetStores получает магазины с фильтрацией по типу и поиску.
-func (s *CatalogService) GetStores(ctx context.Context, categoryType *models.StoreCategoryType, search *string) ([]models.Store, error) {
-	stores, err := s.storeRepo.GetAll(ctx, categoryType, search)
+// Пустой sort сортирует магазины по названию.
+func (s *CatalogService) GetStores(ctx context.Context, categoryType *models.StoreCategoryType, search *string, sort StoreSort) ([]models.Store, error) {
+	if sort == "" {
+		sort = StoreSortName
+	}
+	if !sort.Valid() {
+		return nil, ErrInvalidStoreSort
+	}
+
+	stores, err := s.storeRepo.GetAll(ctx, categoryType, search, sort)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось получить магазины: %w", err)
 	}
+	s.logSearch(search, len(stores))
 	return stores, nil
 }
 
@@ -0,0 +1,365 @@
+package catalog
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SpreadsheetFormat — формат файла импорта и экспорта товаров.
+type SpreadsheetFormat string
+
+const (
+	SpreadsheetCSV  SpreadsheetFormat = "csv"
+	SpreadsheetXLSX SpreadsheetFormat = "xlsx"
+)
+
+// Valid проверяет, что формат поддерживается.
+func (f SpreadsheetFormat) Valid() bool {
+	return f == SpreadsheetCSV || f == SpreadsheetXLSX
+}
+
+// ContentType возвращает MIME-тип файла.
+func (f SpreadsheetFormat) ContentType() string {
+	if f == SpreadsheetXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ErrInvalidSpreadsheet возвращается, если файл не удалось прочитать как таблицу.
+var ErrInvalidSpreadsheet = errors.New("не удалось прочитать файл")
+
+// maxSheetSize ограничивает распакованный размер частей XLSX, чтобы
+// небольшой архив не развернулся в гигабайты.
+const maxSheetSize = 64 << 20
+
+const utf8BOM = "\ufeff"
+
+// readSpreadsheet читает строки таблицы. Формат определяется по содержимому:
+// XLSX — это ZIP-архив, все остальное читается как CSV в UTF-8.
+func readSpreadsheet(data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readXLSX(data)
+	}
+	return readCSV(data)
+}
+
+// writeSpreadsheet записывает строки таблицы. В XLSX значения столбцов
+// из numeric записываются числами, остальные — строками.
+func writeSpreadsheet(w io.Writer, format SpreadsheetFormat, rows [][]string, numeric map[int]bool) error {
+	if format == SpreadsheetXLSX {
+		return writeXLSX(w, rows, numeric)
+	}
+	return writeCSV(w, rows)
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	if !utf8.ValidString(text) {
+		return nil, fmt.Errorf("%w: CSV должен быть в кодировке UTF-8", ErrInvalidSpreadsheet)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// Excel с русской локалью сохраняет CSV через точку с запятой
+	header, _, _ := strings.Cut(text, "\n")
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+	}
+	return rows, nil
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	// BOM нужен, чтобы Excel открыл файл в UTF-8, а не в кодировке системы
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX читает первый лист книги.
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var shared xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	file, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("%w: в книге нет листов", ErrInvalidSpreadsheet)
+	}
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		index := row.Index - 1
+		if row.Index == 0 {
+			index = len(rows)
+		}
+		// Номер строки берется из файла: без проверки файл размером в пару
+		// килобайт заставил бы выделить память под миллионы пустых строк
+		if index < 0 || index > maxImportRows {
+			return nil, fmt.Errorf("%w: неверный номер строки %d", ErrInvalidSpreadsheet, row.Index)
+		}
+		for len(rows) <= index {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for _, cell := range row.Cells {
+			column := len(values)
+			if cell.Ref != "" {
+				if column, err = xlsxColumn(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			// Номер столбца тоже берется из файла: адрес XFD1 в одной ячейке
+			// растянул бы строку до шестнадцати тысяч значений
+			if column >= maxImportColumns {
+				return nil, fmt.Errorf("%w: в строке %d больше %d столбцов", ErrInvalidSpreadsheet, index+1, maxImportColumns)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: ячейка %s ссылается на несуществующую строку", ErrInvalidSpreadsheet, cell.Ref)
+				}
+				values[column] = shared.Items[i].String()
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			case "", "n":
+				values[column] = normalizeXLSXNumber(cell.Value)
+			default:
+				values[column] = cell.Value
+			}
+		}
+		rows[index] = values
+	}
+	return rows, nil
+}
+
+// firstSheetPath находит файл первого листа по workbook.xml и его связям.
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok || decodeXLSXPart(workbookFile, &workbook) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok || decodeXLSXPart(relsFile, &rels) != nil {
+		return fallback
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodeXLSXPart(file *zip.File, v interface{}) error {
+	if file.UncompressedSize64 > maxSheetSize {
+		return fmt.Errorf("%w: %s слишком большой", ErrInvalidSpreadsheet, file.Name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxSheetSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSpreadsheet, file.Name, err)
+	}
+	return nil
+}
+
+// normalizeXLSXNumber убирает двоичную погрешность, с которой Excel
+// сохраняет дробные числа (12.300000000000001 → 12.3).
+func normalizeXLSXNumber(value string) string {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// xlsxColumn возвращает номер столбца (с нуля) по адресу ячейки вида "AB12".
+func xlsxColumn(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("%w: неверный адрес ячейки %q", ErrInvalidSpreadsheet, ref)
+	}
+	return column - 1, nil
+}
+
+// xlsxColumnName возвращает буквенное имя столбца по номеру с нуля.
+func xlsxColumnName(column int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Товары" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// writeXLSX записывает книгу с одним листом. Строки хранятся в самих
+// ячейках (inlineStr), поэтому таблица общих строк не нужна.
+func writeXLSX(w io.Writer, rows [][]string, numeric map[int]bool) error {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			// Заголовок всегда текстовый
+			if i > 0 && numeric[j] {
+				if _, err := strconv.ParseFloat(value, 64); err == nil {
+					fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+					continue
+				}
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRootRels)},
+		{"xl/workbook.xml", []byte(xlsxWorkbookXML)},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+	for _, part := range parts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(part.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config содержит всю конфигурацию приложения.
@@ -13,6 +14,8 @@ type Config struct {
 	JWT      JWTConfig
 	Jaeger   JaegerConfig
 	Telegram TelegramConfig
+	Orders   OrdersConfig
+	Search   SearchConfig
 }
 
 // ServerConfig содержит конфигурацию сервера.
@@ -47,6 +50,37 @@ type TelegramConfig struct {
 	ChatID   string
 }
 
+// OrdersConfig содержит настройки жизненного цикла заказов.
+type OrdersConfig struct {
+	// SelfCancelWindow — сколько времени после создания покупатель может
+	// сам отменить заказ. Ноль снимает ограничение по времени.
+	SelfCancelWindow time.Duration
+	// ScheduleLead — за сколько до начала слота запланированный заказ
+	// передается магазину в работу.
+	ScheduleLead time.Duration
+	// SchedulerInterval — период проверки запланированных заказов.
+	SchedulerInterval time.Duration
+	// EscalateAfter — через сколько после попадания в очередь неподтвержденный
+	// заказ эскалируется. Ноль отключает эскалацию.
+	EscalateAfter time.Duration
+	// AutoCancelAfter — через сколько неподтвержденный заказ отменяется
+	// системой. Ноль отключает автоотмену.
+	AutoCancelAfter time.Duration
+	// StaleCheckInterval — период проверки неподтвержденных заказов.
+	StaleCheckInterval time.Duration
+	// TrackingTTL — срок действия ссылки отслеживания заказа.
+	TrackingTTL time.Duration
+	// DeliveryETA — ожидаемое время доставки заказа без слота
+	// с момента попадания в очередь магазина.
+	DeliveryETA time.Duration
+}
+
+// SearchConfig содержит настройки поиска.
+type SearchConfig struct {
+	// QueryLogFlushInterval — период сброса журнала поисковых запросов в базу.
+	QueryLogFlushInterval time.Duration
+}
+
 // Load загружает конфигурацию из переменных окружения.
 func Load() (*Config, error) {
 	cfg := &Config{
@@ -72,12 +106,36 @@ func Load() (*Config, error) {
 			BotToken: getEnv("TG_BOT_TOKEN", ""),
 			ChatID:   getEnv("TG_CHAT_ID", ""),
 		},
+		Orders: OrdersConfig{
+			SelfCancelWindow:   time.Duration(getEnvAsInt("ORDER_SELF_CANCEL_WINDOW_MINUTES", 15)) * time.Minute,
+			ScheduleLead:       time.Duration(getEnvAsInt("ORDER_SCHEDULE_LEAD_MINUTES", 60)) * time.Minute,
+			SchedulerInterval:  time.Duration(getEnvAsInt("ORDER_SCHEDULER_INTERVAL_SECONDS", 60)) * time.Second,
+			EscalateAfter:      time.Duration(getEnvAsInt("ORDER_ESCALATE_AFTER_MINUTES", 10)) * time.Minute,
+			AutoCancelAfter:    time.Duration(getEnvAsInt("ORDER_AUTO_CANCEL_AFTER_MINUTES", 30)) * time.Minute,
+			StaleCheckInterval: time.Duration(getEnvAsInt("ORDER_STALE_CHECK_INTERVAL_SECONDS", 60)) * time.Second,
+			TrackingTTL:        time.Duration(getEnvAsInt("ORDER_TRACKING_TTL_HOURS", 168)) * time.Hour,
+			DeliveryETA:        time.Duration(getEnvAsInt("ORDER_DELIVERY_ETA_MINUTES", 60)) * time.Minute,
+		},
+		Search: SearchConfig{
+			QueryLogFlushInterval: time.Duration(getEnvAsInt("SEARCH_QUERY_LOG_FLUSH_SECONDS", 10)) * time.Second,
+		},
 	}
 
 	if cfg.JWT.Secret == "your-secret-key-change-in-production" {
 		return nil, fmt.Errorf("JWT_SECRET должен быть установлен в переменных окружения")
 	}
 
+	// Интервалы фоновых задач задают период тикера, который не может быть нулевым
+	if err := requirePositive("ORDER_SCHEDULER_INTERVAL_SECONDS", cfg.Orders.SchedulerInterval); err != nil {
+		return nil, err
+	}
+	if err := requirePositive("ORDER_STALE_CHECK_INTERVAL_SECONDS", cfg.Orders.StaleCheckInterval); err != nil {
+		return nil, err
+	}
+	if err := requirePositive("SEARCH_QUERY_LOG_FLUSH_SECONDS", cfg.Search.QueryLogFlushInterval); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -87,6 +145,14 @@ func (d *DatabaseConfig) DSN() string {
 		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
 }
 
+// requirePositive проверяет, что интервал из переменной окружения key больше нуля.
+func requirePositive(key string, value time.Duration) error {
+	if value <= 0 {
+		return fmt.Errorf("%s должен быть больше нуля", key)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"Laman/internal/config"
@@ -15,6 +16,17 @@ type DB struct {
 	*sqlx.DB
 }
 
+// Querier описывает общие методы *sqlx.DB и *sqlx.Tx,
+// которые используют репозитории.
+type Querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+type txKey struct{}
+
 // New создает новое подключение к базе данных.
 func New(cfg *config.DatabaseConfig) (*DB, error) {
 	db, err := sqlx.Connect("postgres", cfg.DSN())
@@ -35,7 +47,7 @@ func (db *DB) Close() error {
 }
 
 // WithTx выполняет функцию в рамках транзакции.
-func (db *DB) WithTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
+func (db *DB) WithTx(ctx context.Context, fn func(*sqlx.Tx) error) (err error) {
 	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -55,3 +67,41 @@ func (db *DB) WithTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
 	err = fn(tx)
 	return err
 }
+
+// Conn возвращает транзакцию, открытую в контексте через UnitOfWork,
+// либо само подключение, если транзакции нет.
+func (db *DB) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db.DB
+}
+
+// UnitOfWork объединяет операции нескольких репозиториев в одну транзакцию.
+// Репозитории участвуют в ней, получая подключение через DB.Conn(ctx).
+type UnitOfWork interface {
+	// Do выполняет fn в транзакции: при ошибке или панике все изменения
+	// откатываются, иначе фиксируются. Вложенные вызовы используют
+	// уже открытую транзакцию.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// postgresUnitOfWork реализует UnitOfWork поверх DB.WithTx.
+type postgresUnitOfWork struct {
+	db *DB
+}
+
+// NewUnitOfWork создает новый UnitOfWork для PostgreSQL.
+func NewUnitOfWork(db *DB) UnitOfWork {
+	return &postgresUnitOfWork{db: db}
+}
+
+func (u *postgresUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	return u.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
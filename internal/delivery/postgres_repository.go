@@ -21,17 +21,20 @@ func NewPostgresDeliveryRepository(db *database.DB) DeliveryRepository {
 
 func (r *postgresDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
 	query := `
-		INSERT INTO deliveries (id, order_id, address, distance, weight, created_at, updated_at)
-		VALUES (:id, :order_id, :address, :distance, :weight, :created_at, :updated_at)
+		INSERT INTO deliveries (id, order_id, address, distance, weight, slot_id, slot_start, slot_end, created_at, updated_at)
+		VALUES (:id, :order_id, :address, :distance, :weight, :slot_id, :slot_start, :slot_end, :created_at, :updated_at)
 	`
-	_, err := r.db.NamedExecContext(ctx, query, delivery)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, delivery)
 	return err
 }
 
 func (r *postgresDeliveryRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Delivery, error) {
 	var delivery models.Delivery
-	query := `SELECT id, order_id, address, distance, weight, created_at, updated_at FROM deliveries WHERE order_id = $1`
-	err := r.db.GetContext(ctx, &delivery, query, orderID)
+	query := `
+		SELECT id, order_id, address, distance, weight, slot_id, slot_start, slot_end, created_at, updated_at
+		FROM deliveries WHERE order_id = $1
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &delivery, query, orderID)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("доставка не найдена")
 	}
@@ -47,6 +50,6 @@ func (r *postgresDeliveryRepository) Update(ctx context.Context, delivery *model
 		SET address = :address, distance = :distance, weight = :weight, updated_at = :updated_at
 		WHERE id = :id
 	`
-	_, err := r.db.NamedExecContext(ctx, query, delivery)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, delivery)
 	return err
 }
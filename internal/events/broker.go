@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// OrderEvent описывает изменение статуса заказа, доставляемое клиентам.
+// StoreID и UserID нужны подписчикам, чтобы отфильтровать чужие заказы.
+type OrderEvent struct {
+	OrderID    uuid.UUID                  `json:"order_id"`
+	StoreID    uuid.UUID                  `json:"store_id"`
+	UserID     *uuid.UUID                 `json:"user_id,omitempty"`
+	FromStatus *models.OrderStatus        `json:"from_status,omitempty"`
+	Status     models.OrderStatus         `json:"status"`
+	ActorRole  models.UserRole            `json:"actor_role"`
+	ReasonCode *models.CancellationReason `json:"reason_code,omitempty"`
+	Reason     *string                    `json:"reason,omitempty"`
+	OccurredAt time.Time                  `json:"occurred_at"`
+}
+
+// Broker доставляет события заказов подписчикам.
+// Реализация в памяти работает в пределах одного процесса; для нескольких
+// реплик API ее можно заменить реализацией на Postgres LISTEN/NOTIFY
+// с тем же интерфейсом.
+type Broker interface {
+	// Publish отправляет событие всем текущим подписчикам.
+	Publish(ctx context.Context, event OrderEvent) error
+
+	// Subscribe возвращает канал событий и функцию отписки.
+	// Канал закрывается после отписки или остановки брокера.
+	Subscribe() (<-chan OrderEvent, func())
+
+	// Close отключает всех подписчиков.
+	Close() error
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer — размер буфера канала подписчика. Если подписчик
+// не успевает читать, новые события для него отбрасываются, чтобы
+// медленный клиент не задерживал публикацию.
+const subscriberBuffer = 32
+
+// memoryBroker реализует Broker в памяти процесса.
+type memoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[chan OrderEvent]struct{}
+	closed      bool
+}
+
+// NewMemoryBroker создает новый брокер событий в памяти.
+func NewMemoryBroker() Broker {
+	return &memoryBroker{
+		subscribers: make(map[chan OrderEvent]struct{}),
+	}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, event OrderEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe() (<-chan OrderEvent, func()) {
+	ch := make(chan OrderEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[ch]; ok {
+				delete(b.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+	return ch, unsubscribe
+}
+
+func (b *memoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"Laman/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserLoader загружает пользователя, чтобы определить его роль.
+type UserLoader interface {
+	GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error)
+}
+
+// ActorMiddleware загружает пользователя, аутентифицированного AuthMiddleware,
+// и устанавливает в контексте models.Actor с его ролью.
+// Роль читается из базы на каждый запрос, чтобы ее изменение действовало сразу.
+func ActorMiddleware(loader UserLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+			c.Abort()
+			return
+		}
+
+		userIDUUID, ok := userID.(uuid.UUID)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "неверный ID пользователя"})
+			c.Abort()
+			return
+		}
+
+		user, err := loader.GetUser(c.Request.Context(), userIDUUID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+			c.Abort()
+			return
+		}
+
+		c.Set("actor", user.Actor())
+		c.Next()
+	}
+}
+
+// RequireRoles пропускает только пользователей с одной из указанных ролей.
+// Должен подключаться после ActorMiddleware.
+func RequireRoles(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := ActorFromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if actor.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "недостаточно прав"})
+		c.Abort()
+	}
+}
+
+// ActorFromContext возвращает участника, установленного ActorMiddleware.
+func ActorFromContext(c *gin.Context) (models.Actor, bool) {
+	value, ok := c.Get("actor")
+	if !ok {
+		return models.Actor{}, false
+	}
+	actor, ok := value.(models.Actor)
+	return actor, ok
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart представляет серверную корзину пользователя или гостя.
+// Гостевая корзина идентифицируется токеном, который клиент хранит у себя.
+type Cart struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	GuestToken *string    `db:"guest_token" json:"guest_token,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// CartItem представляет позицию в корзине.
+// Цена не хранится: она пересчитывается по каталогу при каждом чтении.
+type CartItem struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CartID    uuid.UUID `db:"cart_id" json:"cart_id"`
+	ProductID uuid.UUID `db:"product_id" json:"product_id"`
+	Quantity  int       `db:"quantity" json:"quantity"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
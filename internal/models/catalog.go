@@ -21,13 +21,24 @@ type Product struct {
 	CategoryID    uuid.UUID  `db:"category_id" json:"category_id"`
 	SubcategoryID *uuid.UUID `db:"subcategory_id" json:"subcategory_id,omitempty"`
 	StoreID       uuid.UUID  `db:"store_id" json:"store_id"`
+	SKU           *string    `db:"sku" json:"sku,omitempty"` // артикул, уникальный в пределах магазина
 	Name          string     `db:"name" json:"name"`
 	Description   *string    `db:"description" json:"description,omitempty"`
-	Price         float64    `db:"price" json:"price"`
+	Price         Money      `db:"price" json:"price"`
 	Weight        *float64   `db:"weight" json:"weight,omitempty"`
 	IsAvailable   bool       `db:"is_available" json:"is_available"`
+	Stock         *int       `db:"stock" json:"stock,omitempty"` // nil — остатки не отслеживаются
+	Rating        float64    `db:"rating" json:"rating"`
+	RatingCount   int        `db:"rating_count" json:"rating_count"`
+	SoldCount     int        `db:"sold_count" json:"-"` // единиц в неотмененных заказах, заполняется в списках
 	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+
+	// Заполняются только в результатах поиска. Совпадения в подсветке
+	// обрамлены тегами <mark>, остальной текст экранирован как HTML.
+	SearchRank           *float64 `db:"search_rank" json:"search_rank,omitempty"`
+	NameHighlight        *string  `db:"name_highlight" json:"name_highlight,omitempty"`
+	DescriptionHighlight *string  `db:"description_highlight" json:"description_highlight,omitempty"`
 }
 
 // Subcategory представляет подкатегорию товаров.
@@ -39,6 +50,13 @@ type Subcategory struct {
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// FacetCount представляет значение фильтра списка товаров и число товаров с ним.
+type FacetCount struct {
+	ID    uuid.UUID `db:"id" json:"id"`
+	Name  string    `db:"name" json:"name"`
+	Count int       `db:"count" json:"count"`
+}
+
 // Store представляет магазин, где можно приобрести товары.
 type Store struct {
 	ID           uuid.UUID         `db:"id" json:"id"`
@@ -48,6 +66,7 @@ type Store struct {
 	Description  *string           `db:"description" json:"description,omitempty"`
 	ImageURL     *string           `db:"image_url" json:"image_url,omitempty"`
 	Rating       float64           `db:"rating" json:"rating"`
+	RatingCount  int               `db:"rating_count" json:"rating_count"`
 	CategoryType StoreCategoryType `db:"category_type" json:"category_type"`
 	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time         `db:"updated_at" json:"updated_at"`
@@ -64,3 +83,14 @@ const (
 	StoreCategoryHome     StoreCategoryType = "HOME"
 	StoreCategoryPharmacy StoreCategoryType = "PHARMACY"
 )
+
+// Valid проверяет, что тип магазина известен.
+func (t StoreCategoryType) Valid() bool {
+	switch t {
+	case StoreCategoryFood, StoreCategoryClothes, StoreCategoryBuilding,
+		StoreCategoryAuto, StoreCategoryHome, StoreCategoryPharmacy:
+		return true
+	default:
+		return false
+	}
+}
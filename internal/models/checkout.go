@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckoutDeliveryMode определяет, как рассчитывается доставка
+// при оформлении товаров из нескольких магазинов.
+type CheckoutDeliveryMode string
+
+const (
+	// CheckoutDeliveryPerOrder — каждый подзаказ оплачивает свою доставку.
+	CheckoutDeliveryPerOrder CheckoutDeliveryMode = "PER_ORDER"
+	// CheckoutDeliveryCombined — одна доставка за все подзаказы по самому дорогому тарифу.
+	CheckoutDeliveryCombined CheckoutDeliveryMode = "COMBINED"
+)
+
+// CheckoutGroup объединяет заказы из разных магазинов, оформленные одним чекаутом.
+// Суммы зафиксированы на момент оформления; текущие суммы считаются по подзаказам.
+type CheckoutGroup struct {
+	ID            uuid.UUID            `db:"id" json:"id"`
+	UserID        *uuid.UUID           `db:"user_id" json:"user_id,omitempty"`
+	PaymentMethod PaymentMethod        `db:"payment_method" json:"payment_method"`
+	DeliveryMode  CheckoutDeliveryMode `db:"delivery_mode" json:"delivery_mode"`
+	ItemsTotal    Money                `db:"items_total" json:"items_total"`
+	ServiceFee    Money                `db:"service_fee" json:"service_fee"`
+	DeliveryFee   Money                `db:"delivery_fee" json:"delivery_fee"`
+	FinalTotal    Money                `db:"final_total" json:"final_total"`
+	CreatedAt     time.Time            `db:"created_at" json:"created_at"`
+}
+
+// CheckoutPayment представляет единую для покупателя оплату чекаута.
+type CheckoutPayment struct {
+	Method PaymentMethod `json:"method"`
+	Status PaymentStatus `json:"status"`
+	Amount Money         `json:"amount"`
+}
+
+// CheckoutGroupWithOrders представляет чекаут с подзаказами для отслеживания покупателем.
+// Tracking заполняется только в ответе на создание чекаута.
+type CheckoutGroupWithOrders struct {
+	CheckoutGroup
+	Status   OrderStatus      `json:"status"`
+	Payment  CheckoutPayment  `json:"payment"`
+	Orders   []OrderWithItems `json:"orders"`
+	Tracking *TrackingLink    `json:"tracking,omitempty"`
+}
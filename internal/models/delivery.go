@@ -13,6 +13,9 @@ type Delivery struct {
 	Address   string     `db:"address" json:"address"`
 	Distance  *float64   `db:"distance" json:"distance,omitempty"`
 	Weight    *float64   `db:"weight" json:"weight,omitempty"`
+	SlotID    *uuid.UUID `db:"slot_id" json:"slot_id,omitempty"`
+	SlotStart *time.Time `db:"slot_start" json:"slot_start,omitempty"`
+	SlotEnd   *time.Time `db:"slot_end" json:"slot_end,omitempty"`
 	CreatedAt time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
 }
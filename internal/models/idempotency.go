@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey хранит результат запроса, выполненного с заголовком Idempotency-Key.
+// Fingerprint — хеш тела запроса, Response — сохраненный JSON ответа.
+type IdempotencyKey struct {
+	Key         string    `db:"key" json:"key"`
+	Fingerprint string    `db:"fingerprint" json:"fingerprint"`
+	OrderID     uuid.UUID `db:"order_id" json:"order_id"`
+	Response    string    `db:"response" json:"response"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
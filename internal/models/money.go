@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money представляет денежную сумму в копейках.
+// В базе данных хранится как DECIMAL(10,2), в JSON сериализуется числом в рублях
+// с двумя знаками после запятой, поэтому формат API не меняется.
+//
+// Правило округления: все операции, дающие доли копейки (процент, умножение
+// на дробный коэффициент), округляются до копейки половиной от нуля (123.455 → 123.46).
+type Money int64
+
+// Kopecks создает сумму из количества копеек.
+func Kopecks(value int64) Money {
+	return Money(value)
+}
+
+// Rubles создает сумму из целого количества рублей.
+func Rubles(value int64) Money {
+	return Money(value * 100)
+}
+
+// moneyPattern задает формат денежной суммы: необязательный минус, целая
+// часть и не больше двух знаков после точки.
+var moneyPattern = regexp.MustCompile(`^-?\d+(\.\d{1,2})?$`)
+
+// ParseMoney разбирает десятичную строку вида "123", "123.4" или "-123.45".
+// Любой другой формат, в том числе больше двух знаков после точки, считается
+// ошибкой, чтобы не терять точность молча.
+func ParseMoney(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("пустая денежная сумма")
+	}
+	if !moneyPattern.MatchString(value) {
+		return 0, fmt.Errorf("неверная денежная сумма %q", value)
+	}
+
+	digits := strings.TrimPrefix(value, "-")
+	whole, frac, _ := strings.Cut(digits, ".")
+	frac += strings.Repeat("0", 2-len(frac))
+
+	rubles, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rubles > math.MaxInt64/100-1 {
+		return 0, fmt.Errorf("неверная денежная сумма %q: слишком большое значение", value)
+	}
+	kopecks, _ := strconv.ParseInt(frac, 10, 64)
+
+	amount := rubles*100 + kopecks
+	if strings.HasPrefix(value, "-") {
+		amount = -amount
+	}
+	return Money(amount), nil
+}
+
+// Kopecks возвращает сумму в копейках.
+func (m Money) Kopecks() int64 {
+	return int64(m)
+}
+
+// Mul умножает сумму на целое количество.
+func (m Money) Mul(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// MulPercent возвращает percent процентов от суммы. Процент учитывается
+// с точностью до сотых (5.25%), результат округляется до копейки.
+func (m Money) MulPercent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100))
+	return Money(divRound(int64(m)*basisPoints, 10000))
+}
+
+// MulFloat умножает сумму на дробный коэффициент (например, вес или расстояние)
+// и округляет результат до копейки.
+func (m Money) MulFloat(factor float64) Money {
+	return Money(int64(math.Round(float64(m) * factor)))
+}
+
+// String возвращает сумму в рублях с двумя знаками после запятой.
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// Scan реализует sql.Scanner для колонок DECIMAL.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case int64:
+		*m = Rubles(v)
+		return nil
+	default:
+		return fmt.Errorf("неподдерживаемый тип денежной суммы: %T", src)
+	}
+}
+
+// Value реализует driver.Valuer: сумма передается строкой, чтобы DECIMAL
+// получил точное значение без двоичной погрешности.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// MarshalJSON сериализует сумму числом в рублях.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON разбирает сумму из числа или строки в рублях.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// divRound делит с округлением половины от нуля.
+func divRound(numerator, denominator int64) int64 {
+	quotient := numerator / denominator
+	remainder := numerator % denominator
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= denominator {
+		if numerator < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
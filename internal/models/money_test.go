@@ -0,0 +1,115 @@
+package models
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{"0", 0},
+		{"123", 12300},
+		{"123.4", 12340},
+		{"123.45", 12345},
+		{"-123.45", -12345},
+		{"0.05", 5},
+		{"-0.5", -50},
+		{" 7.10 ", 710},
+		{"00012.30", 1230},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): неожиданная ошибка %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, ожидалось %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"-",
+		"+5",
+		"--5",
+		"12.+5",
+		"12.-5",
+		".5",
+		"5.",
+		"1.234",
+		"1,5",
+		"1e3",
+		"12 345",
+		"abc",
+		"92233720368547758.07",
+	}
+	for _, input := range inputs {
+		if got, err := ParseMoney(input); err == nil {
+			t.Errorf("ParseMoney(%q) = %d, ожидалась ошибка", input, got)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		value Money
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{12345, "123.45"},
+		{-50, "-0.50"},
+	}
+	for _, tt := range tests {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, ожидалось %q", tt.value, got, tt.want)
+		}
+		parsed, err := ParseMoney(tt.want)
+		if err != nil || parsed != tt.value {
+			t.Errorf("ParseMoney(%q) = %d, %v, ожидалось %d", tt.want, parsed, err, tt.value)
+		}
+	}
+}
+
+func TestMulPercentRounding(t *testing.T) {
+	tests := []struct {
+		value   Money
+		percent float64
+		want    Money
+	}{
+		{10000, 5, 500},
+		{12345, 5.25, 648}, // 648.1125
+		{1, 50, 1},         // 0.5 → 1
+		{-1, 50, -1},       // -0.5 → -1
+		{3, 50, 2},         // 1.5 → 2
+		{-3, 50, -2},       // -1.5 → -2
+		{12346, 10, 1235},  // 1234.6 → 1235
+		{12344, 10, 1234},  // 1234.4 → 1234
+	}
+	for _, tt := range tests {
+		if got := tt.value.MulPercent(tt.percent); got != tt.want {
+			t.Errorf("Money(%d).MulPercent(%v) = %d, ожидалось %d", tt.value, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestMulFloatRounding(t *testing.T) {
+	tests := []struct {
+		value  Money
+		factor float64
+		want   Money
+	}{
+		{5, 0.5, 3},   // 2.5 → 3
+		{-5, 0.5, -3}, // -2.5 → -3
+		{1000, 1.5, 1500},
+		{333, 0.1, 33},
+	}
+	for _, tt := range tests {
+		if got := tt.value.MulFloat(tt.factor); got != tt.want {
+			t.Errorf("Money(%d).MulFloat(%v) = %d, ожидалось %d", tt.value, tt.factor, got, tt.want)
+		}
+	}
+}
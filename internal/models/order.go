@@ -10,6 +10,9 @@ import (
 type OrderStatus string
 
 const (
+	// OrderStatusScheduled — заказ к определенному слоту доставки; попадает
+	// в работу (NEW) незадолго до начала слота.
+	OrderStatusScheduled         OrderStatus = "SCHEDULED"
 	OrderStatusNew               OrderStatus = "NEW"
 	OrderStatusNeedsConfirmation OrderStatus = "NEEDS_CONFIRMATION"
 	OrderStatusConfirmed         OrderStatus = "CONFIRMED"
@@ -18,38 +21,128 @@ const (
 	OrderStatusCancelled         OrderStatus = "CANCELLED"
 )
 
+// CancellationReason представляет код причины отмены заказа.
+type CancellationReason string
+
+const (
+	CancellationReasonCustomerChangedMind CancellationReason = "CUSTOMER_CHANGED_MIND"
+	CancellationReasonOutOfStock          CancellationReason = "OUT_OF_STOCK"
+	CancellationReasonStoreClosed         CancellationReason = "STORE_CLOSED"
+	CancellationReasonCourierUnavailable  CancellationReason = "COURIER_UNAVAILABLE"
+	// CancellationReasonStoreTimeout назначается только системой, когда
+	// магазин не подтвердил заказ вовремя.
+	CancellationReasonStoreTimeout CancellationReason = "STORE_TIMEOUT"
+)
+
+// cancellationReasonTitles содержит названия причин для уведомлений.
+var cancellationReasonTitles = map[CancellationReason]string{
+	CancellationReasonCustomerChangedMind: "Клиент передумал",
+	CancellationReasonOutOfStock:          "Нет в наличии",
+	CancellationReasonStoreClosed:         "Магазин закрыт",
+	CancellationReasonCourierUnavailable:  "Нет свободного курьера",
+	CancellationReasonStoreTimeout:        "Магазин не подтвердил заказ вовремя",
+}
+
+// Valid проверяет, что код причины известен.
+func (r CancellationReason) Valid() bool {
+	_, ok := cancellationReasonTitles[r]
+	return ok
+}
+
+// SystemOnly сообщает, что причину может указать только система.
+func (r CancellationReason) SystemOnly() bool {
+	return r == CancellationReasonStoreTimeout
+}
+
+// Title возвращает название причины на русском языке.
+func (r CancellationReason) Title() string {
+	if title, ok := cancellationReasonTitles[r]; ok {
+		return title
+	}
+	return string(r)
+}
+
 // Order представляет заказ в системе.
 // Поддерживает как зарегистрированных пользователей, так и гостевые заказы.
 type Order struct {
-	ID            uuid.UUID     `db:"id" json:"id"`
-	UserID        *uuid.UUID    `db:"user_id" json:"user_id,omitempty"`
-	GuestName     *string       `db:"guest_name" json:"guest_name,omitempty"`
-	GuestPhone    *string       `db:"guest_phone" json:"guest_phone,omitempty"`
-	GuestAddress  *string       `db:"guest_address" json:"guest_address,omitempty"`
-	Comment       *string       `db:"comment" json:"comment,omitempty"`
-	Status        OrderStatus   `db:"status" json:"status"`
-	StoreID       uuid.UUID     `db:"store_id" json:"store_id"`
-	PaymentMethod PaymentMethod `db:"payment_method" json:"payment_method"`
-	ItemsTotal    float64       `db:"items_total" json:"items_total"`
-	ServiceFee    float64       `db:"service_fee" json:"service_fee"`
-	DeliveryFee   float64       `db:"delivery_fee" json:"delivery_fee"`
-	FinalTotal    float64       `db:"final_total" json:"final_total"`
-	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
+	ID                  uuid.UUID           `db:"id" json:"id"`
+	UserID              *uuid.UUID          `db:"user_id" json:"user_id,omitempty"`
+	GuestName           *string             `db:"guest_name" json:"guest_name,omitempty"`
+	GuestPhone          *string             `db:"guest_phone" json:"guest_phone,omitempty"`
+	GuestAddress        *string             `db:"guest_address" json:"guest_address,omitempty"`
+	Comment             *string             `db:"comment" json:"comment,omitempty"`
+	Status              OrderStatus         `db:"status" json:"status"`
+	StoreID             uuid.UUID           `db:"store_id" json:"store_id"`
+	PaymentMethod       PaymentMethod       `db:"payment_method" json:"payment_method"`
+	ItemsTotal          Money               `db:"items_total" json:"items_total"`
+	ServiceFee          Money               `db:"service_fee" json:"service_fee"`
+	DeliveryFee         Money               `db:"delivery_fee" json:"delivery_fee"`
+	FinalTotal          Money               `db:"final_total" json:"final_total"`
+	PricingRuleID       *uuid.UUID          `db:"pricing_rule_id" json:"pricing_rule_id,omitempty"`
+	CreatedAt           time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time           `db:"updated_at" json:"updated_at"`
+	CancellationReason  *CancellationReason `db:"cancellation_reason" json:"cancellation_reason,omitempty"`
+	CancellationComment *string             `db:"cancellation_comment" json:"cancellation_comment,omitempty"`
+	AwaitingApproval    bool                `db:"awaiting_approval" json:"awaiting_approval"`
+	CheckoutGroupID     *uuid.UUID          `db:"checkout_group_id" json:"checkout_group_id,omitempty"`
+	EscalatedAt         *time.Time          `db:"escalated_at" json:"escalated_at,omitempty"`
 }
 
+// OrderItemStatus представляет статус позиции заказа.
+type OrderItemStatus string
+
+const (
+	OrderItemStatusActive      OrderItemStatus = "ACTIVE"
+	OrderItemStatusUnavailable OrderItemStatus = "UNAVAILABLE"
+)
+
 // OrderItem представляет товар в заказе.
+// Позиции, которые магазин не может собрать, остаются в заказе со статусом
+// UNAVAILABLE и не участвуют в расчете сумм. OriginalQuantity хранит
+// количество до изменения магазином, SubstituteFor — позицию, вместо
+// которой предложена замена.
 type OrderItem struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	OrderID   uuid.UUID `db:"order_id" json:"order_id"`
-	ProductID uuid.UUID `db:"product_id" json:"product_id"`
-	Quantity  int       `db:"quantity" json:"quantity"`
-	Price     float64   `db:"price" json:"price"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID               uuid.UUID       `db:"id" json:"id"`
+	OrderID          uuid.UUID       `db:"order_id" json:"order_id"`
+	ProductID        uuid.UUID       `db:"product_id" json:"product_id"`
+	Quantity         int             `db:"quantity" json:"quantity"`
+	Price            Money           `db:"price" json:"price"`
+	Status           OrderItemStatus `db:"status" json:"status"`
+	OriginalQuantity *int            `db:"original_quantity" json:"original_quantity,omitempty"`
+	SubstituteFor    *uuid.UUID      `db:"substitute_for" json:"substitute_for,omitempty"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+}
+
+// IsActive сообщает, входит ли позиция в состав заказа.
+func (i OrderItem) IsActive() bool {
+	return i.Status != OrderItemStatusUnavailable
+}
+
+// OrderStatusEvent представляет запись истории статусов заказа.
+// FromStatus пуст для начального события создания заказа.
+type OrderStatusEvent struct {
+	ID          uuid.UUID           `db:"id" json:"id"`
+	OrderID     uuid.UUID           `db:"order_id" json:"order_id"`
+	FromStatus  *OrderStatus        `db:"from_status" json:"from_status,omitempty"`
+	ToStatus    OrderStatus         `db:"to_status" json:"to_status"`
+	ActorUserID *uuid.UUID          `db:"actor_user_id" json:"actor_user_id,omitempty"`
+	ActorRole   UserRole            `db:"actor_role" json:"actor_role"`
+	ReasonCode  *CancellationReason `db:"reason_code" json:"reason_code,omitempty"`
+	Reason      *string             `db:"reason" json:"reason,omitempty"`
+	CreatedAt   time.Time           `db:"created_at" json:"created_at"`
 }
 
 // OrderWithItems представляет заказ с его товарами.
+// Tracking заполняется только в ответе на создание заказа.
 type OrderWithItems struct {
 	Order
-	Items []OrderItem `json:"items"`
+	Items    []OrderItem        `json:"items"`
+	History  []OrderStatusEvent `json:"history,omitempty"`
+	Tracking *TrackingLink      `json:"tracking,omitempty"`
+}
+
+// TrackingLink представляет подписанную ссылку отслеживания заказа или чекаута без входа в аккаунт.
+type TrackingLink struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TemplateCadence задает периодичность автоматического повтора шаблона заказа.
+type TemplateCadence string
+
+const (
+	TemplateCadenceWeekly   TemplateCadence = "WEEKLY"
+	TemplateCadenceBiweekly TemplateCadence = "BIWEEKLY"
+	TemplateCadenceMonthly  TemplateCadence = "MONTHLY"
+)
+
+// Valid проверяет, что периодичность известна.
+func (c TemplateCadence) Valid() bool {
+	switch c {
+	case TemplateCadenceWeekly, TemplateCadenceBiweekly, TemplateCadenceMonthly:
+		return true
+	default:
+		return false
+	}
+}
+
+// Next возвращает момент следующего повтора после t.
+func (c TemplateCadence) Next(t time.Time) time.Time {
+	switch c {
+	case TemplateCadenceBiweekly:
+		return t.AddDate(0, 0, 14)
+	case TemplateCadenceMonthly:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 7)
+	}
+}
+
+// OrderTemplate представляет сохраненный покупателем набор товаров с адресом
+// и способом оплаты. Если задана периодичность, заказ по шаблону создается
+// автоматически в NextRunAt.
+type OrderTemplate struct {
+	ID              uuid.UUID           `db:"id" json:"id"`
+	UserID          uuid.UUID           `db:"user_id" json:"user_id"`
+	Name            string              `db:"name" json:"name"`
+	PaymentMethod   PaymentMethod       `db:"payment_method" json:"payment_method"`
+	DeliveryAddress string              `db:"delivery_address" json:"delivery_address"`
+	Distance        *float64            `db:"distance" json:"distance,omitempty"`
+	Comment         *string             `db:"comment" json:"comment,omitempty"`
+	Cadence         *TemplateCadence    `db:"cadence" json:"cadence,omitempty"`
+	NextRunAt       *time.Time          `db:"next_run_at" json:"next_run_at,omitempty"`
+	LastRunAt       *time.Time          `db:"last_run_at" json:"last_run_at,omitempty"`
+	LastOrderID     *uuid.UUID          `db:"last_order_id" json:"last_order_id,omitempty"`
+	LastError       *string             `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt       time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `db:"updated_at" json:"updated_at"`
+	Items           []OrderTemplateItem `db:"-" json:"items"`
+}
+
+// OrderTemplateItem представляет товар шаблона. Price — цена на момент
+// сохранения, по ней покупателю сообщается об изменении цены.
+type OrderTemplateItem struct {
+	TemplateID uuid.UUID `db:"template_id" json:"-"`
+	ProductID  uuid.UUID `db:"product_id" json:"product_id"`
+	Quantity   int       `db:"quantity" json:"quantity"`
+	Price      Money     `db:"price" json:"price"`
+}
@@ -18,9 +18,11 @@ const (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending PaymentStatus = "pending"
-	PaymentStatusPaid    PaymentStatus = "paid"
-	PaymentStatusFailed  PaymentStatus = "failed"
+	PaymentStatusPending       PaymentStatus = "pending"
+	PaymentStatusPaid          PaymentStatus = "paid"
+	PaymentStatusFailed        PaymentStatus = "failed"
+	PaymentStatusCancelled     PaymentStatus = "cancelled"
+	PaymentStatusRefundPending PaymentStatus = "refund_pending"
 )
 
 // Payment представляет оплату заказа.
@@ -29,7 +31,7 @@ type Payment struct {
 	OrderID       uuid.UUID     `db:"order_id" json:"order_id"`
 	Method        PaymentMethod `db:"method" json:"method"`
 	Status        PaymentStatus `db:"status" json:"status"`
-	Amount        float64       `db:"amount" json:"amount"`
+	Amount        Money         `db:"amount" json:"amount"`
 	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PricingRule описывает правило расчета сервисного сбора и стоимости доставки.
+// Правило может относиться к конкретному магазину, к типу магазинов
+// или действовать по умолчанию, если оба поля не заданы.
+type PricingRule struct {
+	ID                    uuid.UUID          `db:"id" json:"id"`
+	Name                  string             `db:"name" json:"name"`
+	StoreID               *uuid.UUID         `db:"store_id" json:"store_id,omitempty"`
+	StoreCategoryType     *StoreCategoryType `db:"store_category_type" json:"store_category_type,omitempty"`
+	ServiceFeePercent     float64            `db:"service_fee_percent" json:"service_fee_percent"`
+	DeliveryFee           Money              `db:"delivery_fee" json:"delivery_fee"`
+	FreeDeliveryThreshold *Money             `db:"free_delivery_threshold" json:"free_delivery_threshold,omitempty"`
+	MinOrderAmount        *Money             `db:"min_order_amount" json:"min_order_amount,omitempty"`
+	WeightIncludedKg      *float64           `db:"weight_included_kg" json:"weight_included_kg,omitempty"`
+	WeightSurchargePerKg  *Money             `db:"weight_surcharge_per_kg" json:"weight_surcharge_per_kg,omitempty"`
+	DistanceIncludedKm    *float64           `db:"distance_included_km" json:"distance_included_km,omitempty"`
+	DistanceFeePerKm      *Money             `db:"distance_fee_per_km" json:"distance_fee_per_km,omitempty"`
+	IsActive              bool               `db:"is_active" json:"is_active"`
+	CreatedAt             time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time          `db:"updated_at" json:"updated_at"`
+}
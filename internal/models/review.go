@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewStatus представляет статус модерации отзыва.
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "PENDING"
+	ReviewStatusApproved ReviewStatus = "APPROVED"
+	ReviewStatusRejected ReviewStatus = "REJECTED"
+)
+
+// Review представляет оценку магазина или товара из доставленного заказа.
+// Отзыв о магазине не содержит ProductID. В рейтинг учитываются только
+// одобренные отзывы.
+type Review struct {
+	ID                uuid.UUID    `db:"id" json:"id"`
+	OrderID           uuid.UUID    `db:"order_id" json:"order_id"`
+	UserID            uuid.UUID    `db:"user_id" json:"user_id"`
+	StoreID           uuid.UUID    `db:"store_id" json:"store_id"`
+	ProductID         *uuid.UUID   `db:"product_id" json:"product_id,omitempty"`
+	Rating            int          `db:"rating" json:"rating"`
+	Text              *string      `db:"text" json:"text,omitempty"`
+	Status            ReviewStatus `db:"status" json:"status"`
+	ModerationComment *string      `db:"moderation_comment" json:"moderation_comment,omitempty"`
+	ModeratedBy       *uuid.UUID   `db:"moderated_by" json:"moderated_by,omitempty"`
+	ModeratedAt       *time.Time   `db:"moderated_at" json:"moderated_at,omitempty"`
+	CreatedAt         time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time    `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StoreHours представляет часы работы магазина в один день недели.
+// Weekday соответствует time.Weekday (0 — воскресенье), время в формате ЧЧ:ММ
+// по часовому поясу сервера.
+type StoreHours struct {
+	StoreID  uuid.UUID `db:"store_id" json:"store_id"`
+	Weekday  int       `db:"weekday" json:"weekday"`
+	OpensAt  string    `db:"opens_at" json:"opens_at"`
+	ClosesAt string    `db:"closes_at" json:"closes_at"`
+}
+
+// DeliverySlot представляет опубликованный магазином интервал доставки
+// с ограничением числа заказов.
+type DeliverySlot struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	StoreID   uuid.UUID `db:"store_id" json:"store_id"`
+	StartsAt  time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt    time.Time `db:"ends_at" json:"ends_at"`
+	Capacity  int       `db:"capacity" json:"capacity"`
+	Reserved  int       `db:"reserved" json:"reserved"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Remaining возвращает число заказов, которые еще можно принять в слот.
+func (s DeliverySlot) Remaining() int {
+	if s.Reserved >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.Reserved
+}
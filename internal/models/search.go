@@ -0,0 +1,30 @@
+package models
+
+import "github.com/google/uuid"
+
+// SuggestionType представляет тип поисковой подсказки.
+type SuggestionType string
+
+const (
+	SuggestionTypeQuery       SuggestionType = "query"
+	SuggestionTypeProduct     SuggestionType = "product"
+	SuggestionTypeSubcategory SuggestionType = "subcategory"
+	SuggestionTypeStore       SuggestionType = "store"
+)
+
+// Suggestion представляет подсказку при вводе поискового запроса:
+// популярный запрос, название товара, подкатегорию или магазин.
+type Suggestion struct {
+	Type       SuggestionType `json:"type"`
+	Text       string         `json:"text"`
+	ID         *uuid.UUID     `json:"id,omitempty"`
+	CategoryID *uuid.UUID     `json:"category_id,omitempty"` // для подкатегории
+	StoreID    *uuid.UUID     `json:"store_id,omitempty"`    // для товара
+}
+
+// SearchQueryStat представляет число поисков и подсказок по запросу.
+type SearchQueryStat struct {
+	Query        string `db:"query" json:"query"`
+	SearchCount  int64  `db:"search_count" json:"search_count"`
+	SuggestCount int64  `db:"suggest_count" json:"suggest_count"`
+}
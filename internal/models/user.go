@@ -6,12 +6,51 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserRole представляет роль пользователя.
+type UserRole string
+
+const (
+	UserRoleCustomer UserRole = "CUSTOMER"
+	UserRoleStore    UserRole = "STORE"
+	UserRoleCourier  UserRole = "COURIER"
+	UserRoleAdmin    UserRole = "ADMIN"
+
+	// UserRoleGuest обозначает гостя без аккаунта. Используется только
+	// как роль участника действия и не хранится в users.
+	UserRoleGuest UserRole = "GUEST"
+
+	// UserRoleSystem обозначает фоновые процессы сервиса, например
+	// планировщик. Используется только как роль участника действия.
+	UserRoleSystem UserRole = "SYSTEM"
+)
+
 // User представляет зарегистрированного пользователя в системе.
+// StoreID задан только для сотрудников магазина.
 type User struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Phone     string    `db:"phone" json:"phone"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Phone     string     `db:"phone" json:"phone"`
+	Role      UserRole   `db:"role" json:"role"`
+	StoreID   *uuid.UUID `db:"store_id" json:"store_id,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Actor возвращает описание пользователя как участника действия.
+func (u *User) Actor() Actor {
+	id := u.ID
+	return Actor{
+		UserID:  &id,
+		Role:    u.Role,
+		StoreID: u.StoreID,
+	}
+}
+
+// Actor описывает, кто выполняет действие: пользователь с ролью
+// и, для сотрудника магазина, его магазин.
+type Actor struct {
+	UserID  *uuid.UUID `json:"user_id,omitempty"`
+	Role    UserRole   `json:"role"`
+	StoreID *uuid.UUID `json:"store_id,omitempty"`
 }
 
 // UserProfile представляет информацию профиля пользователя.
@@ -29,6 +29,7 @@ type OrderMessageMeta struct {
 	Comment  string
 	Address  string
 	Items    string
+	Delivery string
 }
 
 type orderMessageMetaKey struct{}
@@ -69,43 +70,25 @@ func (n *TelegramNotifier) NotifyNewOrder(ctx context.Context, order *models.Ord
 	}
 
 	meta, _ := orderMessageMetaFromContext(ctx)
-	message := buildOrderMessage(order, meta)
-
-	payload := sendMessageRequest{
-		ChatID:                n.chatID,
-		Text:                  message,
-		ParseMode:             "HTML",
-		DisableWebPagePreview: true,
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("%s/bot%s/sendMessage", n.apiBase, n.botToken)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
+	return n.send(ctx, buildOrderMessage(order, meta))
+}
 
-	resp, err := n.client.Do(req)
-	if err != nil {
-		return err
+// NotifyOrderCancelled отправляет уведомление об отмене заказа.
+func (n *TelegramNotifier) NotifyOrderCancelled(ctx context.Context, order *models.Order) error {
+	if n == nil {
+		return nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("telegram api returned %s: %s", resp.Status, string(respBody))
+	if order == nil {
+		return errors.New("order is nil")
 	}
 
-	return nil
+	meta, _ := orderMessageMetaFromContext(ctx)
+	return n.send(ctx, buildCancelledOrderMessage(order, meta))
 }
 
-// NotifyOrderCancelled отправляет уведомление об отмене заказа.
-func (n *TelegramNotifier) NotifyOrderCancelled(ctx context.Context, order *models.Order) error {
+// NotifyOrderEscalated отправляет напоминание о заказе, который магазин
+// не подтверждает дольше waiting.
+func (n *TelegramNotifier) NotifyOrderEscalated(ctx context.Context, order *models.Order, waiting time.Duration) error {
 	if n == nil {
 		return nil
 	}
@@ -114,8 +97,11 @@ func (n *TelegramNotifier) NotifyOrderCancelled(ctx context.Context, order *mode
 	}
 
 	meta, _ := orderMessageMetaFromContext(ctx)
-	message := buildCancelledOrderMessage(order, meta)
+	return n.send(ctx, buildEscalatedOrderMessage(order, meta, waiting))
+}
 
+// send отправляет HTML сообщение в чат.
+func (n *TelegramNotifier) send(ctx context.Context, message string) error {
 	payload := sendMessageRequest{
 		ChatID:                n.chatID,
 		Text:                  message,
@@ -163,6 +149,7 @@ func buildOrderMessage(order *models.Order, meta OrderMessageMeta) string {
 	comment := fallback(meta.Comment, "—")
 	address := fallback(meta.Address, "—")
 	items := fallback(meta.Items, "—")
+	delivery := fallback(meta.Delivery, "как можно скорее")
 
 	createdAt := order.CreatedAt.Local().Format("15:04")
 	total := formatMoney(order.FinalTotal)
@@ -175,6 +162,7 @@ func buildOrderMessage(order *models.Order, meta OrderMessageMeta) string {
 			"<b>📍 Адрес:</b> %s\n"+
 			"<b>💰 Итого:</b> %s\n"+
 			"<b>📦 Товары:</b> %s\n"+
+			"<b>🗓 Доставка:</b> %s\n"+
 			"<b>⏰ Время:</b> %s",
 		html.EscapeString(shortID),
 		html.EscapeString(customer),
@@ -183,6 +171,7 @@ func buildOrderMessage(order *models.Order, meta OrderMessageMeta) string {
 		html.EscapeString(address),
 		html.EscapeString(total),
 		html.EscapeString(items),
+		html.EscapeString(delivery),
 		html.EscapeString(createdAt),
 	)
 }
@@ -194,12 +183,14 @@ func buildCancelledOrderMessage(order *models.Order, meta OrderMessageMeta) stri
 	comment := fallback(meta.Comment, "—")
 	address := fallback(meta.Address, "—")
 	items := fallback(meta.Items, "—")
+	reason := buildCancellationReason(order)
 
 	createdAt := order.CreatedAt.Local().Format("15:04")
 	total := formatMoney(order.FinalTotal)
 
 	return fmt.Sprintf(
 		"<b>❌ Заказ отменён</b> <code>%s</code>\n"+
+			"<b>❓ Причина:</b> %s\n"+
 			"<b>👤 Клиент:</b> %s\n"+
 			"<b>📞 Телефон:</b> %s\n"+
 			"<b>📝 Комментарий:</b> %s\n"+
@@ -208,6 +199,7 @@ func buildCancelledOrderMessage(order *models.Order, meta OrderMessageMeta) stri
 			"<b>📦 Товары:</b> %s\n"+
 			"<b>⏰ Время:</b> %s",
 		html.EscapeString(shortID),
+		html.EscapeString(reason),
 		html.EscapeString(customer),
 		html.EscapeString(phone),
 		html.EscapeString(comment),
@@ -218,6 +210,50 @@ func buildCancelledOrderMessage(order *models.Order, meta OrderMessageMeta) stri
 	)
 }
 
+func buildEscalatedOrderMessage(order *models.Order, meta OrderMessageMeta, waiting time.Duration) string {
+	shortID := shortOrderID(order.ID.String())
+	customer := fallback(meta.Customer, "Гость")
+	phone := fallback(meta.Phone, "—")
+	address := fallback(meta.Address, "—")
+	items := fallback(meta.Items, "—")
+
+	createdAt := order.CreatedAt.Local().Format("15:04")
+	total := formatMoney(order.FinalTotal)
+
+	return fmt.Sprintf(
+		"<b>⚠️ Заказ не подтвержден</b> <code>%s</code>\n"+
+			"<b>⏳ Ожидает:</b> более %d мин\n"+
+			"<b>📌 Статус:</b> %s\n"+
+			"<b>👤 Клиент:</b> %s\n"+
+			"<b>📞 Телефон:</b> %s\n"+
+			"<b>📍 Адрес:</b> %s\n"+
+			"<b>💰 Итого:</b> %s\n"+
+			"<b>📦 Товары:</b> %s\n"+
+			"<b>⏰ Время:</b> %s",
+		html.EscapeString(shortID),
+		int(waiting.Minutes()),
+		html.EscapeString(string(order.Status)),
+		html.EscapeString(customer),
+		html.EscapeString(phone),
+		html.EscapeString(address),
+		html.EscapeString(total),
+		html.EscapeString(items),
+		html.EscapeString(createdAt),
+	)
+}
+
+// buildCancellationReason возвращает причину отмены с комментарием, если он указан.
+func buildCancellationReason(order *models.Order) string {
+	if order.CancellationReason == nil {
+		return "—"
+	}
+	reason := order.CancellationReason.Title()
+	if order.CancellationComment != nil && *order.CancellationComment != "" {
+		reason += " (" + *order.CancellationComment + ")"
+	}
+	return reason
+}
+
 func shortOrderID(id string) string {
 	if len(id) <= 8 {
 		return id
@@ -232,9 +268,10 @@ func fallback(value, def string) string {
 	return value
 }
 
-func formatMoney(amount float64) string {
-	if amount == float64(int64(amount)) {
-		return fmt.Sprintf("%.0f₽", amount)
+// formatMoney форматирует сумму: целые рубли без копеек, иначе с двумя знаками.
+func formatMoney(amount models.Money) string {
+	if amount.Kopecks()%100 == 0 {
+		return fmt.Sprintf("%d₽", amount.Kopecks()/100)
 	}
-	return fmt.Sprintf("%.2f₽", amount)
+	return amount.String() + "₽"
 }
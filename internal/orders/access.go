@@ -0,0 +1,146 @@
+package orders
+
+import (
+	"errors"
+
+	"Laman/internal/events"
+	"Laman/internal/models"
+)
+
+// ErrForbidden возвращается, когда у участника нет прав на операцию с заказом.
+var ErrForbidden = errors.New("недостаточно прав для операции с заказом")
+
+// roleTargetStatuses перечисляет статусы, в которые может перевести заказ каждая роль.
+// Допустимость самого перехода дополнительно проверяет isValidStateTransition;
+// в NEW можно перейти только из SCHEDULED, досрочно запустив сборку.
+var roleTargetStatuses = map[models.UserRole][]models.OrderStatus{
+	models.UserRoleCustomer: {
+		models.OrderStatusCancelled,
+	},
+	models.UserRoleStore: {
+		models.OrderStatusNew,
+		models.OrderStatusNeedsConfirmation,
+		models.OrderStatusConfirmed,
+		models.OrderStatusInProgress,
+		models.OrderStatusCancelled,
+	},
+	models.UserRoleCourier: {
+		models.OrderStatusInProgress,
+		models.OrderStatusDelivered,
+	},
+	models.UserRoleAdmin: {
+		models.OrderStatusNew,
+		models.OrderStatusNeedsConfirmation,
+		models.OrderStatusConfirmed,
+		models.OrderStatusInProgress,
+		models.OrderStatusDelivered,
+		models.OrderStatusCancelled,
+	},
+}
+
+// canViewOrder проверяет, может ли участник видеть заказ:
+// покупатель — свои заказы, магазин — заказы своего магазина,
+// курьер — заказы, готовые к доставке, администратор — все.
+func canViewOrder(actor models.Actor, order *models.Order) bool {
+	switch actor.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleStore:
+		return actor.StoreID != nil && *actor.StoreID == order.StoreID
+	case models.UserRoleCourier:
+		return order.Status == models.OrderStatusConfirmed ||
+			order.Status == models.OrderStatusInProgress ||
+			order.Status == models.OrderStatusDelivered
+	case models.UserRoleCustomer:
+		return actor.UserID != nil && order.UserID != nil && *actor.UserID == *order.UserID
+	default:
+		return false
+	}
+}
+
+// canChangeStatus проверяет, может ли участник перевести заказ в статус next.
+func canChangeStatus(actor models.Actor, order *models.Order, next models.OrderStatus) bool {
+	if !canViewOrder(actor, order) {
+		return false
+	}
+
+	for _, status := range roleTargetStatuses[actor.Role] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// canAdjustOrder проверяет, может ли участник менять состав заказа:
+// это делает магазин заказа или администратор.
+func canAdjustOrder(actor models.Actor, order *models.Order) bool {
+	if actor.Role != models.UserRoleStore && actor.Role != models.UserRoleAdmin {
+		return false
+	}
+	return canViewOrder(actor, order)
+}
+
+// canApproveAdjustment проверяет, может ли участник согласовать изменения состава.
+// Изменения согласует покупатель; по гостевому заказу магазин подтверждает
+// их после звонка клиенту.
+func canApproveAdjustment(actor models.Actor, order *models.Order) bool {
+	switch actor.Role {
+	case models.UserRoleCustomer, models.UserRoleAdmin:
+		return canViewOrder(actor, order)
+	case models.UserRoleStore:
+		return order.UserID == nil && canViewOrder(actor, order)
+	default:
+		return false
+	}
+}
+
+// canViewCheckout проверяет, может ли участник видеть чекаут целиком.
+func canViewCheckout(actor models.Actor, group *models.CheckoutGroup) bool {
+	switch actor.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleCustomer:
+		return actor.UserID != nil && group.UserID != nil && *actor.UserID == *group.UserID
+	default:
+		return false
+	}
+}
+
+// canViewEvent проверяет, может ли участник получить событие заказа.
+// Событие видно, если заказ был виден участнику до или после перехода,
+// чтобы курьер узнал об отмене уже взятого заказа.
+func canViewEvent(actor models.Actor, event events.OrderEvent) bool {
+	order := &models.Order{
+		ID:      event.OrderID,
+		StoreID: event.StoreID,
+		UserID:  event.UserID,
+		Status:  event.Status,
+	}
+	if canViewOrder(actor, order) {
+		return true
+	}
+
+	if event.FromStatus == nil {
+		return false
+	}
+	order.Status = *event.FromStatus
+	return canViewOrder(actor, order)
+}
+
+// canReorder проверяет, может ли участник повторить заказ: это делает
+// только покупатель, оформивший его.
+func canReorder(actor models.Actor, order *models.Order) bool {
+	return actor.Role == models.UserRoleCustomer &&
+		actor.UserID != nil && order.UserID != nil && *actor.UserID == *order.UserID
+}
+
+// canManageTemplates проверяет, может ли участник вести шаблоны заказов.
+func canManageTemplates(actor models.Actor) bool {
+	return actor.Role == models.UserRoleCustomer && actor.UserID != nil
+}
+
+// canManageTemplate проверяет, принадлежит ли шаблон участнику.
+func canManageTemplate(actor models.Actor, template *models.OrderTemplate) bool {
+	return canManageTemplates(actor) && *actor.UserID == template.UserID
+}
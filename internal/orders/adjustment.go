@@ -0,0 +1,405 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/catalog"
+	"Laman/internal/models"
+	"Laman/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// AdjustmentAction представляет изменение позиции заказа магазином.
+type AdjustmentAction string
+
+const (
+	// AdjustmentRemove исключает позицию из заказа: товара нет в магазине,
+	// поэтому он не возвращается на остаток.
+	AdjustmentRemove AdjustmentAction = "REMOVE"
+	// AdjustmentSetQuantity меняет количество товара в позиции.
+	AdjustmentSetQuantity AdjustmentAction = "SET_QUANTITY"
+	// AdjustmentSubstitute заменяет позицию другим товаром того же магазина.
+	AdjustmentSubstitute AdjustmentAction = "SUBSTITUTE"
+)
+
+var (
+	// ErrAdjustmentNotAllowed возвращается при изменении состава подтвержденного заказа.
+	ErrAdjustmentNotAllowed = errors.New("изменить состав можно только до подтверждения заказа")
+
+	// ErrAwaitingApproval возвращается при подтверждении заказа, изменения которого не согласованы.
+	ErrAwaitingApproval = errors.New("заказ ожидает согласия покупателя с изменениями состава")
+
+	// ErrNoPendingAdjustment возвращается, когда заказ не ожидает согласования изменений.
+	ErrNoPendingAdjustment = errors.New("заказ не ожидает согласования изменений")
+
+	// ErrOrderItemNotFound возвращается, когда позиция не найдена среди активных позиций заказа.
+	ErrOrderItemNotFound = errors.New("позиция заказа не найдена")
+)
+
+// AdjustOrderRequest представляет запрос магазина на изменение состава заказа.
+type AdjustOrderRequest struct {
+	Items   []ItemAdjustment `json:"items" binding:"required,min=1,dive"`
+	Comment *string          `json:"comment,omitempty" binding:"omitempty,max=1000"`
+}
+
+// ItemAdjustment описывает изменение одной позиции.
+// Для SUBSTITUTE без quantity замена получает количество исходной позиции.
+type ItemAdjustment struct {
+	ItemID              uuid.UUID        `json:"item_id" binding:"required"`
+	Action              AdjustmentAction `json:"action" binding:"required,oneof=REMOVE SET_QUANTITY SUBSTITUTE"`
+	Quantity            int              `json:"quantity,omitempty" binding:"omitempty,min=1"`
+	SubstituteProductID *uuid.UUID       `json:"substitute_product_id,omitempty"`
+}
+
+// ApproveAdjustmentRequest представляет ответ покупателя на изменения состава.
+type ApproveAdjustmentRequest struct {
+	Comment *string `json:"comment,omitempty" binding:"omitempty,max=1000"`
+}
+
+// AdjustOrder применяет изменения состава заказа магазином: пересчитывает суммы,
+// сумму оплаты и остатки и переводит заказ в NEEDS_CONFIRMATION до согласия покупателя.
+func (s *OrderService) AdjustOrder(ctx context.Context, id uuid.UUID, req AdjustOrderRequest, actor models.Actor) (*models.OrderWithItems, error) {
+	var result *models.OrderWithItems
+	var event *models.OrderStatusEvent
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := s.lockOrder(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось получить заказ: %w", err)
+		}
+
+		if !canAdjustOrder(actor, order) {
+			return ErrForbidden
+		}
+		if order.Status != models.OrderStatusNew && order.Status != models.OrderStatusNeedsConfirmation {
+			return ErrAdjustmentNotAllowed
+		}
+
+		items, err := s.orderItemRepo.GetByOrderID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось получить товары заказа: %w", err)
+		}
+
+		productMap, err := s.adjustmentProducts(ctx, items, req.Items)
+		if err != nil {
+			return err
+		}
+
+		itemByID := make(map[uuid.UUID]*models.OrderItem, len(items))
+		for i := range items {
+			itemByID[items[i].ID] = &items[i]
+		}
+
+		var reserve, release, discard, created []models.OrderItem
+		changed := make(map[uuid.UUID]struct{}, len(req.Items))
+		now := time.Now()
+		for _, adj := range req.Items {
+			item, ok := itemByID[adj.ItemID]
+			if !ok || !item.IsActive() {
+				return fmt.Errorf("%w: %s", ErrOrderItemNotFound, adj.ItemID)
+			}
+
+			switch adj.Action {
+			case AdjustmentRemove:
+				discard = append(discard, *item)
+				item.Status = models.OrderItemStatusUnavailable
+
+			case AdjustmentSetQuantity:
+				if adj.Quantity < 1 {
+					return errors.New("для SET_QUANTITY нужно указать количество")
+				}
+				delta := adj.Quantity - item.Quantity
+				if delta > 0 {
+					reserve = append(reserve, models.OrderItem{ProductID: item.ProductID, Quantity: delta})
+				} else if delta < 0 {
+					release = append(release, models.OrderItem{ProductID: item.ProductID, Quantity: -delta})
+				}
+				if item.OriginalQuantity == nil {
+					original := item.Quantity
+					item.OriginalQuantity = &original
+				}
+				item.Quantity = adj.Quantity
+
+			case AdjustmentSubstitute:
+				substitute, err := substituteProduct(productMap, adj, order.StoreID)
+				if err != nil {
+					return err
+				}
+				quantity := adj.Quantity
+				if quantity == 0 {
+					quantity = item.Quantity
+				}
+
+				release = append(release, *item)
+				item.Status = models.OrderItemStatusUnavailable
+
+				originalID := item.ID
+				replacement := models.OrderItem{
+					ID:            uuid.New(),
+					OrderID:       order.ID,
+					ProductID:     substitute.ID,
+					Quantity:      quantity,
+					Price:         substitute.Price,
+					Status:        models.OrderItemStatusActive,
+					SubstituteFor: &originalID,
+					CreatedAt:     now,
+				}
+				created = append(created, replacement)
+				reserve = append(reserve, replacement)
+			}
+			changed[item.ID] = struct{}{}
+		}
+
+		for i := range items {
+			if _, ok := changed[items[i].ID]; !ok {
+				continue
+			}
+			if err := s.orderItemRepo.Update(ctx, &items[i]); err != nil {
+				return fmt.Errorf("не удалось обновить товар заказа: %w", err)
+			}
+		}
+		if err := s.orderItemRepo.CreateBatch(ctx, created); err != nil {
+			return fmt.Errorf("не удалось добавить замену: %w", err)
+		}
+
+		if err := s.applyStockChanges(ctx, reserve, release, discard, productMap); err != nil {
+			return err
+		}
+
+		allItems := append(items, created...)
+		if err := s.repriceOrder(ctx, order, activeItems(allItems), productMap); err != nil {
+			return err
+		}
+
+		// Заказ ждет согласия покупателя; новый заказ переходит в NEEDS_CONFIRMATION
+		previous := order.Status
+		order.Status = models.OrderStatusNeedsConfirmation
+		order.AwaitingApproval = true
+		order.UpdatedAt = now
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("не удалось обновить заказ: %w", err)
+		}
+		if order.CheckoutGroupID != nil {
+			if err := s.syncCheckout(ctx, *order.CheckoutGroupID); err != nil {
+				return err
+			}
+			// Пересчет чекаута мог перенести доставку на этот подзаказ или с него
+			if order, err = s.orderRepo.GetByID(ctx, id); err != nil {
+				return fmt.Errorf("не удалось получить заказ: %w", err)
+			}
+		}
+
+		if previous != order.Status {
+			event, err = s.recordStatusEvent(ctx, id, &previous, order.Status, actor, nil, req.Comment)
+			if err != nil {
+				return err
+			}
+		}
+
+		result = &models.OrderWithItems{Order: *order, Items: allItems}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, &result.Order, event)
+
+	return result, nil
+}
+
+// applyStockChanges сводит резервы, возвраты и снятия с продажи в изменения
+// по каждому товару и применяет их одним проходом в порядке возрастания ID,
+// как reserveStock: иначе замена A→B блокировала бы A раньше B и могла
+// взаимно заблокироваться с параллельным оформлением тех же товаров.
+func (s *OrderService) applyStockChanges(ctx context.Context, reserve, release, discard []models.OrderItem, productMap map[uuid.UUID]models.Product) error {
+	deltas := aggregateQuantities(reserve)
+	for productID, quantity := range aggregateQuantities(release) {
+		deltas[productID] -= quantity
+	}
+	discards := aggregateQuantities(discard)
+
+	affected := make(map[uuid.UUID]int, len(deltas)+len(discards))
+	for productID := range deltas {
+		affected[productID] = 0
+	}
+	for productID := range discards {
+		affected[productID] = 0
+	}
+
+	for _, productID := range sortedProductIDs(affected) {
+		delta := deltas[productID]
+		switch {
+		case delta > 0:
+			if err := s.productRepo.ReserveStock(ctx, productID, delta); err != nil {
+				if errors.Is(err, catalog.ErrInsufficientStock) {
+					return fmt.Errorf("недостаточно товара на складе: %s", productMap[productID].Name)
+				}
+				return fmt.Errorf("не удалось зарезервировать товар: %w", err)
+			}
+		case delta < 0:
+			if err := s.productRepo.ReleaseStock(ctx, productID, -delta); err != nil {
+				return fmt.Errorf("не удалось вернуть товар на склад: %w", err)
+			}
+		}
+		if quantity := discards[productID]; quantity > 0 {
+			if err := s.productRepo.DiscardReservation(ctx, productID, quantity); err != nil {
+				return fmt.Errorf("не удалось снять товар с продажи: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// adjustmentProducts загружает товары заказа и предложенных замен одним запросом.
+func (s *OrderService) adjustmentProducts(ctx context.Context, items []models.OrderItem, adjustments []ItemAdjustment) (map[uuid.UUID]models.Product, error) {
+	ids := make([]uuid.UUID, 0, len(items)+len(adjustments))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	for _, adj := range adjustments {
+		if adj.SubstituteProductID != nil {
+			ids = append(ids, *adj.SubstituteProductID)
+		}
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+
+	productMap := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+	return productMap, nil
+}
+
+// substituteProduct проверяет товар, предложенный на замену.
+func substituteProduct(productMap map[uuid.UUID]models.Product, adj ItemAdjustment, storeID uuid.UUID) (models.Product, error) {
+	if adj.SubstituteProductID == nil {
+		return models.Product{}, errors.New("для SUBSTITUTE нужно указать substitute_product_id")
+	}
+
+	product, ok := productMap[*adj.SubstituteProductID]
+	if !ok {
+		return models.Product{}, fmt.Errorf("товар для замены не найден: %s", *adj.SubstituteProductID)
+	}
+	if product.StoreID != storeID {
+		return models.Product{}, errors.New("замена должна быть из того же магазина")
+	}
+	if !product.IsAvailable {
+		return models.Product{}, fmt.Errorf("товар для замены недоступен: %s", product.Name)
+	}
+	return product, nil
+}
+
+// repriceOrder пересчитывает суммы заказа по активным позициям и обновляет
+// вес доставки и сумму оплаты. Проведенную оплату изменить нельзя.
+func (s *OrderService) repriceOrder(ctx context.Context, order *models.Order, items []models.OrderItem, productMap map[uuid.UUID]models.Product) error {
+	if len(items) == 0 {
+		return errors.New("в заказе не осталось товаров, заказ нужно отменить")
+	}
+
+	var itemsTotal models.Money
+	var totalWeight float64
+	for _, item := range items {
+		itemsTotal += item.Price.Mul(item.Quantity)
+		if product, ok := productMap[item.ProductID]; ok && product.Weight != nil {
+			totalWeight += *product.Weight * float64(item.Quantity)
+		}
+	}
+
+	delivery, err := s.deliveryRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить доставку: %w", err)
+	}
+
+	quote, err := s.pricer.Calculate(ctx, pricing.Input{
+		StoreID:     order.StoreID,
+		ItemsTotal:  itemsTotal,
+		TotalWeight: totalWeight,
+		Distance:    delivery.Distance,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось пересчитать заказ: %w", err)
+	}
+
+	payment, err := s.paymentRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить оплату: %w", err)
+	}
+	if payment.Status != models.PaymentStatusPending {
+		return errors.New("оплата заказа уже проведена, изменить сумму нельзя")
+	}
+	if err := s.paymentRepo.UpdateAmount(ctx, payment.ID, quote.FinalTotal); err != nil {
+		return fmt.Errorf("не удалось обновить сумму оплаты: %w", err)
+	}
+
+	delivery.Weight = &totalWeight
+	delivery.UpdatedAt = time.Now()
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("не удалось обновить доставку: %w", err)
+	}
+
+	order.ItemsTotal = itemsTotal
+	order.ServiceFee = quote.ServiceFee
+	order.DeliveryFee = quote.DeliveryFee
+	order.FinalTotal = quote.FinalTotal
+	order.PricingRuleID = &quote.RuleID
+	return nil
+}
+
+// ApproveAdjustment подтверждает изменения состава: заказ переходит в CONFIRMED.
+func (s *OrderService) ApproveAdjustment(ctx context.Context, id uuid.UUID, req ApproveAdjustmentRequest, actor models.Actor) error {
+	return s.changeStatus(ctx, id, statusChange{
+		status: models.OrderStatusConfirmed,
+		actor:  actor,
+		reason: req.Comment,
+		authorize: func(order *models.Order) bool {
+			return canApproveAdjustment(actor, order)
+		},
+		guard: requirePendingAdjustment,
+		apply: func(ctx context.Context, order *models.Order) error {
+			order.AwaitingApproval = false
+			order.UpdatedAt = time.Now()
+			if err := s.orderRepo.Update(ctx, order); err != nil {
+				return fmt.Errorf("не удалось обновить заказ: %w", err)
+			}
+			return nil
+		},
+	})
+}
+
+// RejectAdjustment отклоняет изменения состава: заказ отменяется без учета
+// окна самостоятельной отмены, ведь изменения предложил магазин.
+func (s *OrderService) RejectAdjustment(ctx context.Context, id uuid.UUID, req ApproveAdjustmentRequest, actor models.Actor) error {
+	comment := req.Comment
+	if comment == nil {
+		text := "покупатель не согласился с изменениями состава"
+		comment = &text
+	}
+
+	reason := models.CancellationReasonCustomerChangedMind
+	return s.changeStatus(ctx, id, statusChange{
+		status:       models.OrderStatusCancelled,
+		actor:        actor,
+		reason:       comment,
+		cancellation: &reason,
+		authorize: func(order *models.Order) bool {
+			return canApproveAdjustment(actor, order)
+		},
+		guard: requirePendingAdjustment,
+	})
+}
+
+// requirePendingAdjustment проверяет, что заказ ожидает согласования изменений.
+func requirePendingAdjustment(order *models.Order) error {
+	if !order.AwaitingApproval {
+		return ErrNoPendingAdjustment
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidCancellationReason возвращается для неизвестного кода причины отмены.
+	ErrInvalidCancellationReason = errors.New("неизвестная причина отмены")
+
+	// ErrSelfCancelNotAllowed возвращается, когда покупатель отменяет уже подтвержденный заказ.
+	ErrSelfCancelNotAllowed = errors.New("заказ уже подтвержден магазином, отмена возможна только через поддержку")
+
+	// ErrSelfCancelWindowExpired возвращается, когда истекло время самостоятельной отмены.
+	ErrSelfCancelWindowExpired = errors.New("время на самостоятельную отмену заказа истекло")
+)
+
+// CancelOrderRequest представляет запрос на отмену заказа.
+type CancelOrderRequest struct {
+	Reason  models.CancellationReason `json:"reason" binding:"required"`
+	Comment *string                   `json:"comment,omitempty" binding:"omitempty,max=1000"`
+}
+
+// CancelOrder отменяет заказ с указанием причины.
+// Покупатель может отменить заказ сам только до подтверждения магазином
+// и в пределах настроенного окна после создания.
+func (s *OrderService) CancelOrder(ctx context.Context, id uuid.UUID, req CancelOrderRequest, actor models.Actor) error {
+	if !req.Reason.Valid() || req.Reason.SystemOnly() {
+		return ErrInvalidCancellationReason
+	}
+
+	reason := req.Reason
+	return s.changeStatus(ctx, id, statusChange{
+		status:       models.OrderStatusCancelled,
+		actor:        actor,
+		reason:       req.Comment,
+		cancellation: &reason,
+		guard: func(order *models.Order) error {
+			if actor.Role != models.UserRoleCustomer {
+				return nil
+			}
+			return s.checkSelfCancel(order, time.Now())
+		},
+	})
+}
+
+// checkSelfCancel проверяет, может ли покупатель сам отменить заказ.
+// Запланированный заказ можно отменить в любой момент до начала сборки.
+func (s *OrderService) checkSelfCancel(order *models.Order, now time.Time) error {
+	if order.Status == models.OrderStatusScheduled {
+		return nil
+	}
+	if order.Status != models.OrderStatusNew && order.Status != models.OrderStatusNeedsConfirmation {
+		return ErrSelfCancelNotAllowed
+	}
+	if s.cancelWindow > 0 && now.Sub(order.CreatedAt) > s.cancelWindow {
+		return ErrSelfCancelWindowExpired
+	}
+	return nil
+}
+
+// cancelPayment закрывает оплату отмененного заказа: неоплаченная
+// отменяется, по оплаченной ожидается возврат.
+func (s *OrderService) cancelPayment(ctx context.Context, orderID uuid.UUID) error {
+	payment, err := s.paymentRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить оплату: %w", err)
+	}
+
+	var status models.PaymentStatus
+	switch payment.Status {
+	case models.PaymentStatusPending:
+		status = models.PaymentStatusCancelled
+	case models.PaymentStatusPaid:
+		status = models.PaymentStatusRefundPending
+	default:
+		return nil
+	}
+
+	if err := s.paymentRepo.UpdateStatus(ctx, payment.ID, status); err != nil {
+		return fmt.Errorf("не удалось обновить статус оплаты: %w", err)
+	}
+	return nil
+}
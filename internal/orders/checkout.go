@@ -0,0 +1,395 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"Laman/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// CreateCheckoutRequest представляет запрос на оформление товаров из нескольких магазинов.
+// Товары разбиваются на подзаказы по магазинам; контакты, адрес и способ оплаты общие.
+type CreateCheckoutRequest struct {
+	CreateOrderRequest
+	DeliveryMode models.CheckoutDeliveryMode `json:"delivery_mode,omitempty" binding:"omitempty,oneof=PER_ORDER COMBINED"`
+}
+
+// ErrSlotRequiresSingleStore возвращается при выборе слота для корзины из нескольких магазинов.
+var ErrSlotRequiresSingleStore = errors.New("слот доставки можно выбрать только для заказа из одного магазина")
+
+// statusProgress задает порядок продвижения заказа для сводного статуса чекаута.
+var statusProgress = map[models.OrderStatus]int{
+	models.OrderStatusScheduled:         0,
+	models.OrderStatusNew:               1,
+	models.OrderStatusNeedsConfirmation: 2,
+	models.OrderStatusConfirmed:         3,
+	models.OrderStatusInProgress:        4,
+	models.OrderStatusDelivered:         5,
+}
+
+// CreateCheckout создает по одному заказу на каждый магазин в одной транзакции.
+// Каждый магазин получает уведомление только о своем подзаказе.
+func (s *OrderService) CreateCheckout(ctx context.Context, req CreateCheckoutRequest) (*models.CheckoutGroupWithOrders, error) {
+	if err := validateCustomer(req.CreateOrderRequest); err != nil {
+		return nil, err
+	}
+	if req.DeliveryMode == "" {
+		req.DeliveryMode = models.CheckoutDeliveryPerOrder
+	}
+
+	itemsByStore, err := s.splitByStore(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	// Слот публикует конкретный магазин, поэтому он применим только к одному подзаказу
+	if req.DeliverySlotID != nil && len(itemsByStore) > 1 {
+		return nil, ErrSlotRequiresSingleStore
+	}
+
+	evals := make([]*orderEvaluation, 0, len(itemsByStore))
+	for _, items := range itemsByStore {
+		eval, err := s.evaluateOrder(ctx, items, req.Distance)
+		if err != nil {
+			return nil, err
+		}
+		if err := eval.firstError(); err != nil {
+			return nil, err
+		}
+		evals = append(evals, eval)
+	}
+
+	quotes := checkoutQuotes(evals, req.DeliveryMode)
+
+	group := &models.CheckoutGroup{
+		ID:            uuid.New(),
+		UserID:        req.UserID,
+		PaymentMethod: req.PaymentMethod,
+		DeliveryMode:  req.DeliveryMode,
+		CreatedAt:     time.Now(),
+	}
+
+	drafts := make([]*orderDraft, 0, len(evals))
+	for i, eval := range evals {
+		draft := buildOrderDraft(req.CreateOrderRequest, eval, quotes[i])
+		draft.order.CheckoutGroupID = &group.ID
+		drafts = append(drafts, draft)
+
+		group.ItemsTotal += draft.order.ItemsTotal
+		group.ServiceFee += draft.order.ServiceFee
+		group.DeliveryFee += draft.order.DeliveryFee
+		group.FinalTotal += draft.order.FinalTotal
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkoutRepo.Create(ctx, group); err != nil {
+			return fmt.Errorf("не удалось создать чекаут: %w", err)
+		}
+		for _, draft := range drafts {
+			if err := s.persistOrder(ctx, draft); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CheckoutGroupWithOrders{
+		CheckoutGroup: *group,
+		Payment: models.CheckoutPayment{
+			Method: group.PaymentMethod,
+			Status: models.PaymentStatusPending,
+			Amount: group.FinalTotal,
+		},
+		Orders: make([]models.OrderWithItems, 0, len(drafts)),
+	}
+	orders := make([]models.Order, 0, len(drafts))
+	for _, draft := range drafts {
+		s.announceOrder(ctx, draft)
+		result.Orders = append(result.Orders, *draft.result)
+		orders = append(orders, *draft.order)
+	}
+	result.Status = checkoutStatus(orders)
+	s.issueCheckoutTracking(result)
+
+	return result, nil
+}
+
+// splitByStore разбивает позиции запроса по магазинам в порядке их появления.
+func (s *OrderService) splitByStore(ctx context.Context, items []CreateOrderItemRequest) ([][]CreateOrderItemRequest, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("заказ не содержит товаров")
+	}
+
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось получить товары: %w", ErrEvaluationFailed, err)
+	}
+
+	storeByProduct := make(map[uuid.UUID]uuid.UUID, len(products))
+	for _, product := range products {
+		storeByProduct[product.ID] = product.StoreID
+	}
+
+	index := make(map[uuid.UUID]int)
+	var groups [][]CreateOrderItemRequest
+	for _, item := range items {
+		storeID, ok := storeByProduct[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("товар не найден: %s", item.ProductID)
+		}
+
+		i, ok := index[storeID]
+		if !ok {
+			i = len(groups)
+			index[storeID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups, nil
+}
+
+// checkoutQuotes возвращает расчеты подзаказов с учетом режима доставки.
+func checkoutQuotes(evals []*orderEvaluation, mode models.CheckoutDeliveryMode) []*pricing.Quote {
+	quotes := make([]*pricing.Quote, len(evals))
+	for i, eval := range evals {
+		quotes[i] = eval.quote
+	}
+	return combineDelivery(quotes, mode)
+}
+
+// combineDelivery применяет режим доставки к полным расчетам подзаказов.
+// В режиме COMBINED доставку оплачивает только подзаказ с самым дорогим
+// тарифом, остальные подзаказы доставляются вместе с ним бесплатно.
+func combineDelivery(quotes []*pricing.Quote, mode models.CheckoutDeliveryMode) []*pricing.Quote {
+	if mode != models.CheckoutDeliveryCombined || len(quotes) < 2 {
+		return quotes
+	}
+
+	carrier := 0
+	for i, quote := range quotes {
+		if quote.DeliveryFee > quotes[carrier].DeliveryFee {
+			carrier = i
+		}
+	}
+
+	combined := make([]*pricing.Quote, len(quotes))
+	for i, quote := range quotes {
+		if i == carrier {
+			combined[i] = quote
+			continue
+		}
+		free := *quote
+		free.FinalTotal -= free.DeliveryFee
+		free.BaseDeliveryFee = 0
+		free.WeightSurcharge = 0
+		free.DistanceFee = 0
+		free.DeliveryFee = 0
+		combined[i] = &free
+	}
+	return combined
+}
+
+// lockOrder блокирует заказ для изменения. У подзаказа чекаута сначала
+// блокируется чекаут: пересчет чекаута меняет соседние подзаказы, поэтому
+// изменения подзаказов одного чекаута выполняются по очереди и не могут
+// взаимно заблокироваться.
+func (s *OrderService) lockOrder(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.CheckoutGroupID != nil {
+		if _, err := s.checkoutRepo.GetByIDForUpdate(ctx, *order.CheckoutGroupID); err != nil {
+			return nil, fmt.Errorf("не удалось заблокировать чекаут: %w", err)
+		}
+	}
+	return s.orderRepo.GetByIDForUpdate(ctx, id)
+}
+
+// syncCheckout пересчитывает чекаут после отмены или изменения подзаказа
+// в той же транзакции. В режиме COMBINED доставка заново распределяется
+// между неотмененными подзаказами, чтобы после отмены подзаказа, который
+// оплачивал доставку, ее оплатил другой. Если хотя бы одна из их оплат уже
+// проведена, суммы подзаказов не меняются. Итоги чекаута — суммы
+// неотмененных подзаказов.
+func (s *OrderService) syncCheckout(ctx context.Context, groupID uuid.UUID) error {
+	group, err := s.checkoutRepo.GetByIDForUpdate(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить чекаут: %w", err)
+	}
+	orders, err := s.orderRepo.GetByCheckoutGroupID(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить подзаказы: %w", err)
+	}
+
+	active := make([]*models.Order, 0, len(orders))
+	for i := range orders {
+		if orders[i].Status != models.OrderStatusCancelled {
+			active = append(active, &orders[i])
+		}
+	}
+	if group.DeliveryMode == models.CheckoutDeliveryCombined {
+		if err := s.redistributeDelivery(ctx, active); err != nil {
+			return err
+		}
+	}
+
+	group.ItemsTotal, group.ServiceFee, group.DeliveryFee, group.FinalTotal = 0, 0, 0, 0
+	for _, order := range active {
+		group.ItemsTotal += order.ItemsTotal
+		group.ServiceFee += order.ServiceFee
+		group.DeliveryFee += order.DeliveryFee
+		group.FinalTotal += order.FinalTotal
+	}
+	if err := s.checkoutRepo.UpdateTotals(ctx, group); err != nil {
+		return fmt.Errorf("не удалось обновить итоги чекаута: %w", err)
+	}
+	return nil
+}
+
+// redistributeDelivery заново рассчитывает неотмененные подзаказы режима
+// COMBINED и сохраняет те, чьи суммы изменились.
+func (s *OrderService) redistributeDelivery(ctx context.Context, active []*models.Order) error {
+	payments := make([]*models.Payment, len(active))
+	quotes := make([]*pricing.Quote, len(active))
+	for i, order := range active {
+		payment, err := s.paymentRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("не удалось получить оплату: %w", err)
+		}
+		if payment.Status != models.PaymentStatusPending {
+			return nil
+		}
+		payments[i] = payment
+
+		delivery, err := s.deliveryRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("не удалось получить доставку: %w", err)
+		}
+		var weight float64
+		if delivery.Weight != nil {
+			weight = *delivery.Weight
+		}
+		quotes[i], err = s.pricer.Calculate(ctx, pricing.Input{
+			StoreID:     order.StoreID,
+			ItemsTotal:  order.ItemsTotal,
+			TotalWeight: weight,
+			Distance:    delivery.Distance,
+		})
+		if err != nil {
+			return fmt.Errorf("не удалось пересчитать подзаказ: %w", err)
+		}
+	}
+
+	now := time.Now()
+	for i, quote := range combineDelivery(quotes, models.CheckoutDeliveryCombined) {
+		order := active[i]
+		if order.DeliveryFee == quote.DeliveryFee && order.FinalTotal == quote.FinalTotal {
+			continue
+		}
+		order.ServiceFee = quote.ServiceFee
+		order.DeliveryFee = quote.DeliveryFee
+		order.FinalTotal = quote.FinalTotal
+		order.PricingRuleID = &quote.RuleID
+		order.UpdatedAt = now
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("не удалось обновить подзаказ: %w", err)
+		}
+		if err := s.paymentRepo.UpdateAmount(ctx, payments[i].ID, quote.FinalTotal); err != nil {
+			return fmt.Errorf("не удалось обновить сумму оплаты: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCheckout возвращает чекаут с подзаказами для единого отслеживания.
+// Чекаут видят покупатель, оформивший его, и администратор; магазины
+// работают только со своими подзаказами через обычные эндпоинты заказов.
+func (s *OrderService) GetCheckout(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.CheckoutGroupWithOrders, error) {
+	group, err := s.checkoutRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить чекаут: %w", err)
+	}
+
+	if !canViewCheckout(actor, group) {
+		return nil, ErrForbidden
+	}
+
+	orders, err := s.orderRepo.GetByCheckoutGroupID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить подзаказы: %w", err)
+	}
+
+	result := &models.CheckoutGroupWithOrders{
+		CheckoutGroup: *group,
+		Status:        checkoutStatus(orders),
+		Orders:        make([]models.OrderWithItems, 0, len(orders)),
+	}
+
+	payments := make([]models.Payment, 0, len(orders))
+	for _, order := range orders {
+		items, err := s.orderItemRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить товары заказа: %w", err)
+		}
+		result.Orders = append(result.Orders, models.OrderWithItems{Order: order, Items: items})
+
+		payment, err := s.paymentRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить оплату: %w", err)
+		}
+		payments = append(payments, *payment)
+	}
+	result.Payment = checkoutPayment(group.PaymentMethod, payments)
+
+	return result, nil
+}
+
+// checkoutStatus возвращает сводный статус: статус наименее продвинутого
+// неотмененного подзаказа или CANCELLED, если отменены все.
+func checkoutStatus(orders []models.Order) models.OrderStatus {
+	status := models.OrderStatusCancelled
+	for _, order := range orders {
+		progress, ok := statusProgress[order.Status]
+		if !ok {
+			continue
+		}
+		if status == models.OrderStatusCancelled || progress < statusProgress[status] {
+			status = order.Status
+		}
+	}
+	return status
+}
+
+// checkoutPayment сводит оплаты подзаказов в одну: сумма считается по
+// неотмененным оплатам, статус pending держится, пока не оплачена хотя бы одна из них.
+func checkoutPayment(method models.PaymentMethod, payments []models.Payment) models.CheckoutPayment {
+	result := models.CheckoutPayment{Method: method, Status: models.PaymentStatusCancelled}
+	for _, payment := range payments {
+		switch payment.Status {
+		case models.PaymentStatusCancelled, models.PaymentStatusRefundPending:
+			continue
+		case models.PaymentStatusPending:
+			result.Status = models.PaymentStatusPending
+		default:
+			if result.Status != models.PaymentStatusPending {
+				result.Status = payment.Status
+			}
+		}
+		result.Amount += payment.Amount
+	}
+	return result
+}
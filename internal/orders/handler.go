@@ -1,16 +1,32 @@
 package orders
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"Laman/internal/events"
 	"Laman/internal/middleware"
+	"Laman/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// maxIdempotencyKeyLength соответствует размеру колонки idempotency_keys.key.
+const maxIdempotencyKeyLength = 255
+
+// streamKeepAliveInterval — период отправки ping в потоке событий, чтобы
+// прокси и мобильные сети не закрывали простаивающее соединение.
+const streamKeepAliveInterval = 25 * time.Second
+
 // Handler обрабатывает HTTP запросы для заказов.
 type Handler struct {
 	orderService *OrderService
 	authService  AuthService
+	userLoader   middleware.UserLoader
 }
 
 // AuthService определяет интерфейс, необходимый из модуля auth.
@@ -19,10 +35,11 @@ type AuthService interface {
 }
 
 // NewHandler создает новый обработчик заказов.
-func NewHandler(orderService *OrderService, authService AuthService) *Handler {
+func NewHandler(orderService *OrderService, authService AuthService, userLoader middleware.UserLoader) *Handler {
 	return &Handler{
 		orderService: orderService,
 		authService:  authService,
+		userLoader:   userLoader,
 	}
 }
 
@@ -31,13 +48,52 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	orders := router.Group("/orders")
 	{
 		orders.POST("", h.CreateOrder)
-		orders.GET("/:id", h.GetOrder)
-		orders.GET("", middleware.AuthMiddleware(h.authService), h.GetUserOrders)
-		orders.PUT("/:id/status", h.UpdateOrderStatus)
+		orders.POST("/quote", h.QuoteOrder)
 	}
+
+	// Чтение заказа и смена статуса доступны только участникам с подходящей ролью
+	protected := orders.Group("")
+	protected.Use(middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader))
+	{
+		protected.GET("", h.ListOrders)
+		protected.GET("/events", h.StreamUserEvents)
+		protected.GET("/:id", h.GetOrder)
+		protected.GET("/:id/history", h.GetOrderHistory)
+		protected.GET("/:id/events", h.StreamOrderEvents)
+		protected.PUT("/:id/status", h.UpdateOrderStatus)
+		protected.POST("/:id/cancel", h.CancelOrder)
+		protected.POST("/:id/adjustments", h.AdjustOrder)
+		protected.POST("/:id/adjustments/approve", h.ApproveAdjustment)
+		protected.POST("/:id/adjustments/reject", h.RejectAdjustment)
+		protected.POST("/:id/reorder", h.Reorder)
+	}
+
+	// Шаблоны заказов покупателя для повторных покупок
+	templates := router.Group("/order-templates")
+	templates.Use(middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader))
+	{
+		templates.POST("", h.CreateTemplate)
+		templates.GET("", h.GetTemplates)
+		templates.GET("/:id", h.GetTemplate)
+		templates.PUT("/:id", h.UpdateTemplate)
+		templates.DELETE("/:id", h.DeleteTemplate)
+		templates.POST("/:id/order", h.OrderFromTemplate)
+	}
+
+	// Чекаут из нескольких магазинов: один запрос, по заказу на каждый магазин
+	checkouts := router.Group("/checkouts")
+	{
+		checkouts.POST("", h.CreateCheckout)
+		checkouts.GET("/:id", middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader), h.GetCheckout)
+	}
+
+	// Публичное отслеживание заказа и чекаута по подписанной ссылке, выданной при создании
+	router.GET("/track/:token", h.TrackOrder)
+	router.GET("/track/checkout/:token", h.TrackCheckout)
 }
 
-// CreateOrder обрабатывает POST /orders
+// CreateOrder обрабатывает POST /orders.
+// Заголовок Idempotency-Key защищает от повторного создания заказа при ретраях.
 func (h *Handler) CreateOrder(c *gin.Context) {
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,24 +102,72 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	}
 
 	// Если пользователь аутентифицирован, устанавливаем user_id
-	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			userID, err := h.authService.ValidateToken(authHeader[7:])
-			if err == nil {
-				req.UserID = &userID
-			}
+	if userID := h.optionalUserID(c); userID != nil {
+		req.UserID = userID
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		order, err := h.orderService.CreateOrder(c.Request.Context(), req)
+		if err != nil {
+			respondCreateError(c, err)
+			return
 		}
+
+		c.JSON(http.StatusCreated, order)
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "слишком длинный Idempotency-Key"})
+		return
 	}
 
-	order, err := h.orderService.CreateOrder(c.Request.Context(), req)
+	order, replayed, err := h.orderService.CreateOrderIdempotent(c.Request.Context(), key, req)
+	if errors.Is(err, ErrIdempotencyKeyMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondCreateError(c, err)
 		return
 	}
 
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
 	c.JSON(http.StatusCreated, order)
 }
 
+// optionalUserID возвращает ID пользователя из Bearer токена или nil для гостя.
+func (h *Handler) optionalUserID(c *gin.Context) *uuid.UUID {
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		userID, err := h.authService.ValidateToken(authHeader[7:])
+		if err == nil {
+			return &userID
+		}
+	}
+	return nil
+}
+
+// QuoteOrder обрабатывает POST /orders/quote
+func (h *Handler) QuoteOrder(c *gin.Context) {
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.orderService.QuoteOrder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
 // GetOrder обрабатывает GET /orders/:id
 func (h *Handler) GetOrder(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -72,7 +176,17 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrder(c.Request.Context(), id)
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	order, err := h.orderService.GetOrder(c.Request.Context(), id, actor)
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -81,27 +195,227 @@ func (h *Handler) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
-// GetUserOrders обрабатывает GET /orders
-func (h *Handler) GetUserOrders(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+// TrackOrder обрабатывает GET /track/:token.
+// Возвращает сокращенное представление заказа без персональных данных.
+func (h *Handler) TrackOrder(c *gin.Context) {
+	view, err := h.orderService.TrackOrder(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, ErrInvalidTrackingToken) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// TrackCheckout обрабатывает GET /track/checkout/:token.
+// Возвращает сводный статус чекаута и подзаказы в виде страницы отслеживания.
+func (h *Handler) TrackCheckout(c *gin.Context) {
+	view, err := h.orderService.TrackCheckout(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, ErrInvalidTrackingToken) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// GetOrderHistory обрабатывает GET /orders/:id/history
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
 		return
 	}
 
-	userIDUUID, ok := userID.(uuid.UUID)
+	history, err := h.orderService.GetOrderHistory(c.Request.Context(), id, actor)
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// StreamOrderEvents обрабатывает GET /orders/:id/events.
+// Отдает смены статуса одного заказа в формате Server-Sent Events.
+func (h *Handler) StreamOrderEvents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "неверный ID пользователя"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	// Права проверяются один раз при подключении, дальше поток
+	// содержит все события этого заказа
+	if _, err := h.orderService.GetOrder(c.Request.Context(), id, actor); err != nil {
+		if errors.Is(err, ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	orders, err := h.orderService.GetUserOrders(c.Request.Context(), userIDUUID)
+	h.streamEvents(c, func(event events.OrderEvent) bool {
+		return event.OrderID == id
+	})
+}
+
+// StreamUserEvents обрабатывает GET /orders/events.
+// Отдает смены статуса всех заказов, доступных участнику.
+func (h *Handler) StreamUserEvents(c *gin.Context) {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	h.streamEvents(c, func(event events.OrderEvent) bool {
+		return canViewEvent(actor, event)
+	})
+}
+
+// streamEvents держит SSE соединение, пока клиент не отключится
+// или брокер не будет остановлен.
+func (h *Handler) streamEvents(c *gin.Context, match func(events.OrderEvent) bool) {
+	ch, unsubscribe := h.orderService.SubscribeEvents()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(streamKeepAliveInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			if match(event) {
+				c.SSEvent("status", event)
+			}
+			return true
+		case <-ticker.C:
+			c.SSEvent("ping", gin.H{"time": time.Now()})
+			return true
+		}
+	})
+}
+
+// ListOrders обрабатывает GET /orders.
+// Фильтры: status (через запятую), store_id, from, to (RFC 3339),
+// payment_method, guest_phone; страница: cursor, limit. Без cursor и limit
+// возвращает все заказы массивом, как до постраничной выдачи.
+func (h *Handler) ListOrders(c *gin.Context) {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	req, err := parseListOrdersRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var page interface{}
+	if req.Cursor == "" && c.Query("limit") == "" {
+		page, err = h.orderService.ListAllOrders(c.Request.Context(), req, actor)
+	} else {
+		page, err = h.orderService.ListOrders(c.Request.Context(), req, actor)
+	}
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, page)
+}
+
+// parseListOrdersRequest разбирает query-параметры списка заказов.
+func parseListOrdersRequest(c *gin.Context) (ListOrdersRequest, error) {
+	req := ListOrdersRequest{Cursor: c.Query("cursor")}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			req.Statuses = append(req.Statuses, models.OrderStatus(strings.TrimSpace(status)))
+		}
+	}
+	if value := c.Query("store_id"); value != "" {
+		storeID, err := uuid.Parse(value)
+		if err != nil {
+			return req, errors.New("неверный параметр store_id")
+		}
+		req.StoreID = &storeID
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return req, errors.New("неверный параметр from")
+		}
+		req.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return req, errors.New("неверный параметр to")
+		}
+		req.To = &to
+	}
+	if value := c.Query("payment_method"); value != "" {
+		method := models.PaymentMethod(value)
+		req.PaymentMethod = &method
+	}
+	if value := c.Query("guest_phone"); value != "" {
+		req.GuestPhone = &value
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return req, errors.New("неверный параметр limit")
+		}
+		req.Limit = limit
+	}
+	return req, nil
 }
 
 // UpdateOrderStatus обрабатывает PUT /orders/:id/status
@@ -118,10 +432,407 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status); err != nil {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	if err := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req, actor); err != nil {
+		if errors.Is(err, ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrAwaitingApproval) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "статус заказа обновлен"})
 }
+
+// CancelOrder обрабатывает POST /orders/:id/cancel
+func (h *Handler) CancelOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	if err := h.orderService.CancelOrder(c.Request.Context(), id, req, actor); err != nil {
+		switch {
+		case errors.Is(err, ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrSelfCancelNotAllowed), errors.Is(err, ErrSelfCancelWindowExpired):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "заказ отменен"})
+}
+
+// AdjustOrder обрабатывает POST /orders/:id/adjustments
+func (h *Handler) AdjustOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	var req AdjustOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	order, err := h.orderService.AdjustOrder(c.Request.Context(), id, req, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAdjustmentNotAllowed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ApproveAdjustment обрабатывает POST /orders/:id/adjustments/approve
+func (h *Handler) ApproveAdjustment(c *gin.Context) {
+	h.respondToAdjustment(c, h.orderService.ApproveAdjustment, "изменения состава приняты")
+}
+
+// RejectAdjustment обрабатывает POST /orders/:id/adjustments/reject
+func (h *Handler) RejectAdjustment(c *gin.Context) {
+	h.respondToAdjustment(c, h.orderService.RejectAdjustment, "изменения состава отклонены, заказ отменен")
+}
+
+// respondToAdjustment обрабатывает ответ покупателя на изменения состава.
+func (h *Handler) respondToAdjustment(
+	c *gin.Context,
+	respond func(ctx context.Context, id uuid.UUID, req ApproveAdjustmentRequest, actor models.Actor) error,
+	message string,
+) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	// Тело запроса необязательно
+	var req ApproveAdjustmentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	if err := respond(c.Request.Context(), id, req, actor); err != nil {
+		switch {
+		case errors.Is(err, ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrNoPendingAdjustment):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// CreateCheckout обрабатывает POST /checkouts
+func (h *Handler) CreateCheckout(c *gin.Context) {
+	var req CreateCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if userID := h.optionalUserID(c); userID != nil {
+		req.UserID = userID
+	}
+
+	checkout, err := h.orderService.CreateCheckout(c.Request.Context(), req)
+	if err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkout)
+}
+
+// GetCheckout обрабатывает GET /checkouts/:id
+func (h *Handler) GetCheckout(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID чекаута"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	checkout, err := h.orderService.GetCheckout(c.Request.Context(), id, actor)
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, checkout)
+}
+
+// Reorder обрабатывает POST /orders/:id/reorder.
+// Без "place": true возвращает предпросмотр с изменениями цен и наличия.
+func (h *Handler) Reorder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	var req ReorderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	result, err := h.orderService.Reorder(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	respondReorder(c, result)
+}
+
+// CreateTemplate обрабатывает POST /order-templates
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	var req OrderTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	template, err := h.orderService.CreateTemplate(c.Request.Context(), req, actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetTemplates обрабатывает GET /order-templates
+func (h *Handler) GetTemplates(c *gin.Context) {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	templates, err := h.orderService.GetTemplates(c.Request.Context(), actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate обрабатывает GET /order-templates/:id
+func (h *Handler) GetTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID шаблона"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	template, err := h.orderService.GetTemplate(c.Request.Context(), id, actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate обрабатывает PUT /order-templates/:id
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID шаблона"})
+		return
+	}
+
+	var req OrderTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	template, err := h.orderService.UpdateTemplate(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate обрабатывает DELETE /order-templates/:id
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID шаблона"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	if err := h.orderService.DeleteTemplate(c.Request.Context(), id, actor); err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "шаблон удален"})
+}
+
+// OrderFromTemplate обрабатывает POST /order-templates/:id/order.
+// Работает как повтор заказа: без "place": true возвращает предпросмотр.
+func (h *Handler) OrderFromTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID шаблона"})
+		return
+	}
+
+	var req ReorderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	result, err := h.orderService.OrderFromTemplate(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondReorderError(c, err)
+		return
+	}
+
+	respondReorder(c, result)
+}
+
+// respondReorder отвечает 201, если заказ создан, иначе 200 с предпросмотром.
+func respondReorder(c *gin.Context, result *ReorderResult) {
+	if result.Order != nil {
+		c.JSON(http.StatusCreated, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// respondCreateError отвечает 500 на сбой расчета заказа и 400 на ошибки в данных заказа.
+func respondCreateError(c *gin.Context, err error) {
+	if errors.Is(err, ErrEvaluationFailed) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// respondReorderError переводит ошибки повторов и шаблонов в HTTP статусы.
+func respondReorderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTemplateNameTaken), errors.Is(err, ErrNothingToReorder):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEvaluationFailed):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
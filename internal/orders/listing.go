@@ -0,0 +1,194 @@
+package orders
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultOrdersPageSize — размер страницы списка заказов по умолчанию.
+	defaultOrdersPageSize = 20
+	// maxOrdersPageSize — максимальный размер страницы списка заказов.
+	maxOrdersPageSize = 100
+)
+
+// ErrInvalidCursor возвращается при поврежденном курсоре страницы.
+var ErrInvalidCursor = errors.New("неверный курсор страницы")
+
+// ListOrdersRequest представляет фильтры и параметры страницы списка заказов.
+type ListOrdersRequest struct {
+	Statuses      []models.OrderStatus
+	StoreID       *uuid.UUID
+	From          *time.Time
+	To            *time.Time
+	PaymentMethod *models.PaymentMethod
+	GuestPhone    *string
+	Cursor        string
+	Limit         int
+}
+
+// OrderPage представляет страницу списка заказов. NextCursor пуст на последней странице.
+type OrderPage struct {
+	Orders     []models.Order `json:"orders"`
+	NextCursor *string        `json:"next_cursor,omitempty"`
+}
+
+// ListOrders возвращает страницу заказов, видимых участнику: покупателю —
+// свои, магазину — своего магазина, курьеру — готовые к доставке,
+// администратору — все. Заказы отсортированы от новых к старым.
+func (s *OrderService) ListOrders(ctx context.Context, req ListOrdersRequest, actor models.Actor) (*OrderPage, error) {
+	filter, err := scopeOrderFilter(req, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeOrderCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	}
+
+	filter.Limit = req.Limit
+	if filter.Limit <= 0 {
+		filter.Limit = defaultOrdersPageSize
+	}
+	if filter.Limit > maxOrdersPageSize {
+		filter.Limit = maxOrdersPageSize
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	orders, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить заказы: %w", err)
+	}
+
+	page := &OrderPage{Orders: orders}
+	if page.Orders == nil {
+		page.Orders = []models.Order{}
+	}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		cursor := encodeOrderCursor(OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		page.NextCursor = &cursor
+	}
+	return page, nil
+}
+
+// ListAllOrders возвращает все заказы, видимые участнику, одним списком.
+// Нужен клиентам, которые получали список заказов до постраничной выдачи;
+// заказы читаются страницами максимального размера.
+func (s *OrderService) ListAllOrders(ctx context.Context, req ListOrdersRequest, actor models.Actor) ([]models.Order, error) {
+	req.Cursor = ""
+	req.Limit = maxOrdersPageSize
+
+	orders := []models.Order{}
+	for {
+		page, err := s.ListOrders(ctx, req, actor)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, page.Orders...)
+		if page.NextCursor == nil {
+			return orders, nil
+		}
+		req.Cursor = *page.NextCursor
+	}
+}
+
+// scopeOrderFilter переносит фильтры запроса и ограничивает их областью
+// видимости участника, как canViewOrder.
+func scopeOrderFilter(req ListOrdersRequest, actor models.Actor) (OrderFilter, error) {
+	filter := OrderFilter{
+		StoreID:       req.StoreID,
+		Statuses:      req.Statuses,
+		CreatedFrom:   req.From,
+		CreatedTo:     req.To,
+		PaymentMethod: req.PaymentMethod,
+		GuestPhone:    req.GuestPhone,
+	}
+
+	switch actor.Role {
+	case models.UserRoleAdmin:
+	case models.UserRoleStore:
+		if actor.StoreID == nil {
+			return filter, ErrForbidden
+		}
+		if req.StoreID != nil && *req.StoreID != *actor.StoreID {
+			return filter, ErrForbidden
+		}
+		filter.StoreID = actor.StoreID
+	case models.UserRoleCourier:
+		visible := []models.OrderStatus{
+			models.OrderStatusConfirmed,
+			models.OrderStatusInProgress,
+			models.OrderStatusDelivered,
+		}
+		if len(req.Statuses) == 0 {
+			filter.Statuses = visible
+			break
+		}
+		for _, status := range req.Statuses {
+			if !containsStatus(visible, status) {
+				return filter, ErrForbidden
+			}
+		}
+	case models.UserRoleCustomer:
+		if actor.UserID == nil {
+			return filter, ErrForbidden
+		}
+		filter.UserID = actor.UserID
+	default:
+		return filter, ErrForbidden
+	}
+	return filter, nil
+}
+
+func containsStatus(statuses []models.OrderStatus, status models.OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeOrderCursor кодирует позицию последнего заказа страницы.
+func encodeOrderCursor(cursor OrderCursor) string {
+	raw := cursor.CreatedAt.Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeOrderCursor разбирает курсор, выданный encodeOrderCursor.
+func decodeOrderCursor(value string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &OrderCursor{CreatedAt: t, ID: orderID}, nil
+}
@@ -5,10 +5,20 @@ import (
 	"Laman/internal/models"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// orderColumns перечисляет колонки заказа в порядке полей models.Order.
+const orderColumns = `id, user_id, guest_name, guest_phone, guest_address, comment, status, store_id, payment_method,
+		       items_total, service_fee, delivery_fee, final_total, pricing_rule_id, created_at, updated_at,
+		       cancellation_reason, cancellation_comment, awaiting_approval, checkout_group_id, escalated_at`
+
 // postgresOrderRepository реализует OrderRepository используя PostgreSQL.
 type postgresOrderRepository struct {
 	db *database.DB
@@ -22,24 +32,25 @@ func NewPostgresOrderRepository(db *database.DB) OrderRepository {
 func (r *postgresOrderRepository) Create(ctx context.Context, order *models.Order) error {
 	query := `
 		INSERT INTO orders (id, user_id, guest_name, guest_phone, guest_address, comment, status,
-		                    store_id, payment_method, items_total, service_fee, delivery_fee, final_total, created_at, updated_at)
+		                    store_id, payment_method, items_total, service_fee, delivery_fee, final_total,
+		                    pricing_rule_id, checkout_group_id, created_at, updated_at)
 		VALUES (:id, :user_id, :guest_name, :guest_phone, :guest_address, :comment, :status,
-		        :store_id, :payment_method, :items_total, :service_fee, :delivery_fee, :final_total, :created_at, :updated_at)
+		        :store_id, :payment_method, :items_total, :service_fee, :delivery_fee, :final_total,
+		        :pricing_rule_id, :checkout_group_id, :created_at, :updated_at)
 	`
-	_, err := r.db.NamedExecContext(ctx, query, order)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, order)
 	return err
 }
 
 func (r *postgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
 	var order models.Order
 	query := `
-		SELECT id, user_id, guest_name, guest_phone, guest_address, comment, status, store_id, payment_method,
-		       items_total, service_fee, delivery_fee, final_total, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders WHERE id = $1
 	`
-	err := r.db.GetContext(ctx, &order, query, id)
+	err := r.db.Conn(ctx).GetContext(ctx, &order, query, id)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("заказ не найден")
+		return nil, ErrOrderNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -47,20 +58,174 @@ func (r *postgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 	return &order, nil
 }
 
-func (r *postgresOrderRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Order, error) {
+func (r *postgresOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders WHERE id = $1
+		FOR UPDATE
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &order, query, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *postgresOrderRepository) List(ctx context.Context, filter OrderFilter) ([]models.Order, error) {
+	conditions := make([]string, 0, 8)
+	args := make([]interface{}, 0, 10)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != nil {
+		conditions = append(conditions, "user_id = "+arg(*filter.UserID))
+	}
+	if filter.StoreID != nil {
+		conditions = append(conditions, "store_id = "+arg(*filter.StoreID))
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(statusArray(filter.Statuses))+")")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.PaymentMethod != nil {
+		conditions = append(conditions, "payment_method = "+arg(*filter.PaymentMethod))
+	}
+	if filter.GuestPhone != nil {
+		conditions = append(conditions, "guest_phone = "+arg(*filter.GuestPhone))
+	}
+	if filter.After != nil {
+		// Сравнение кортежей использует индексы (…, created_at DESC, id DESC)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < (%s, %s)",
+			arg(filter.After.CreatedAt), arg(filter.After.ID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
 	var orders []models.Order
 	query := `
-		SELECT id, user_id, guest_name, guest_phone, guest_address, comment, status, store_id, payment_method,
-		       items_total, service_fee, delivery_fee, final_total, created_at, updated_at
-		FROM orders WHERE user_id = $1 ORDER BY created_at DESC
+		SELECT ` + orderColumns + `
+		FROM orders ` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + arg(filter.Limit)
+	err := r.db.Conn(ctx).SelectContext(ctx, &orders, query, args...)
+	return orders, err
+}
+
+func (r *postgresOrderRepository) GetByCheckoutGroupID(ctx context.Context, groupID uuid.UUID) ([]models.Order, error) {
+	var orders []models.Order
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders WHERE checkout_group_id = $1 ORDER BY created_at, id
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &orders, query, groupID)
+	return orders, err
+}
+
+func (r *postgresOrderRepository) GetScheduledDue(ctx context.Context, until time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT o.id
+		FROM orders o
+		JOIN deliveries d ON d.order_id = o.id
+		WHERE o.status = $1 AND d.slot_start <= $2
+		ORDER BY d.slot_start, o.id
+		LIMIT $3
 	`
-	err := r.db.SelectContext(ctx, &orders, query, userID)
+	err := r.db.Conn(ctx).SelectContext(ctx, &ids, query, models.OrderStatusScheduled, until, limit)
+	return ids, err
+}
+
+// staleOrderCondition отбирает заказы, ожидающие магазин: время попадания
+// в очередь — последний переход в NEW (для старых заказов — создание).
+// Заказы, ожидающие ответа покупателя на изменение состава, не учитываются.
+const staleOrderCondition = `
+		o.status = ANY($1) AND o.awaiting_approval = FALSE
+		AND COALESCE(
+			(SELECT MAX(e.created_at) FROM order_status_events e
+			 WHERE e.order_id = o.id AND e.to_status = 'NEW'),
+			o.created_at
+		) <= $2`
+
+func (r *postgresOrderRepository) ClaimForEscalation(ctx context.Context, statuses []models.OrderStatus, queuedBefore time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	query := `
+		UPDATE orders SET escalated_at = NOW()
+		WHERE id IN (
+			SELECT o.id FROM orders o
+			WHERE o.escalated_at IS NULL AND ` + staleOrderCondition + `
+			ORDER BY o.created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + orderColumns
+	err := r.db.Conn(ctx).SelectContext(ctx, &orders, query, statusArray(statuses), queuedBefore, limit)
 	return orders, err
 }
 
+func (r *postgresOrderRepository) GetStale(ctx context.Context, statuses []models.OrderStatus, queuedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT o.id FROM orders o
+		WHERE ` + staleOrderCondition + `
+		ORDER BY o.created_at
+		LIMIT $3
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &ids, query, statusArray(statuses), queuedBefore, limit)
+	return ids, err
+}
+
+// statusArray передает список статусов как массив PostgreSQL.
+func statusArray(statuses []models.OrderStatus) interface{} {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	return pq.Array(values)
+}
+
+func (r *postgresOrderRepository) ClaimGuestOrders(ctx context.Context, userID uuid.UUID, phone string) (int, error) {
+	// Номер сравнивается по цифрам с заменой ведущей 8 на 7, как в normalizePhone
+	query := `
+		UPDATE orders SET user_id = $1, updated_at = NOW()
+		WHERE user_id IS NULL
+		  AND regexp_replace(regexp_replace(guest_phone, '\D', '', 'g'), '^8(\d{10})$', '7\1') = $2
+	`
+	result, err := r.db.Conn(ctx).ExecContext(ctx, query, userID, phone)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
 func (r *postgresOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus) error {
 	query := `UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, status, id)
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, status, id)
+	return err
+}
+
+func (r *postgresOrderRepository) Cancel(ctx context.Context, id uuid.UUID, reason models.CancellationReason, comment *string) error {
+	query := `
+		UPDATE orders
+		SET status = $1, cancellation_reason = $2, cancellation_comment = $3, awaiting_approval = FALSE,
+		    updated_at = NOW()
+		WHERE id = $4
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, models.OrderStatusCancelled, reason, comment, id)
 	return err
 }
 
@@ -70,10 +235,11 @@ func (r *postgresOrderRepository) Update(ctx context.Context, order *models.Orde
 		SET user_id = :user_id, guest_name = :guest_name, guest_phone = :guest_phone,
 		    guest_address = :guest_address, comment = :comment, status = :status, store_id = :store_id, payment_method = :payment_method,
 		    items_total = :items_total, service_fee = :service_fee, delivery_fee = :delivery_fee,
-		    final_total = :final_total, updated_at = :updated_at
+		    final_total = :final_total, pricing_rule_id = :pricing_rule_id, awaiting_approval = :awaiting_approval,
+		    updated_at = :updated_at
 		WHERE id = :id
 	`
-	_, err := r.db.NamedExecContext(ctx, query, order)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, order)
 	return err
 }
 
@@ -89,10 +255,10 @@ func NewPostgresOrderItemRepository(db *database.DB) OrderItemRepository {
 
 func (r *postgresOrderItemRepository) Create(ctx context.Context, item *models.OrderItem) error {
 	query := `
-		INSERT INTO order_items (id, order_id, product_id, quantity, price, created_at)
-		VALUES (:id, :order_id, :product_id, :quantity, :price, :created_at)
+		INSERT INTO order_items (id, order_id, product_id, quantity, price, status, original_quantity, substitute_for, created_at)
+		VALUES (:id, :order_id, :product_id, :quantity, :price, :status, :original_quantity, :substitute_for, :created_at)
 	`
-	_, err := r.db.NamedExecContext(ctx, query, item)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, item)
 	return err
 }
 
@@ -102,16 +268,361 @@ func (r *postgresOrderItemRepository) CreateBatch(ctx context.Context, items []m
 	}
 
 	query := `
-		INSERT INTO order_items (id, order_id, product_id, quantity, price, created_at)
-		VALUES (:id, :order_id, :product_id, :quantity, :price, :created_at)
+		INSERT INTO order_items (id, order_id, product_id, quantity, price, status, original_quantity, substitute_for, created_at)
+		VALUES (:id, :order_id, :product_id, :quantity, :price, :status, :original_quantity, :substitute_for, :created_at)
 	`
-	_, err := r.db.NamedExecContext(ctx, query, items)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, items)
 	return err
 }
 
 func (r *postgresOrderItemRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
 	var items []models.OrderItem
-	query := `SELECT id, order_id, product_id, quantity, price, created_at FROM order_items WHERE order_id = $1 ORDER BY created_at`
-	err := r.db.SelectContext(ctx, &items, query, orderID)
+	query := `
+		SELECT id, order_id, product_id, quantity, price, status, original_quantity, substitute_for, created_at
+		FROM order_items WHERE order_id = $1 ORDER BY created_at
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &items, query, orderID)
 	return items, err
 }
+
+func (r *postgresOrderItemRepository) Update(ctx context.Context, item *models.OrderItem) error {
+	query := `
+		UPDATE order_items
+		SET quantity = :quantity, status = :status, original_quantity = :original_quantity
+		WHERE id = :id
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, item)
+	return err
+}
+
+// postgresIdempotencyRepository реализует IdempotencyRepository используя PostgreSQL.
+type postgresIdempotencyRepository struct {
+	db *database.DB
+}
+
+// NewPostgresIdempotencyRepository создает новый PostgreSQL репозиторий ключей идемпотентности.
+func NewPostgresIdempotencyRepository(db *database.DB) IdempotencyRepository {
+	return &postgresIdempotencyRepository{db: db}
+}
+
+func (r *postgresIdempotencyRepository) Create(ctx context.Context, key *models.IdempotencyKey) error {
+	query := `
+		INSERT INTO idempotency_keys (key, fingerprint, order_id, response, created_at)
+		VALUES (:key, :fingerprint, :order_id, :response, :created_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, key)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+func (r *postgresIdempotencyRepository) GetByKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	query := `SELECT key, fingerprint, order_id, response, created_at FROM idempotency_keys WHERE key = $1`
+	err := r.db.Conn(ctx).GetContext(ctx, &record, query, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// postgresOrderStatusEventRepository реализует OrderStatusEventRepository используя PostgreSQL.
+type postgresOrderStatusEventRepository struct {
+	db *database.DB
+}
+
+// NewPostgresOrderStatusEventRepository создает новый PostgreSQL репозиторий истории статусов.
+func NewPostgresOrderStatusEventRepository(db *database.DB) OrderStatusEventRepository {
+	return &postgresOrderStatusEventRepository{db: db}
+}
+
+func (r *postgresOrderStatusEventRepository) Create(ctx context.Context, event *models.OrderStatusEvent) error {
+	query := `
+		INSERT INTO order_status_events (id, order_id, from_status, to_status, actor_user_id, actor_role, reason_code, reason, created_at)
+		VALUES (:id, :order_id, :from_status, :to_status, :actor_user_id, :actor_role, :reason_code, :reason, :created_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, event)
+	return err
+}
+
+func (r *postgresOrderStatusEventRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusEvent, error) {
+	var events []models.OrderStatusEvent
+	query := `
+		SELECT id, order_id, from_status, to_status, actor_user_id, actor_role, reason_code, reason, created_at
+		FROM order_status_events WHERE order_id = $1 ORDER BY created_at, id
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &events, query, orderID)
+	return events, err
+}
+
+// postgresCheckoutGroupRepository реализует CheckoutGroupRepository используя PostgreSQL.
+type postgresCheckoutGroupRepository struct {
+	db *database.DB
+}
+
+// NewPostgresCheckoutGroupRepository создает новый PostgreSQL репозиторий чекаутов.
+func NewPostgresCheckoutGroupRepository(db *database.DB) CheckoutGroupRepository {
+	return &postgresCheckoutGroupRepository{db: db}
+}
+
+func (r *postgresCheckoutGroupRepository) Create(ctx context.Context, group *models.CheckoutGroup) error {
+	query := `
+		INSERT INTO checkout_groups (id, user_id, payment_method, delivery_mode, items_total, service_fee,
+		                             delivery_fee, final_total, created_at)
+		VALUES (:id, :user_id, :payment_method, :delivery_mode, :items_total, :service_fee,
+		        :delivery_fee, :final_total, :created_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, group)
+	return err
+}
+
+func (r *postgresCheckoutGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CheckoutGroup, error) {
+	var group models.CheckoutGroup
+	query := `
+		SELECT id, user_id, payment_method, delivery_mode, items_total, service_fee, delivery_fee, final_total, created_at
+		FROM checkout_groups WHERE id = $1
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &group, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("чекаут не найден")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *postgresCheckoutGroupRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.CheckoutGroup, error) {
+	var group models.CheckoutGroup
+	query := `
+		SELECT id, user_id, payment_method, delivery_mode, items_total, service_fee, delivery_fee, final_total, created_at
+		FROM checkout_groups WHERE id = $1
+		FOR UPDATE
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &group, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("чекаут не найден")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *postgresCheckoutGroupRepository) UpdateTotals(ctx context.Context, group *models.CheckoutGroup) error {
+	query := `
+		UPDATE checkout_groups
+		SET items_total = :items_total, service_fee = :service_fee,
+		    delivery_fee = :delivery_fee, final_total = :final_total
+		WHERE id = :id
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, group)
+	return err
+}
+
+// orderTemplateColumns перечисляет колонки шаблона заказа.
+const orderTemplateColumns = `id, user_id, name, payment_method, delivery_address, distance, comment, cadence,
+		       next_run_at, last_run_at, last_order_id, last_error, created_at, updated_at`
+
+// postgresOrderTemplateRepository реализует OrderTemplateRepository используя PostgreSQL.
+type postgresOrderTemplateRepository struct {
+	db *database.DB
+}
+
+// NewPostgresOrderTemplateRepository создает новый PostgreSQL репозиторий шаблонов заказов.
+func NewPostgresOrderTemplateRepository(db *database.DB) OrderTemplateRepository {
+	return &postgresOrderTemplateRepository{db: db}
+}
+
+func (r *postgresOrderTemplateRepository) Create(ctx context.Context, template *models.OrderTemplate) error {
+	query := `
+		INSERT INTO order_templates (id, user_id, name, payment_method, delivery_address, distance, comment,
+		                             cadence, next_run_at, created_at, updated_at)
+		VALUES (:id, :user_id, :name, :payment_method, :delivery_address, :distance, :comment,
+		        :cadence, :next_run_at, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, template)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTemplateNameTaken
+	}
+	if err != nil {
+		return err
+	}
+	return r.insertItems(ctx, template)
+}
+
+func (r *postgresOrderTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.OrderTemplate, error) {
+	var template models.OrderTemplate
+	query := `
+		SELECT ` + orderTemplateColumns + `
+		FROM order_templates WHERE id = $1
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &template, query, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []models.OrderTemplate{template}
+	if err := r.loadItems(ctx, templates); err != nil {
+		return nil, err
+	}
+	return &templates[0], nil
+}
+
+func (r *postgresOrderTemplateRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.OrderTemplate, error) {
+	var templates []models.OrderTemplate
+	query := `
+		SELECT ` + orderTemplateColumns + `
+		FROM order_templates WHERE user_id = $1 ORDER BY name
+	`
+	if err := r.db.Conn(ctx).SelectContext(ctx, &templates, query, userID); err != nil {
+		return nil, err
+	}
+	if err := r.loadItems(ctx, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *postgresOrderTemplateRepository) Update(ctx context.Context, template *models.OrderTemplate) error {
+	query := `
+		UPDATE order_templates
+		SET name = :name, payment_method = :payment_method, delivery_address = :delivery_address,
+		    distance = :distance, comment = :comment, cadence = :cadence, next_run_at = :next_run_at,
+		    updated_at = :updated_at
+		WHERE id = :id
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, template)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTemplateNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Conn(ctx).ExecContext(ctx, `DELETE FROM order_template_items WHERE template_id = $1`, template.ID); err != nil {
+		return err
+	}
+	return r.insertItems(ctx, template)
+}
+
+func (r *postgresOrderTemplateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Conn(ctx).ExecContext(ctx, `DELETE FROM order_templates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+func (r *postgresOrderTemplateRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.OrderTemplate, error) {
+	var templates []models.OrderTemplate
+	query := `
+		SELECT ` + orderTemplateColumns + `
+		FROM order_templates
+		WHERE next_run_at IS NOT NULL AND next_run_at <= $1
+		ORDER BY next_run_at, id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	if err := r.db.Conn(ctx).SelectContext(ctx, &templates, query, now, limit); err != nil {
+		return nil, err
+	}
+	if err := r.loadItems(ctx, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *postgresOrderTemplateRepository) UpdateSchedule(ctx context.Context, id uuid.UUID, nextRunAt *time.Time) error {
+	query := `UPDATE order_templates SET next_run_at = $1, updated_at = NOW() WHERE id = $2`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, nextRunAt, id)
+	return err
+}
+
+func (r *postgresOrderTemplateRepository) RecordRun(ctx context.Context, id uuid.UUID, runAt time.Time, orderID *uuid.UUID, runErr *string) error {
+	query := `
+		UPDATE order_templates
+		SET last_run_at = $1, last_order_id = COALESCE($2, last_order_id), last_error = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, runAt, orderID, runErr, id)
+	return err
+}
+
+func (r *postgresOrderTemplateRepository) insertItems(ctx context.Context, template *models.OrderTemplate) error {
+	if len(template.Items) == 0 {
+		return nil
+	}
+
+	for i := range template.Items {
+		template.Items[i].TemplateID = template.ID
+	}
+	query := `
+		INSERT INTO order_template_items (template_id, product_id, quantity, price)
+		VALUES (:template_id, :product_id, :quantity, :price)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, template.Items)
+	return err
+}
+
+// loadItems загружает товары для всех переданных шаблонов одним запросом.
+func (r *postgresOrderTemplateRepository) loadItems(ctx context.Context, templates []models.OrderTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(templates))
+	index := make(map[uuid.UUID]int, len(templates))
+	for i, template := range templates {
+		ids[i] = template.ID
+		index[template.ID] = i
+		templates[i].Items = []models.OrderTemplateItem{}
+	}
+
+	var items []models.OrderTemplateItem
+	query, args, err := sqlx.In(`
+		SELECT template_id, product_id, quantity, price
+		FROM order_template_items WHERE template_id IN (?)
+		ORDER BY product_id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	query = r.db.Rebind(query)
+	if err := r.db.Conn(ctx).SelectContext(ctx, &items, query, args...); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		i := index[item.TemplateID]
+		templates[i].Items = append(templates[i].Items, item)
+	}
+	return nil
+}
+
+func (r *postgresCheckoutGroupRepository) ClaimForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE checkout_groups g SET user_id = $1
+		WHERE g.user_id IS NULL
+		  AND EXISTS (SELECT 1 FROM orders o WHERE o.checkout_group_id = g.id AND o.user_id = $1)
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, userID)
+	return err
+}
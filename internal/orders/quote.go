@@ -0,0 +1,223 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"Laman/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// ErrEvaluationFailed возвращается, когда заказ не удалось рассчитать из-за
+// сбоя инфраструктуры, а не ошибки в данных заказа.
+var ErrEvaluationFailed = errors.New("не удалось рассчитать заказ")
+
+// QuoteRequest представляет запрос на предварительный расчет заказа.
+type QuoteRequest struct {
+	Items    []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	Distance *float64                 `json:"distance,omitempty" binding:"omitempty,gte=0"`
+}
+
+// OrderQuote представляет предварительный расчет заказа без его создания.
+// Valid = false означает, что заказ с такими параметрами создать нельзя:
+// причины перечислены в Errors и в ошибках отдельных позиций.
+type OrderQuote struct {
+	StoreID     *uuid.UUID     `json:"store_id,omitempty"`
+	Lines       []QuoteLine    `json:"lines"`
+	ItemsTotal  models.Money   `json:"items_total"`
+	ServiceFee  models.Money   `json:"service_fee"`
+	DeliveryFee models.Money   `json:"delivery_fee"`
+	FinalTotal  models.Money   `json:"final_total"`
+	TotalWeight float64        `json:"total_weight"`
+	Pricing     *pricing.Quote `json:"pricing,omitempty"`
+	Errors      []string       `json:"errors,omitempty"`
+	Valid       bool           `json:"valid"`
+}
+
+// QuoteLine представляет позицию предварительного расчета.
+type QuoteLine struct {
+	ProductID uuid.UUID    `json:"product_id"`
+	Name      string       `json:"name,omitempty"`
+	Price     models.Money `json:"price"`
+	Quantity  int          `json:"quantity"`
+	LineTotal models.Money `json:"line_total"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// orderEvaluation содержит результат проверки и расчета заказа,
+// общий для QuoteOrder и CreateOrder.
+type orderEvaluation struct {
+	storeID     *uuid.UUID
+	productMap  map[uuid.UUID]models.Product
+	lines       []QuoteLine
+	orderItems  []models.OrderItem
+	itemsTotal  models.Money
+	totalWeight float64
+	quote       *pricing.Quote
+	errs        []string
+}
+
+// firstError возвращает первую ошибку позиции или заказа.
+func (e *orderEvaluation) firstError() error {
+	for _, line := range e.lines {
+		if line.Error != "" {
+			return errors.New(line.Error)
+		}
+	}
+	if len(e.errs) > 0 {
+		return errors.New(e.errs[0])
+	}
+	return nil
+}
+
+// QuoteOrder рассчитывает заказ по тем же правилам, что и CreateOrder,
+// но ничего не сохраняет. Ошибки позиций не прерывают расчет.
+func (s *OrderService) QuoteOrder(ctx context.Context, req QuoteRequest) (*OrderQuote, error) {
+	eval, err := s.evaluateOrder(ctx, req.Items, req.Distance)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrderQuote{
+		StoreID:     eval.storeID,
+		Lines:       eval.lines,
+		ItemsTotal:  eval.itemsTotal,
+		TotalWeight: eval.totalWeight,
+		Pricing:     eval.quote,
+		Errors:      eval.errs,
+		Valid:       eval.firstError() == nil,
+	}
+	if eval.quote != nil {
+		result.ServiceFee = eval.quote.ServiceFee
+		result.DeliveryFee = eval.quote.DeliveryFee
+		result.FinalTotal = eval.quote.FinalTotal
+	}
+
+	return result, nil
+}
+
+// evaluateOrder проверяет товары запроса и рассчитывает сборы. Ошибки данных
+// (товар не найден, недоступен, из другого магазина, минимальная сумма)
+// записываются в результат; возвращаемая ошибка означает сбой инфраструктуры
+// и оборачивает ErrEvaluationFailed.
+func (s *OrderService) evaluateOrder(ctx context.Context, items []CreateOrderItemRequest, distance *float64) (*orderEvaluation, error) {
+	// Получение товаров
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось получить товары: %w", ErrEvaluationFailed, err)
+	}
+
+	eval := &orderEvaluation{
+		productMap: make(map[uuid.UUID]models.Product, len(products)),
+		lines:      make([]QuoteLine, 0, len(items)),
+		orderItems: make([]models.OrderItem, 0, len(items)),
+	}
+	for _, product := range products {
+		eval.productMap[product.ID] = product
+	}
+
+	if len(items) == 0 {
+		eval.errs = append(eval.errs, "заказ не содержит товаров")
+	}
+
+	for _, itemReq := range items {
+		line := QuoteLine{
+			ProductID: itemReq.ProductID,
+			Quantity:  itemReq.Quantity,
+		}
+
+		product, ok := eval.productMap[itemReq.ProductID]
+		if !ok {
+			line.Error = fmt.Sprintf("товар не найден: %s", itemReq.ProductID)
+			eval.lines = append(eval.lines, line)
+			continue
+		}
+
+		line.Name = product.Name
+		line.Price = product.Price
+		line.LineTotal = product.Price.Mul(itemReq.Quantity)
+		line.Error = s.validateLine(eval, product, itemReq.Quantity)
+		eval.lines = append(eval.lines, line)
+		if line.Error != "" {
+			continue
+		}
+
+		// Расчет общей стоимости товаров
+		eval.itemsTotal += line.LineTotal
+		if product.Weight != nil {
+			eval.totalWeight += *product.Weight * float64(itemReq.Quantity)
+		}
+
+		eval.orderItems = append(eval.orderItems, models.OrderItem{
+			ID:        uuid.New(),
+			ProductID: product.ID,
+			Quantity:  itemReq.Quantity,
+			Price:     product.Price,
+			Status:    models.OrderItemStatusActive,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if eval.storeID == nil {
+		if len(items) > 0 {
+			eval.errs = append(eval.errs, "не удалось определить магазин заказа")
+		}
+		return eval, nil
+	}
+
+	// Расчет сборов по правилам магазина
+	quote, err := s.pricer.Calculate(ctx, pricing.Input{
+		StoreID:     *eval.storeID,
+		ItemsTotal:  eval.itemsTotal,
+		TotalWeight: eval.totalWeight,
+		Distance:    distance,
+	})
+	if errors.Is(err, pricing.ErrBelowMinOrderAmount) {
+		eval.errs = append(eval.errs, err.Error())
+		return eval, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEvaluationFailed, err)
+	}
+	eval.quote = quote
+
+	return eval, nil
+}
+
+// validateLine проверяет позицию заказа и возвращает текст ошибки.
+// Магазин заказа определяется по первому товару с заданным магазином.
+func (s *OrderService) validateLine(eval *orderEvaluation, product models.Product, quantity int) string {
+	if quantity < 1 {
+		return fmt.Sprintf("количество товара должно быть положительным: %s", product.Name)
+	}
+
+	if product.StoreID == uuid.Nil {
+		return "у товара не задан магазин"
+	}
+
+	if eval.storeID == nil {
+		storeID := product.StoreID
+		eval.storeID = &storeID
+	} else if product.StoreID != *eval.storeID {
+		return "нельзя создавать заказ из разных магазинов"
+	}
+
+	if !product.IsAvailable {
+		return fmt.Sprintf("товар недоступен: %s", product.Name)
+	}
+
+	if product.Stock != nil && *product.Stock < quantity {
+		return fmt.Sprintf("недостаточно товара на складе: %s", product.Name)
+	}
+
+	return ""
+}
@@ -0,0 +1,216 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrNothingToReorder возвращается, когда ни один товар нельзя заказать повторно.
+var ErrNothingToReorder = errors.New("ни одного товара для повторного заказа нет в наличии")
+
+// ReorderChangeType описывает, что изменилось в товаре с момента прошлого заказа.
+type ReorderChangeType string
+
+const (
+	ReorderChangePriceChanged    ReorderChangeType = "PRICE_CHANGED"
+	ReorderChangeQuantityReduced ReorderChangeType = "QUANTITY_REDUCED"
+	ReorderChangeUnavailable     ReorderChangeType = "UNAVAILABLE"
+	ReorderChangeNotFound        ReorderChangeType = "NOT_FOUND"
+)
+
+// ReorderRequest представляет запрос на повтор заказа или заказ по шаблону.
+// Незаполненные поля берутся из исходного заказа (шаблона). Без place
+// возвращается только предпросмотр с изменениями и расчетом.
+type ReorderRequest struct {
+	PaymentMethod   *models.PaymentMethod `json:"payment_method,omitempty"`
+	DeliveryAddress *string               `json:"delivery_address,omitempty"`
+	Distance        *float64              `json:"distance,omitempty" binding:"omitempty,gte=0"`
+	Comment         *string               `json:"comment,omitempty"`
+	DeliverySlotID  *uuid.UUID            `json:"delivery_slot_id,omitempty"`
+	Place           bool                  `json:"place"`
+}
+
+// ReorderChange представляет изменение товара относительно исходного заказа.
+type ReorderChange struct {
+	ProductID   uuid.UUID         `json:"product_id"`
+	Name        string            `json:"name,omitempty"`
+	Type        ReorderChangeType `json:"type"`
+	OldPrice    models.Money      `json:"old_price"`
+	NewPrice    *models.Money     `json:"new_price,omitempty"`
+	OldQuantity int               `json:"old_quantity"`
+	NewQuantity int               `json:"new_quantity"`
+}
+
+// ReorderResult представляет собранный заново заказ: запрос на создание,
+// изменения по товарам и расчет по текущим ценам. Order заполнен, если
+// заказ был создан.
+type ReorderResult struct {
+	Request CreateOrderRequest     `json:"request"`
+	Changes []ReorderChange        `json:"changes"`
+	Quote   *OrderQuote            `json:"quote"`
+	Order   *models.OrderWithItems `json:"order,omitempty"`
+}
+
+// reorderSource представляет товар, который нужно заказать повторно,
+// с ценой на момент исходного заказа.
+type reorderSource struct {
+	productID uuid.UUID
+	quantity  int
+	price     models.Money
+}
+
+// Reorder собирает новый заказ по товарам прошлого заказа покупателя.
+// Наличие и цены проверяются по каталогу; недоступные товары исключаются,
+// количество уменьшается до остатка.
+func (s *OrderService) Reorder(ctx context.Context, id uuid.UUID, req ReorderRequest, actor models.Actor) (*ReorderResult, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canReorder(actor, order) {
+		return nil, ErrForbidden
+	}
+
+	items, err := s.orderItemRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары заказа: %w", err)
+	}
+	delivery, err := s.deliveryRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить доставку: %w", err)
+	}
+
+	base := CreateOrderRequest{
+		UserID:          order.UserID,
+		PaymentMethod:   order.PaymentMethod,
+		DeliveryAddress: delivery.Address,
+		Distance:        delivery.Distance,
+	}
+	return s.rebuildOrder(ctx, applyReorderRequest(base, req), orderSources(items), req.Place)
+}
+
+// rebuildOrder сверяет товары с каталогом, собирает запрос на создание
+// заказа и, если place, создает заказ.
+func (s *OrderService) rebuildOrder(ctx context.Context, base CreateOrderRequest, sources []reorderSource, place bool) (*ReorderResult, error) {
+	productIDs := make([]uuid.UUID, len(sources))
+	for i, source := range sources {
+		productIDs[i] = source.productID
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	productMap := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	items := make([]CreateOrderItemRequest, 0, len(sources))
+	changes := make([]ReorderChange, 0)
+	for _, source := range sources {
+		change := ReorderChange{
+			ProductID:   source.productID,
+			OldPrice:    source.price,
+			OldQuantity: source.quantity,
+		}
+
+		product, ok := productMap[source.productID]
+		if !ok {
+			change.Type = ReorderChangeNotFound
+			changes = append(changes, change)
+			continue
+		}
+		change.Name = product.Name
+		price := product.Price
+		change.NewPrice = &price
+
+		quantity := source.quantity
+		if product.Stock != nil && *product.Stock < quantity {
+			quantity = *product.Stock
+		}
+		if !product.IsAvailable || quantity <= 0 {
+			change.Type = ReorderChangeUnavailable
+			changes = append(changes, change)
+			continue
+		}
+
+		change.NewQuantity = quantity
+		if quantity < source.quantity {
+			change.Type = ReorderChangeQuantityReduced
+			changes = append(changes, change)
+		} else if product.Price != source.price {
+			change.Type = ReorderChangePriceChanged
+			changes = append(changes, change)
+		}
+
+		items = append(items, CreateOrderItemRequest{ProductID: product.ID, Quantity: quantity})
+	}
+
+	base.Items = items
+	quote, err := s.QuoteOrder(ctx, QuoteRequest{Items: items, Distance: base.Distance})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReorderResult{
+		Request: base,
+		Changes: changes,
+		Quote:   quote,
+	}
+	if !place {
+		return result, nil
+	}
+	if len(items) == 0 {
+		return nil, ErrNothingToReorder
+	}
+
+	order, err := s.CreateOrder(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	result.Order = order
+	return result, nil
+}
+
+// applyReorderRequest переносит переопределенные покупателем поля в запрос.
+func applyReorderRequest(base CreateOrderRequest, req ReorderRequest) CreateOrderRequest {
+	if req.PaymentMethod != nil {
+		base.PaymentMethod = *req.PaymentMethod
+	}
+	if req.DeliveryAddress != nil {
+		base.DeliveryAddress = *req.DeliveryAddress
+	}
+	if req.Distance != nil {
+		base.Distance = req.Distance
+	}
+	if req.Comment != nil {
+		base.Comment = req.Comment
+	}
+	base.DeliverySlotID = req.DeliverySlotID
+	return base
+}
+
+// orderSources собирает активные позиции заказа, объединяя повторы товара.
+func orderSources(items []models.OrderItem) []reorderSource {
+	sources := make([]reorderSource, 0, len(items))
+	index := make(map[uuid.UUID]int, len(items))
+	for _, item := range activeItems(items) {
+		if i, ok := index[item.ProductID]; ok {
+			sources[i].quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(sources)
+		sources = append(sources, reorderSource{
+			productID: item.ProductID,
+			quantity:  item.Quantity,
+			price:     item.Price,
+		})
+	}
+	return sources
+}
@@ -2,10 +2,18 @@ package orders
 
 import (
 	"context"
+	"errors"
+	"time"
 	"Laman/internal/models"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+	// ErrOrderNotFound возвращается, когда заказа с таким ID нет.
+	ErrOrderNotFound = errors.New("заказ не найден")
+)
+
 // OrderRepository определяет интерфейс для доступа к данным заказов.
 type OrderRepository interface {
 	// Create создает новый заказ.
@@ -14,16 +22,61 @@ type OrderRepository interface {
 	// GetByID получает заказ по ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
 	
-	// GetByUserID получает все заказы пользователя.
-	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Order, error)
+	// GetByIDForUpdate получает заказ по ID и блокирует его строку до конца транзакции.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	
+	// List получает страницу заказов по фильтру от новых к старым.
+	List(ctx context.Context, filter OrderFilter) ([]models.Order, error)
+	
+	// GetByCheckoutGroupID получает подзаказы чекаута.
+	GetByCheckoutGroupID(ctx context.Context, groupID uuid.UUID) ([]models.Order, error)
+	
+	// GetScheduledDue получает ID запланированных заказов, слот которых начинается не позже until.
+	GetScheduledDue(ctx context.Context, until time.Time, limit int) ([]uuid.UUID, error)
+	
+	// ClaimForEscalation отмечает эскалацию неэскалированных заказов в статусах
+	// statuses, ожидающих магазин с момента не позже queuedBefore, и возвращает их.
+	// Строки, заблокированные другими репликами, пропускаются.
+	ClaimForEscalation(ctx context.Context, statuses []models.OrderStatus, queuedBefore time.Time, limit int) ([]models.Order, error)
+	
+	// GetStale получает ID заказов в статусах statuses, ожидающих магазин
+	// с момента не позже queuedBefore.
+	GetStale(ctx context.Context, statuses []models.OrderStatus, queuedBefore time.Time, limit int) ([]uuid.UUID, error)
+	
+	// ClaimGuestOrders привязывает к пользователю гостевые заказы с телефоном,
+	// совпадающим с phone по цифрам. Возвращает число привязанных заказов.
+	ClaimGuestOrders(ctx context.Context, userID uuid.UUID, phone string) (int, error)
 	
 	// UpdateStatus обновляет статус заказа.
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus) error
 	
+	// Cancel переводит заказ в CANCELLED и сохраняет причину отмены.
+	Cancel(ctx context.Context, id uuid.UUID, reason models.CancellationReason, comment *string) error
+	
 	// Update обновляет заказ.
 	Update(ctx context.Context, order *models.Order) error
 }
 
+// OrderFilter задает условия выборки списка заказов. Пустые поля не ограничивают выборку.
+type OrderFilter struct {
+	UserID        *uuid.UUID
+	StoreID       *uuid.UUID
+	Statuses      []models.OrderStatus
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	PaymentMethod *models.PaymentMethod
+	GuestPhone    *string
+	// After — позиция последнего заказа предыдущей страницы.
+	After *OrderCursor
+	Limit int
+}
+
+// OrderCursor задает позицию в списке заказов, упорядоченном по (created_at, id).
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
 // OrderItemRepository определяет интерфейс для доступа к данным товаров заказа.
 type OrderItemRepository interface {
 	// Create создает новый товар заказа.
@@ -34,4 +87,78 @@ type OrderItemRepository interface {
 	
 	// GetByOrderID получает все товары для заказа.
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+	
+	// Update обновляет количество и статус товара заказа.
+	Update(ctx context.Context, item *models.OrderItem) error
+}
+
+// IdempotencyRepository определяет интерфейс для хранения ключей идемпотентности.
+type IdempotencyRepository interface {
+	// Create сохраняет ключ. Возвращает ErrIdempotencyKeyExists, если ключ уже занят.
+	Create(ctx context.Context, key *models.IdempotencyKey) error
+
+	// GetByKey получает сохраненный ключ. Возвращает nil, если ключ не найден.
+	GetByKey(ctx context.Context, key string) (*models.IdempotencyKey, error)
+}
+
+// OrderStatusEventRepository определяет интерфейс для доступа к истории статусов заказа.
+type OrderStatusEventRepository interface {
+	// Create добавляет событие в историю.
+	Create(ctx context.Context, event *models.OrderStatusEvent) error
+
+	// GetByOrderID получает историю заказа в хронологическом порядке.
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusEvent, error)
+}
+
+// CheckoutGroupRepository определяет интерфейс для доступа к чекаутам из нескольких магазинов.
+type CheckoutGroupRepository interface {
+	// Create создает чекаут.
+	Create(ctx context.Context, group *models.CheckoutGroup) error
+
+	// GetByID получает чекаут по ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CheckoutGroup, error)
+
+	// GetByIDForUpdate получает чекаут по ID и блокирует его строку до конца транзакции.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.CheckoutGroup, error)
+
+	// UpdateTotals сохраняет пересчитанные итоги чекаута.
+	UpdateTotals(ctx context.Context, group *models.CheckoutGroup) error
+
+	// ClaimForUser привязывает к пользователю гостевые чекауты, подзаказы которых ему принадлежат.
+	ClaimForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// Ошибки шаблонов заказов.
+var (
+	ErrTemplateNotFound  = errors.New("шаблон заказа не найден")
+	ErrTemplateNameTaken = errors.New("шаблон с таким названием уже существует")
+)
+
+// OrderTemplateRepository определяет интерфейс для доступа к шаблонам заказов.
+// Товары шаблона сохраняются и загружаются вместе с ним.
+type OrderTemplateRepository interface {
+	// Create создает шаблон с товарами. Возвращает ErrTemplateNameTaken, если название занято.
+	Create(ctx context.Context, template *models.OrderTemplate) error
+
+	// GetByID получает шаблон по ID. Возвращает ErrTemplateNotFound, если шаблон не найден.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.OrderTemplate, error)
+
+	// GetByUserID получает шаблоны пользователя.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.OrderTemplate, error)
+
+	// Update обновляет шаблон и заменяет его товары.
+	Update(ctx context.Context, template *models.OrderTemplate) error
+
+	// Delete удаляет шаблон.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ClaimDue блокирует шаблоны, время повтора которых наступило, пропуская
+	// заблокированные другими репликами. Вызывается внутри транзакции.
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.OrderTemplate, error)
+
+	// UpdateSchedule переносит следующий повтор шаблона.
+	UpdateSchedule(ctx context.Context, id uuid.UUID, nextRunAt *time.Time) error
+
+	// RecordRun сохраняет результат автоматического повтора.
+	RecordRun(ctx context.Context, id uuid.UUID, runAt time.Time, orderID *uuid.UUID, runErr *string) error
 }
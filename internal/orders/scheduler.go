@@ -0,0 +1,120 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// scheduledBatchSize ограничивает число заказов, активируемых за один проход.
+const scheduledBatchSize = 100
+
+// errNotScheduled означает, что заказ уже активирован или отменен параллельно.
+var errNotScheduled = errors.New("заказ больше не запланирован")
+
+// systemActor — участник, от имени которого фоновые задачи меняют статус.
+var systemActor = models.Actor{Role: models.UserRoleSystem}
+
+// ActivateScheduledOrders переводит в NEW запланированные заказы, слот которых
+// начинается не позже until. Возвращает число активированных заказов;
+// ошибка отдельного заказа записывается в лог и не прерывает проход.
+// Каждый заказ блокируется в changeStatus, поэтому параллельные запуски
+// на нескольких репликах не активируют заказ дважды.
+func (s *OrderService) ActivateScheduledOrders(ctx context.Context, until time.Time) (int, error) {
+	ids, err := s.orderRepo.GetScheduledDue(ctx, until, scheduledBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить запланированные заказы: %w", err)
+	}
+
+	activated := 0
+	for _, id := range ids {
+		err := s.changeStatus(ctx, id, statusChange{
+			status:    models.OrderStatusNew,
+			actor:     systemActor,
+			authorize: func(*models.Order) bool { return true },
+			guard: func(order *models.Order) error {
+				if order.Status != models.OrderStatusScheduled {
+					return errNotScheduled
+				}
+				return nil
+			},
+		})
+		if errors.Is(err, errNotScheduled) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return activated, ctx.Err()
+			}
+			// Сбойный заказ не должен задерживать остальные: он попадет
+			// в следующий проход
+			if s.logger != nil {
+				s.logger.Warn("Не удалось активировать запланированный заказ",
+					zap.String("order_id", id.String()), zap.Error(err))
+			}
+			continue
+		}
+		activated++
+	}
+	return activated, nil
+}
+
+// Scheduler периодически переводит запланированные заказы в активную очередь
+// за lead до начала слота доставки и создает заказы по повторяющимся шаблонам.
+type Scheduler struct {
+	service  *OrderService
+	interval time.Duration
+	lead     time.Duration
+	logger   *zap.Logger
+}
+
+// NewScheduler создает планировщик запланированных заказов.
+func NewScheduler(service *OrderService, interval, lead time.Duration, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		service:  service,
+		interval: interval,
+		lead:     lead,
+		logger:   logger,
+	}
+}
+
+// Run выполняет проходы планировщика, пока не будет отменен ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	now := time.Now()
+
+	activated, err := s.service.ActivateScheduledOrders(ctx, now.Add(s.lead))
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Не удалось активировать запланированные заказы", zap.Error(err))
+	}
+	if activated > 0 {
+		s.logger.Info("Запланированные заказы переданы в работу", zap.Int("count", activated))
+	}
+
+	placed, err := s.service.RunDueTemplates(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Не удалось выполнить повторы шаблонов", zap.Error(err))
+	}
+	if placed > 0 {
+		s.logger.Info("Созданы заказы по шаблонам", zap.Int("count", placed))
+	}
+}
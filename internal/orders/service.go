@@ -2,12 +2,21 @@ package orders
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"Laman/internal/catalog"
+	"Laman/internal/database"
+	"Laman/internal/events"
 	"Laman/internal/models"
 	"Laman/internal/observability"
+	"Laman/internal/pricing"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -15,54 +24,104 @@ import (
 // OrderService обрабатывает бизнес-логику, связанную с созданием заказов,
 // расчетом цен и управлением жизненным циклом.
 type OrderService struct {
-	orderRepo         OrderRepository
-	orderItemRepo     OrderItemRepository
-	productRepo       ProductRepository
-	deliveryRepo      DeliveryRepository
-	paymentRepo       PaymentRepository
-	notifier          *observability.TelegramNotifier
-	logger            *zap.Logger
-	serviceFeePercent float64
-	deliveryFee       float64
+	uow             database.UnitOfWork
+	orderRepo       OrderRepository
+	orderItemRepo   OrderItemRepository
+	productRepo     ProductRepository
+	deliveryRepo    DeliveryRepository
+	paymentRepo     PaymentRepository
+	idempotencyRepo IdempotencyRepository
+	eventRepo       OrderStatusEventRepository
+	checkoutRepo    CheckoutGroupRepository
+	templateRepo    OrderTemplateRepository
+	pricer          Pricer
+	slots           SlotReserver
+	broker          events.Broker
+	cancelWindow    time.Duration
+	tracking        *TrackingSigner
+	deliveryETA     time.Duration
+	notifier        *observability.TelegramNotifier
+	logger          *zap.Logger
 }
 
+// ErrIdempotencyKeyMismatch возвращается, когда Idempotency-Key повторно
+// используется с другим телом запроса.
+var ErrIdempotencyKeyMismatch = errors.New("ключ идемпотентности уже использован с другим запросом")
+
 // ProductRepository определяет интерфейс, необходимый из модуля catalog.
 type ProductRepository interface {
 	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Product, error)
+	ReserveStock(ctx context.Context, id uuid.UUID, quantity int) error
+	ReleaseStock(ctx context.Context, id uuid.UUID, quantity int) error
+	DiscardReservation(ctx context.Context, id uuid.UUID, quantity int) error
+}
+
+// Pricer определяет интерфейс, необходимый из модуля pricing.
+type Pricer interface {
+	Calculate(ctx context.Context, in pricing.Input) (*pricing.Quote, error)
+}
+
+// SlotReserver определяет интерфейс, необходимый из модуля scheduling.
+type SlotReserver interface {
+	ReserveSlot(ctx context.Context, storeID, slotID uuid.UUID, now time.Time) (*models.DeliverySlot, error)
+	ReleaseSlot(ctx context.Context, slotID uuid.UUID) error
 }
 
 // DeliveryRepository определяет интерфейс, необходимый из модуля delivery.
 type DeliveryRepository interface {
 	Create(ctx context.Context, delivery *models.Delivery) error
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Delivery, error)
+	Update(ctx context.Context, delivery *models.Delivery) error
 }
 
 // PaymentRepository определяет интерфейс, необходимый из модуля payments.
 type PaymentRepository interface {
 	Create(ctx context.Context, payment *models.Payment) error
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status models.PaymentStatus) error
+	UpdateAmount(ctx context.Context, id uuid.UUID, amount models.Money) error
 }
 
 // NewOrderService создает новый сервис заказов.
 func NewOrderService(
+	uow database.UnitOfWork,
 	orderRepo OrderRepository,
 	orderItemRepo OrderItemRepository,
 	productRepo ProductRepository,
 	deliveryRepo DeliveryRepository,
 	paymentRepo PaymentRepository,
-	serviceFeePercent float64,
-	deliveryFee float64,
+	idempotencyRepo IdempotencyRepository,
+	eventRepo OrderStatusEventRepository,
+	checkoutRepo CheckoutGroupRepository,
+	templateRepo OrderTemplateRepository,
+	pricer Pricer,
+	slots SlotReserver,
+	broker events.Broker,
+	cancelWindow time.Duration,
+	tracking *TrackingSigner,
+	deliveryETA time.Duration,
 	notifier *observability.TelegramNotifier,
 	logger *zap.Logger,
 ) *OrderService {
 	return &OrderService{
-		orderRepo:         orderRepo,
-		orderItemRepo:     orderItemRepo,
-		productRepo:       productRepo,
-		deliveryRepo:      deliveryRepo,
-		paymentRepo:       paymentRepo,
-		serviceFeePercent: serviceFeePercent,
-		deliveryFee:       deliveryFee,
-		notifier:          notifier,
-		logger:            logger,
+		uow:             uow,
+		orderRepo:       orderRepo,
+		orderItemRepo:   orderItemRepo,
+		productRepo:     productRepo,
+		deliveryRepo:    deliveryRepo,
+		paymentRepo:     paymentRepo,
+		idempotencyRepo: idempotencyRepo,
+		eventRepo:       eventRepo,
+		checkoutRepo:    checkoutRepo,
+		templateRepo:    templateRepo,
+		pricer:          pricer,
+		slots:           slots,
+		broker:          broker,
+		cancelWindow:    cancelWindow,
+		tracking:        tracking,
+		deliveryETA:     deliveryETA,
+		notifier:        notifier,
+		logger:          logger,
 	}
 }
 
@@ -73,9 +132,11 @@ type CreateOrderRequest struct {
 	GuestPhone      *string                  `json:"guest_phone,omitempty"`
 	GuestAddress    *string                  `json:"guest_address,omitempty"`
 	Comment         *string                  `json:"comment,omitempty"`
-	Items           []CreateOrderItemRequest `json:"items" binding:"required"`
+	Items           []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
 	PaymentMethod   models.PaymentMethod     `json:"payment_method" binding:"required"`
 	DeliveryAddress string                   `json:"delivery_address" binding:"required"`
+	Distance        *float64                 `json:"distance,omitempty" binding:"omitempty,gte=0"`
+	DeliverySlotID  *uuid.UUID               `json:"delivery_slot_id,omitempty"`
 }
 
 // CreateOrderItemRequest представляет товар в запросе на создание заказа.
@@ -86,82 +147,155 @@ type CreateOrderItemRequest struct {
 
 // CreateOrder создает новый заказ с товарами, доставкой и оплатой.
 func (s *OrderService) CreateOrder(ctx context.Context, req CreateOrderRequest) (*models.OrderWithItems, error) {
-	// Валидация запроса
-	if req.UserID == nil && (req.GuestName == nil || req.GuestPhone == nil || req.GuestAddress == nil) {
-		return nil, fmt.Errorf("должен быть указан либо user_id, либо информация о госте")
+	return s.createOrder(ctx, req, nil)
+}
+
+// CreateOrderIdempotent создает заказ не более одного раза для данного ключа.
+// Повторный запрос с тем же ключом и телом возвращает исходный заказ
+// (replayed = true), с другим телом — ErrIdempotencyKeyMismatch.
+func (s *OrderService) CreateOrderIdempotent(ctx context.Context, key string, req CreateOrderRequest) (order *models.OrderWithItems, replayed bool, err error) {
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("не удалось вычислить отпечаток запроса: %w", err)
 	}
 
-	// Получение товаров
-	productIDs := make([]uuid.UUID, len(req.Items))
-	for i, item := range req.Items {
-		productIDs[i] = item.ProductID
+	order, err = s.replayIdempotent(ctx, key, fingerprint)
+	if err != nil || order != nil {
+		return order, order != nil, err
 	}
 
-	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	order, err = s.createOrder(ctx, req, func(ctx context.Context, created *models.OrderWithItems) error {
+		response, err := json.Marshal(created)
+		if err != nil {
+			return err
+		}
+		return s.idempotencyRepo.Create(ctx, &models.IdempotencyKey{
+			Key:         key,
+			Fingerprint: fingerprint,
+			OrderID:     created.ID,
+			Response:    string(response),
+			CreatedAt:   time.Now(),
+		})
+	})
+	if errors.Is(err, ErrIdempotencyKeyExists) {
+		// Параллельный запрос с тем же ключом успел создать заказ первым
+		order, err = s.replayIdempotent(ctx, key, fingerprint)
+		if err == nil && order == nil {
+			err = fmt.Errorf("не удалось получить результат по ключу идемпотентности")
+		}
+		return order, order != nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return order, false, nil
+}
+
+// replayIdempotent возвращает сохраненный по ключу заказ или nil, если ключ еще не использовался.
+func (s *OrderService) replayIdempotent(ctx context.Context, key, fingerprint string) (*models.OrderWithItems, error) {
+	record, err := s.idempotencyRepo.GetByKey(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+		return nil, fmt.Errorf("не удалось получить ключ идемпотентности: %w", err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyMismatch
 	}
 
-	// Создание карты товаров
-	productMap := make(map[uuid.UUID]models.Product)
-	for _, product := range products {
-		productMap[product.ID] = product
+	var order models.OrderWithItems
+	if err := json.Unmarshal([]byte(record.Response), &order); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать сохраненный ответ: %w", err)
 	}
+	return &order, nil
+}
 
-	// Расчет общей стоимости товаров
-	var itemsTotal float64
-	var totalWeight float64
-	orderItems := make([]models.OrderItem, 0, len(req.Items))
-	itemLines := make([]string, 0, len(req.Items))
-	var storeID *uuid.UUID
+// requestFingerprint вычисляет SHA-256 от JSON-представления запроса.
+func requestFingerprint(req CreateOrderRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	for _, itemReq := range req.Items {
-		product, ok := productMap[itemReq.ProductID]
-		if !ok {
-			return nil, fmt.Errorf("товар не найден: %s", itemReq.ProductID)
-		}
+// createOrder выполняет создание заказа. beforeCommit, если задан, вызывается
+// внутри транзакции после записи заказа и может ее откатить, вернув ошибку.
+func (s *OrderService) createOrder(
+	ctx context.Context,
+	req CreateOrderRequest,
+	beforeCommit func(ctx context.Context, order *models.OrderWithItems) error,
+) (*models.OrderWithItems, error) {
+	// Валидация запроса
+	if err := validateCustomer(req); err != nil {
+		return nil, err
+	}
 
-		if product.StoreID == uuid.Nil {
-			return nil, fmt.Errorf("у товара не задан магазин")
-		}
+	eval, err := s.evaluateOrder(ctx, req.Items, req.Distance)
+	if err != nil {
+		return nil, err
+	}
+	if err := eval.firstError(); err != nil {
+		return nil, err
+	}
 
-		if storeID == nil {
-			storeID = &product.StoreID
-		} else if product.StoreID != *storeID {
-			return nil, fmt.Errorf("нельзя создавать заказ из разных магазинов")
-		}
+	draft := buildOrderDraft(req, eval, eval.quote)
 
-		if !product.IsAvailable {
-			return nil, fmt.Errorf("товар недоступен: %s", product.Name)
+	// Заказ, товары, доставка, оплата и резерв остатков создаются в одной транзакции
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.persistOrder(ctx, draft); err != nil {
+			return err
 		}
 
-		itemTotal := product.Price * float64(itemReq.Quantity)
-		itemsTotal += itemTotal
-
-		if product.Weight != nil {
-			totalWeight += *product.Weight * float64(itemReq.Quantity)
+		if beforeCommit != nil {
+			return beforeCommit(ctx, draft.result)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		orderItems = append(orderItems, models.OrderItem{
-			ID:        uuid.New(),
-			ProductID: product.ID,
-			Quantity:  itemReq.Quantity,
-			Price:     product.Price,
-			CreatedAt: time.Now(),
-		})
+	s.announceOrder(ctx, draft)
 
-		itemLines = append(itemLines, fmt.Sprintf("%s ×%d", product.Name, itemReq.Quantity))
+	return draft.result, nil
+}
+
+// validateCustomer проверяет, что заказ оформляет пользователь или гость с контактами.
+func validateCustomer(req CreateOrderRequest) error {
+	if req.UserID == nil && (req.GuestName == nil || req.GuestPhone == nil || req.GuestAddress == nil) {
+		return fmt.Errorf("должен быть указан либо user_id, либо информация о госте")
 	}
+	return nil
+}
 
-	// Расчет сборов
-	serviceFee := itemsTotal * s.serviceFeePercent / 100
-	finalTotal := itemsTotal + serviceFee + s.deliveryFee
+// orderDraft содержит подготовленный к записи заказ со связанными сущностями.
+type orderDraft struct {
+	req        CreateOrderRequest
+	order      *models.Order
+	items      []models.OrderItem
+	delivery   *models.Delivery
+	payment    *models.Payment
+	productMap map[uuid.UUID]models.Product
+	itemLines  []string
+	result     *models.OrderWithItems
+	event      *models.OrderStatusEvent
+}
+
+// buildOrderDraft собирает заказ, доставку и оплату по результату расчета.
+// quote передается отдельно, чтобы оформление из нескольких магазинов могло
+// изменить стоимость доставки подзаказа.
+func buildOrderDraft(req CreateOrderRequest, eval *orderEvaluation, quote *pricing.Quote) *orderDraft {
+	itemLines := make([]string, 0, len(eval.lines))
+	for _, line := range eval.lines {
+		itemLines = append(itemLines, fmt.Sprintf("%s ×%d", line.Name, line.Quantity))
+	}
 
 	// Создание заказа
 	now := time.Now()
-	if storeID == nil {
-		return nil, fmt.Errorf("не удалось определить магазин заказа")
-	}
 	order := &models.Order{
 		ID:            uuid.New(),
 		UserID:        req.UserID,
@@ -170,83 +304,266 @@ func (s *OrderService) CreateOrder(ctx context.Context, req CreateOrderRequest)
 		GuestAddress:  req.GuestAddress,
 		Comment:       req.Comment,
 		Status:        models.OrderStatusNew,
-		StoreID:       *storeID,
+		StoreID:       *eval.storeID,
 		PaymentMethod: req.PaymentMethod,
-		ItemsTotal:    itemsTotal,
-		ServiceFee:    serviceFee,
-		DeliveryFee:   s.deliveryFee,
-		FinalTotal:    finalTotal,
+		ItemsTotal:    eval.itemsTotal,
+		ServiceFee:    quote.ServiceFee,
+		DeliveryFee:   quote.DeliveryFee,
+		FinalTotal:    quote.FinalTotal,
+		PricingRuleID: &quote.RuleID,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
 
-	// Создание заказа в транзакции
-	err = s.orderRepo.Create(ctx, order)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось создать заказ: %w", err)
-	}
-
 	// Установка ID заказа для товаров
+	orderItems := eval.orderItems
 	for i := range orderItems {
 		orderItems[i].OrderID = order.ID
 	}
 
-	// Создание товаров заказа
-	if err := s.orderItemRepo.CreateBatch(ctx, orderItems); err != nil {
-		return nil, fmt.Errorf("не удалось создать товары заказа: %w", err)
-	}
-
-	// Создание доставки
+	totalWeight := eval.totalWeight
 	delivery := &models.Delivery{
 		ID:        uuid.New(),
 		OrderID:   order.ID,
 		Address:   req.DeliveryAddress,
+		Distance:  req.Distance,
 		Weight:    &totalWeight,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
-		return nil, fmt.Errorf("не удалось создать доставку: %w", err)
-	}
-
-	// Создание оплаты
 	payment := &models.Payment{
 		ID:        uuid.New(),
 		OrderID:   order.ID,
 		Method:    req.PaymentMethod,
 		Status:    models.PaymentStatusPending,
-		Amount:    finalTotal,
+		Amount:    quote.FinalTotal,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	if err := s.paymentRepo.Create(ctx, payment); err != nil {
-		return nil, fmt.Errorf("не удалось создать оплату: %w", err)
+	return &orderDraft{
+		req:        req,
+		order:      order,
+		items:      orderItems,
+		delivery:   delivery,
+		payment:    payment,
+		productMap: eval.productMap,
+		itemLines:  itemLines,
 	}
+}
 
-	if s.notifier != nil {
-		itemsText := strings.Join(itemLines, ", ")
-		customerText := buildCustomerText(req, order.ID)
-		addressText := buildAddressText(req)
+// persistOrder записывает подготовленный заказ. Вызывается внутри транзакции.
+func (s *OrderService) persistOrder(ctx context.Context, draft *orderDraft) error {
+	if err := s.reserveStock(ctx, draft.items, draft.productMap); err != nil {
+		return err
+	}
 
-		notifyCtx := observability.WithOrderMessageMeta(ctx, observability.OrderMessageMeta{
-			Customer: customerText,
-			Phone:    buildPhoneText(req),
-			Comment:  buildCommentText(req),
-			Address:  addressText,
-			Items:    itemsText,
-		})
+	// Заказ на выбранный слот ждет в статусе SCHEDULED, пока планировщик
+	// не переведет его в активную очередь
+	if draft.req.DeliverySlotID != nil {
+		slot, err := s.slots.ReserveSlot(ctx, draft.order.StoreID, *draft.req.DeliverySlotID, draft.order.CreatedAt)
+		if err != nil {
+			return err
+		}
+		draft.delivery.SlotID = &slot.ID
+		draft.delivery.SlotStart = &slot.StartsAt
+		draft.delivery.SlotEnd = &slot.EndsAt
+		draft.order.Status = models.OrderStatusScheduled
+	}
+
+	if err := s.orderRepo.Create(ctx, draft.order); err != nil {
+		return fmt.Errorf("не удалось создать заказ: %w", err)
+	}
+
+	if err := s.orderItemRepo.CreateBatch(ctx, draft.items); err != nil {
+		return fmt.Errorf("не удалось создать товары заказа: %w", err)
+	}
+
+	if err := s.deliveryRepo.Create(ctx, draft.delivery); err != nil {
+		return fmt.Errorf("не удалось создать доставку: %w", err)
+	}
+
+	if err := s.paymentRepo.Create(ctx, draft.payment); err != nil {
+		return fmt.Errorf("не удалось создать оплату: %w", err)
+	}
+
+	event, err := s.recordStatusEvent(ctx, draft.order.ID, nil, draft.order.Status, creatorActor(draft.req), nil, nil)
+	if err != nil {
+		return err
+	}
+	draft.event = event
+	draft.result = &models.OrderWithItems{
+		Order:   *draft.order,
+		Items:   draft.items,
+		History: []models.OrderStatusEvent{*event},
+	}
+	s.issueTracking(draft.result)
+	return nil
+}
+
+// announceOrder публикует событие создания и отправляет уведомление магазину.
+// Вызывается только после коммита транзакции.
+func (s *OrderService) announceOrder(ctx context.Context, draft *orderDraft) {
+	s.publishEvent(ctx, draft.order, draft.event)
+
+	if s.notifier == nil {
+		return
+	}
+
+	req := draft.req
+	notifyCtx := observability.WithOrderMessageMeta(ctx, observability.OrderMessageMeta{
+		Customer: buildCustomerText(req, draft.order.ID),
+		Phone:    buildPhoneText(req),
+		Comment:  buildCommentText(req),
+		Address:  buildAddressText(req),
+		Items:    strings.Join(draft.itemLines, ", "),
+		Delivery: buildDeliveryText(draft.delivery),
+	})
+
+	if err := s.notifier.NotifyNewOrder(notifyCtx, draft.order); err != nil && s.logger != nil {
+		s.logger.Warn("Не удалось отправить уведомление в Telegram", zap.Error(err))
+	}
+}
+
+// creatorActor возвращает участника, создающего заказ: покупателя или гостя.
+func creatorActor(req CreateOrderRequest) models.Actor {
+	if req.UserID != nil {
+		return models.Actor{UserID: req.UserID, Role: models.UserRoleCustomer}
+	}
+	return models.Actor{Role: models.UserRoleGuest}
+}
+
+// recordStatusEvent добавляет переход статуса в историю заказа.
+func (s *OrderService) recordStatusEvent(
+	ctx context.Context,
+	orderID uuid.UUID,
+	from *models.OrderStatus,
+	to models.OrderStatus,
+	actor models.Actor,
+	reasonCode *models.CancellationReason,
+	reason *string,
+) (*models.OrderStatusEvent, error) {
+	event := &models.OrderStatusEvent{
+		ID:          uuid.New(),
+		OrderID:     orderID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		ReasonCode:  reasonCode,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		return nil, fmt.Errorf("не удалось записать историю статусов: %w", err)
+	}
+	return event, nil
+}
 
-		if err := s.notifier.NotifyNewOrder(notifyCtx, order); err != nil && s.logger != nil {
-			s.logger.Warn("Не удалось отправить уведомление в Telegram", zap.Error(err))
+// publishEvent рассылает подписчикам уже зафиксированный переход статуса.
+// Ошибка доставки не влияет на результат операции: история остается в БД.
+func (s *OrderService) publishEvent(ctx context.Context, order *models.Order, event *models.OrderStatusEvent) {
+	if s.broker == nil || event == nil {
+		return
+	}
+
+	err := s.broker.Publish(ctx, events.OrderEvent{
+		OrderID:    order.ID,
+		StoreID:    order.StoreID,
+		UserID:     order.UserID,
+		FromStatus: event.FromStatus,
+		Status:     event.ToStatus,
+		ActorRole:  event.ActorRole,
+		ReasonCode: event.ReasonCode,
+		Reason:     event.Reason,
+		OccurredAt: event.CreatedAt,
+	})
+	if err != nil && s.logger != nil {
+		s.logger.Warn("Не удалось опубликовать событие заказа", zap.Error(err))
+	}
+}
+
+// SubscribeEvents подписывает на события всех заказов.
+// Фильтрация по правам участника выполняется на стороне вызывающего.
+func (s *OrderService) SubscribeEvents() (<-chan events.OrderEvent, func()) {
+	return s.broker.Subscribe()
+}
+
+// reserveStock списывает остатки по всем товарам заказа. Товары блокируются
+// в порядке возрастания ID, чтобы параллельные оформления не взаимоблокировались.
+func (s *OrderService) reserveStock(ctx context.Context, items []models.OrderItem, productMap map[uuid.UUID]models.Product) error {
+	quantities := aggregateQuantities(items)
+	for _, id := range sortedProductIDs(quantities) {
+		if err := s.productRepo.ReserveStock(ctx, id, quantities[id]); err != nil {
+			if errors.Is(err, catalog.ErrInsufficientStock) {
+				return fmt.Errorf("недостаточно товара на складе: %s", productMap[id].Name)
+			}
+			return fmt.Errorf("не удалось зарезервировать товар: %w", err)
 		}
 	}
+	return nil
+}
 
-	return &models.OrderWithItems{
-		Order: *order,
-		Items: orderItems,
-	}, nil
+// releaseStock возвращает на остаток товары отмененного заказа в том же
+// порядке блокировок, что и reserveStock.
+func (s *OrderService) releaseStock(ctx context.Context, orderID uuid.UUID) error {
+	items, err := s.orderItemRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить товары заказа: %w", err)
+	}
+
+	quantities := aggregateQuantities(activeItems(items))
+	for _, id := range sortedProductIDs(quantities) {
+		if err := s.productRepo.ReleaseStock(ctx, id, quantities[id]); err != nil {
+			return fmt.Errorf("не удалось вернуть товар на склад: %w", err)
+		}
+	}
+	return nil
+}
+
+// releaseSlot освобождает место в слоте доставки отмененного заказа.
+func (s *OrderService) releaseSlot(ctx context.Context, orderID uuid.UUID) error {
+	delivery, err := s.deliveryRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить доставку: %w", err)
+	}
+	if delivery.SlotID == nil {
+		return nil
+	}
+	return s.slots.ReleaseSlot(ctx, *delivery.SlotID)
+}
+
+// activeItems возвращает позиции, входящие в состав заказа.
+func activeItems(items []models.OrderItem) []models.OrderItem {
+	active := make([]models.OrderItem, 0, len(items))
+	for _, item := range items {
+		if item.IsActive() {
+			active = append(active, item)
+		}
+	}
+	return active
+}
+
+// aggregateQuantities суммирует количество по каждому товару.
+func aggregateQuantities(items []models.OrderItem) map[uuid.UUID]int {
+	quantities := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// sortedProductIDs возвращает ID товаров в порядке возрастания, в котором
+// блокируются их строки.
+func sortedProductIDs(quantities map[uuid.UUID]int) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
 }
 
 func buildCustomerText(req CreateOrderRequest, orderID uuid.UUID) string {
@@ -285,6 +602,14 @@ func buildAddressText(req CreateOrderRequest) string {
 	return ""
 }
 
+// buildDeliveryText возвращает выбранный слот доставки или пустую строку для заказа «как можно скорее».
+func buildDeliveryText(delivery *models.Delivery) string {
+	if delivery == nil || delivery.SlotStart == nil || delivery.SlotEnd == nil {
+		return ""
+	}
+	return delivery.SlotStart.Local().Format("02.01 15:04") + "–" + delivery.SlotEnd.Local().Format("15:04")
+}
+
 func shortUUID(id uuid.UUID) string {
 	value := id.String()
 	if len(value) <= 8 {
@@ -326,7 +651,11 @@ func buildAddressTextFromOrder(order *models.Order) string {
 
 func (s *OrderService) buildItemsText(ctx context.Context, orderID uuid.UUID) string {
 	items, err := s.orderItemRepo.GetByOrderID(ctx, orderID)
-	if err != nil || len(items) == 0 {
+	if err != nil {
+		return ""
+	}
+	items = activeItems(items)
+	if len(items) == 0 {
 		return ""
 	}
 
@@ -362,57 +691,180 @@ func (s *OrderService) buildItemsText(ctx context.Context, orderID uuid.UUID) st
 	return strings.Join(lines, ", ")
 }
 
-// GetOrder получает заказ по ID с товарами.
-func (s *OrderService) GetOrder(ctx context.Context, id uuid.UUID) (*models.OrderWithItems, error) {
+// GetOrder получает заказ по ID с товарами, если участнику разрешено его видеть.
+func (s *OrderService) GetOrder(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.OrderWithItems, error) {
 	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось получить заказ: %w", err)
 	}
 
+	if !canViewOrder(actor, order) {
+		return nil, ErrForbidden
+	}
+
 	items, err := s.orderItemRepo.GetByOrderID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось получить товары заказа: %w", err)
 	}
 
+	history, err := s.eventRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю заказа: %w", err)
+	}
+
 	return &models.OrderWithItems{
-		Order: *order,
-		Items: items,
+		Order:   *order,
+		Items:   items,
+		History: history,
 	}, nil
 }
 
-// GetUserOrders получает все заказы пользователя.
-func (s *OrderService) GetUserOrders(ctx context.Context, userID uuid.UUID) ([]models.Order, error) {
-	orders, err := s.orderRepo.GetByUserID(ctx, userID)
+// GetOrderHistory получает историю статусов заказа, если участнику разрешено видеть заказ.
+func (s *OrderService) GetOrderHistory(ctx context.Context, id uuid.UUID, actor models.Actor) ([]models.OrderStatusEvent, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить заказы пользователя: %w", err)
+		return nil, fmt.Errorf("не удалось получить заказ: %w", err)
+	}
+
+	if !canViewOrder(actor, order) {
+		return nil, ErrForbidden
 	}
-	return orders, nil
+
+	history, err := s.eventRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю заказа: %w", err)
+	}
+	return history, nil
 }
 
 // UpdateOrderStatusRequest представляет запрос на обновление статуса заказа.
 type UpdateOrderStatusRequest struct {
 	Status models.OrderStatus `json:"status" binding:"required"`
+	Reason *string            `json:"reason,omitempty"`
 }
 
-// UpdateOrderStatus обновляет статус заказа с валидацией.
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newStatus models.OrderStatus) error {
-	// Получение текущего заказа
-	order, err := s.orderRepo.GetByID(ctx, id)
-	if err != nil {
-		return fmt.Errorf("не удалось получить заказ: %w", err)
-	}
+// ErrCancelRequiresReason возвращается при попытке отменить заказ через смену статуса.
+var ErrCancelRequiresReason = errors.New("для отмены заказа используйте POST /orders/:id/cancel с указанием причины")
+
+// UpdateOrderStatus обновляет статус заказа с валидацией перехода и прав участника.
+// Отмена выполняется только через CancelOrder, чтобы у нее всегда была причина.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, req UpdateOrderStatusRequest, actor models.Actor) error {
+	if req.Status == models.OrderStatusCancelled {
+		return ErrCancelRequiresReason
+	}
+
+	return s.changeStatus(ctx, id, statusChange{
+		status: req.Status,
+		actor:  actor,
+		reason: req.Reason,
+		guard: func(order *models.Order) error {
+			if order.AwaitingApproval && req.Status == models.OrderStatusConfirmed {
+				return ErrAwaitingApproval
+			}
+			return nil
+		},
+	})
+}
 
-	// Валидация перехода состояния
-	if !isValidStateTransition(order.Status, newStatus) {
-		return fmt.Errorf("недопустимый переход состояния из %s в %s", order.Status, newStatus)
-	}
+// statusChange описывает переход статуса, выполняемый changeStatus.
+type statusChange struct {
+	status       models.OrderStatus
+	actor        models.Actor
+	reason       *string
+	cancellation *models.CancellationReason
+	// authorize заменяет проверку прав по роли для особых сценариев.
+	authorize func(order *models.Order) bool
+	// guard выполняет дополнительные проверки над заблокированным заказом.
+	guard func(order *models.Order) error
+	// apply выполняет дополнительные изменения в той же транзакции после смены статуса.
+	apply func(ctx context.Context, order *models.Order) error
+}
 
-	// Обновление статуса
-	if err := s.orderRepo.UpdateStatus(ctx, id, newStatus); err != nil {
-		return fmt.Errorf("не удалось обновить статус заказа: %w", err)
+// changeStatus переводит заказ в новый статус в одной транзакции: блокирует
+// заказ, проверяет права и допустимость перехода, пишет историю, а при отмене
+// возвращает остатки и закрывает оплату. Уведомления отправляются после коммита.
+func (s *OrderService) changeStatus(ctx context.Context, id uuid.UUID, change statusChange) error {
+	var order *models.Order
+	var event *models.OrderStatusEvent
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Получение текущего заказа с блокировкой, чтобы параллельные
+		// переходы не вернули остатки дважды
+		var err error
+		order, err = s.lockOrder(ctx, id)
+		if err != nil {
+			return fmt.Errorf("не удалось получить заказ: %w", err)
+		}
+
+		allowed := canChangeStatus(change.actor, order, change.status)
+		if change.authorize != nil {
+			allowed = change.authorize(order)
+		}
+		if !allowed {
+			return ErrForbidden
+		}
+
+		if change.guard != nil {
+			if err := change.guard(order); err != nil {
+				return err
+			}
+		}
+
+		// Валидация перехода состояния
+		if !isValidStateTransition(order.Status, change.status) {
+			return fmt.Errorf("недопустимый переход состояния из %s в %s", order.Status, change.status)
+		}
+
+		// Обновление статуса
+		cancelled := change.status == models.OrderStatusCancelled
+		if cancelled {
+			if change.cancellation == nil {
+				return ErrCancelRequiresReason
+			}
+			if err := s.orderRepo.Cancel(ctx, id, *change.cancellation, change.reason); err != nil {
+				return fmt.Errorf("не удалось отменить заказ: %w", err)
+			}
+			order.CancellationReason = change.cancellation
+			order.CancellationComment = change.reason
+		} else if err := s.orderRepo.UpdateStatus(ctx, id, change.status); err != nil {
+			return fmt.Errorf("не удалось обновить статус заказа: %w", err)
+		}
+
+		previous := order.Status
+		event, err = s.recordStatusEvent(ctx, id, &previous, change.status, change.actor, change.cancellation, change.reason)
+		if err != nil {
+			return err
+		}
+		order.Status = change.status
+
+		if change.apply != nil {
+			if err := change.apply(ctx, order); err != nil {
+				return err
+			}
+		}
+
+		if cancelled {
+			if err := s.releaseStock(ctx, id); err != nil {
+				return err
+			}
+			if err := s.releaseSlot(ctx, id); err != nil {
+				return err
+			}
+			if err := s.cancelPayment(ctx, id); err != nil {
+				return err
+			}
+			if order.CheckoutGroupID != nil {
+				return s.syncCheckout(ctx, *order.CheckoutGroupID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if newStatus == models.OrderStatusCancelled && s.notifier != nil {
+	s.publishEvent(ctx, order, event)
+
+	if order.Status == models.OrderStatusCancelled && s.notifier != nil {
 		itemsText := s.buildItemsText(ctx, order.ID)
 		notifyCtx := observability.WithOrderMessageMeta(ctx, observability.OrderMessageMeta{
 			Customer: buildCustomerTextFromOrder(order),
@@ -433,6 +885,10 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newS
 // isValidStateTransition валидирует, разрешен ли переход состояния.
 func isValidStateTransition(current, next models.OrderStatus) bool {
 	validTransitions := map[models.OrderStatus][]models.OrderStatus{
+		models.OrderStatusScheduled: {
+			models.OrderStatusNew,
+			models.OrderStatusCancelled,
+		},
 		models.OrderStatusNew: {
 			models.OrderStatusNeedsConfirmation,
 			models.OrderStatusCancelled,
@@ -0,0 +1,173 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"Laman/internal/observability"
+
+	"go.uber.org/zap"
+)
+
+// staleBatchSize ограничивает число заказов, обрабатываемых за один проход.
+const staleBatchSize = 100
+
+// staleStatuses перечисляет статусы, в которых заказ ждет подтверждения магазином.
+var staleStatuses = []models.OrderStatus{
+	models.OrderStatusNew,
+	models.OrderStatusNeedsConfirmation,
+}
+
+// errNotStale означает, что заказ подтвердили или отменили параллельно.
+var errNotStale = errors.New("заказ больше не ожидает подтверждения")
+
+// EscalateStaleOrders отправляет напоминание по заказам, которые ждут
+// подтверждения магазином дольше after. По каждому заказу напоминание
+// отправляется один раз: заказ отмечается эскалированным атомарно, поэтому
+// при нескольких репликах его получает только одна.
+func (s *OrderService) EscalateStaleOrders(ctx context.Context, now time.Time, after time.Duration) (int, error) {
+	orders, err := s.orderRepo.ClaimForEscalation(ctx, staleStatuses, now.Add(-after), staleBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось выбрать заказы для эскалации: %w", err)
+	}
+
+	for i := range orders {
+		order := &orders[i]
+		if s.logger != nil {
+			s.logger.Warn("Магазин не подтверждает заказ",
+				zap.String("order_id", order.ID.String()),
+				zap.String("store_id", order.StoreID.String()),
+				zap.String("status", string(order.Status)))
+		}
+		if s.notifier == nil {
+			continue
+		}
+
+		notifyCtx := observability.WithOrderMessageMeta(ctx, observability.OrderMessageMeta{
+			Customer: buildCustomerTextFromOrder(order),
+			Phone:    buildPhoneTextFromOrder(order),
+			Comment:  buildCommentTextFromOrder(order),
+			Address:  buildAddressTextFromOrder(order),
+			Items:    s.buildItemsText(ctx, order.ID),
+		})
+		if err := s.notifier.NotifyOrderEscalated(notifyCtx, order, after); err != nil && s.logger != nil {
+			s.logger.Warn("Не удалось отправить эскалацию в Telegram", zap.Error(err))
+		}
+	}
+	return len(orders), nil
+}
+
+// CancelStaleOrders отменяет заказы, которые ждут подтверждения магазином
+// дольше after, с причиной STORE_TIMEOUT от имени системы. Отмена идет через
+// changeStatus: переход проверяется isValidStateTransition, остатки и оплата
+// возвращаются, уведомление об отмене отправляется как обычно. Заказ
+// блокируется перед отменой, поэтому параллельные реплики не отменят его дважды.
+// Ошибка отдельного заказа записывается в лог и не прерывает проход.
+func (s *OrderService) CancelStaleOrders(ctx context.Context, now time.Time, after time.Duration) (int, error) {
+	ids, err := s.orderRepo.GetStale(ctx, staleStatuses, now.Add(-after), staleBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось выбрать просроченные заказы: %w", err)
+	}
+
+	reason := models.CancellationReasonStoreTimeout
+	comment := fmt.Sprintf("Заказ не подтвержден за %d мин", int(after.Minutes()))
+
+	cancelled := 0
+	for _, id := range ids {
+		err := s.changeStatus(ctx, id, statusChange{
+			status:       models.OrderStatusCancelled,
+			actor:        systemActor,
+			reason:       &comment,
+			cancellation: &reason,
+			authorize:    func(*models.Order) bool { return true },
+			guard: func(order *models.Order) error {
+				if !containsStatus(staleStatuses, order.Status) || order.AwaitingApproval {
+					return errNotStale
+				}
+				return nil
+			},
+		})
+		if errors.Is(err, errNotStale) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return cancelled, ctx.Err()
+			}
+			// Сбойный заказ выбирается первым в каждом проходе, поэтому
+			// прерывать на нем проход нельзя: остальные не отменились бы никогда
+			if s.logger != nil {
+				s.logger.Warn("Не удалось отменить просроченный заказ",
+					zap.String("order_id", id.String()), zap.Error(err))
+			}
+			continue
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+// StaleOrderWorker периодически эскалирует и отменяет заказы,
+// которые магазин не подтверждает. Нулевой порог отключает шаг.
+type StaleOrderWorker struct {
+	service       *OrderService
+	interval      time.Duration
+	escalateAfter time.Duration
+	cancelAfter   time.Duration
+	logger        *zap.Logger
+}
+
+// NewStaleOrderWorker создает обработчик просроченных заказов.
+func NewStaleOrderWorker(service *OrderService, interval, escalateAfter, cancelAfter time.Duration, logger *zap.Logger) *StaleOrderWorker {
+	return &StaleOrderWorker{
+		service:       service,
+		interval:      interval,
+		escalateAfter: escalateAfter,
+		cancelAfter:   cancelAfter,
+		logger:        logger,
+	}
+}
+
+// Run выполняет проходы обработчика, пока не будет отменен ctx.
+func (w *StaleOrderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *StaleOrderWorker) tick(ctx context.Context) {
+	now := time.Now()
+
+	// Сначала отмена: заказ, который уже пора отменить, не нужно эскалировать
+	if w.cancelAfter > 0 {
+		cancelled, err := w.service.CancelStaleOrders(ctx, now, w.cancelAfter)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("Не удалось отменить просроченные заказы", zap.Error(err))
+		}
+		if cancelled > 0 {
+			w.logger.Info("Просроченные заказы отменены", zap.Int("count", cancelled))
+		}
+	}
+
+	if w.escalateAfter > 0 {
+		escalated, err := w.service.EscalateStaleOrders(ctx, now, w.escalateAfter)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("Не удалось эскалировать заказы", zap.Error(err))
+		}
+		if escalated > 0 {
+			w.logger.Info("Заказы эскалированы", zap.Int("count", escalated))
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// templateBatchSize ограничивает число шаблонов, повторяемых за один проход.
+const templateBatchSize = 50
+
+// ErrInvalidCadence возвращается при неизвестной периодичности шаблона.
+var ErrInvalidCadence = errors.New("неизвестная периодичность: ожидается WEEKLY, BIWEEKLY или MONTHLY")
+
+// OrderTemplateRequest представляет запрос на создание или замену шаблона заказа.
+// Товары задаются списком items или копируются из заказа order_id.
+// Если указана периодичность cadence, заказ по шаблону создается
+// автоматически, начиная с start_at (по умолчанию — через один период).
+type OrderTemplateRequest struct {
+	Name            string                   `json:"name" binding:"required,max=255"`
+	OrderID         *uuid.UUID               `json:"order_id,omitempty"`
+	Items           []CreateOrderItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+	PaymentMethod   models.PaymentMethod     `json:"payment_method" binding:"required"`
+	DeliveryAddress string                   `json:"delivery_address" binding:"required"`
+	Distance        *float64                 `json:"distance,omitempty" binding:"omitempty,gte=0"`
+	Comment         *string                  `json:"comment,omitempty"`
+	Cadence         *models.TemplateCadence  `json:"cadence,omitempty"`
+	StartAt         *time.Time               `json:"start_at,omitempty"`
+}
+
+// CreateTemplate сохраняет шаблон заказа покупателя.
+func (s *OrderService) CreateTemplate(ctx context.Context, req OrderTemplateRequest, actor models.Actor) (*models.OrderTemplate, error) {
+	if !canManageTemplates(actor) {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now()
+	template := &models.OrderTemplate{
+		ID:        uuid.New(),
+		UserID:    *actor.UserID,
+		CreatedAt: now,
+	}
+	if err := s.fillTemplate(ctx, template, req, actor, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		if errors.Is(err, ErrTemplateNameTaken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось сохранить шаблон: %w", err)
+	}
+	return template, nil
+}
+
+// GetTemplates получает шаблоны покупателя.
+func (s *OrderService) GetTemplates(ctx context.Context, actor models.Actor) ([]models.OrderTemplate, error) {
+	if !canManageTemplates(actor) {
+		return nil, ErrForbidden
+	}
+
+	templates, err := s.templateRepo.GetByUserID(ctx, *actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить шаблоны: %w", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate получает шаблон покупателя по ID.
+func (s *OrderService) GetTemplate(ctx context.Context, id uuid.UUID, actor models.Actor) (*models.OrderTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageTemplate(actor, template) {
+		return nil, ErrForbidden
+	}
+	return template, nil
+}
+
+// UpdateTemplate заменяет шаблон покупателя, в том числе товары и расписание.
+func (s *OrderService) UpdateTemplate(ctx context.Context, id uuid.UUID, req OrderTemplateRequest, actor models.Actor) (*models.OrderTemplate, error) {
+	var template *models.OrderTemplate
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		template, err = s.GetTemplate(ctx, id, actor)
+		if err != nil {
+			return err
+		}
+		if err := s.fillTemplate(ctx, template, req, actor, time.Now()); err != nil {
+			return err
+		}
+		return s.templateRepo.Update(ctx, template)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTemplate удаляет шаблон покупателя.
+func (s *OrderService) DeleteTemplate(ctx context.Context, id uuid.UUID, actor models.Actor) error {
+	if _, err := s.GetTemplate(ctx, id, actor); err != nil {
+		return err
+	}
+	return s.templateRepo.Delete(ctx, id)
+}
+
+// OrderFromTemplate собирает заказ по шаблону так же, как повтор заказа.
+func (s *OrderService) OrderFromTemplate(ctx context.Context, id uuid.UUID, req ReorderRequest, actor models.Actor) (*ReorderResult, error) {
+	template, err := s.GetTemplate(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.rebuildOrder(ctx, applyReorderRequest(templateOrderRequest(template), req), templateSources(template), req.Place)
+}
+
+// RunDueTemplates создает заказы по шаблонам, время повтора которых наступило.
+// Шаблоны блокируются с SKIP LOCKED, и следующий повтор переносится в той же
+// транзакции, поэтому при нескольких репликах каждый повтор выполняется один раз.
+// Ошибка создания заказа сохраняется в шаблоне и не останавливает остальные.
+func (s *OrderService) RunDueTemplates(ctx context.Context, now time.Time) (int, error) {
+	var due []models.OrderTemplate
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		due, err = s.templateRepo.ClaimDue(ctx, now, templateBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, template := range due {
+			next := nextTemplateRun(template, now)
+			if err := s.templateRepo.UpdateSchedule(ctx, template.ID, next); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось выбрать шаблоны для повтора: %w", err)
+	}
+
+	placed := 0
+	for i := range due {
+		template := &due[i]
+		var orderID *uuid.UUID
+		var runErr *string
+
+		result, err := s.rebuildOrder(ctx, templateOrderRequest(template), templateSources(template), true)
+		if err != nil {
+			message := err.Error()
+			runErr = &message
+			if s.logger != nil {
+				s.logger.Warn("Не удалось создать заказ по шаблону",
+					zap.String("template_id", template.ID.String()), zap.Error(err))
+			}
+		} else {
+			orderID = &result.Order.ID
+			placed++
+		}
+
+		if err := s.templateRepo.RecordRun(ctx, template.ID, now, orderID, runErr); err != nil {
+			return placed, fmt.Errorf("не удалось сохранить результат повтора: %w", err)
+		}
+	}
+	return placed, nil
+}
+
+// fillTemplate переносит запрос в шаблон: собирает товары с текущими ценами
+// и рассчитывает ближайший повтор.
+func (s *OrderService) fillTemplate(ctx context.Context, template *models.OrderTemplate, req OrderTemplateRequest, actor models.Actor, now time.Time) error {
+	var sources []reorderSource
+	switch {
+	case req.OrderID != nil && len(req.Items) > 0:
+		return errors.New("укажите либо order_id, либо items")
+	case req.OrderID != nil:
+		order, err := s.orderRepo.GetByID(ctx, *req.OrderID)
+		if err != nil {
+			return err
+		}
+		if !canReorder(actor, order) {
+			return ErrForbidden
+		}
+		items, err := s.orderItemRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("не удалось получить товары заказа: %w", err)
+		}
+		sources = orderSources(items)
+	case len(req.Items) > 0:
+		var err error
+		sources, err = s.catalogSources(ctx, req.Items)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("шаблон не содержит товаров")
+	}
+
+	if len(sources) == 0 {
+		return errors.New("шаблон не содержит товаров")
+	}
+
+	template.Name = req.Name
+	template.PaymentMethod = req.PaymentMethod
+	template.DeliveryAddress = req.DeliveryAddress
+	template.Distance = req.Distance
+	template.Comment = req.Comment
+	template.UpdatedAt = now
+	template.Items = make([]models.OrderTemplateItem, 0, len(sources))
+	for _, source := range sources {
+		template.Items = append(template.Items, models.OrderTemplateItem{
+			TemplateID: template.ID,
+			ProductID:  source.productID,
+			Quantity:   source.quantity,
+			Price:      source.price,
+		})
+	}
+
+	template.Cadence = nil
+	template.NextRunAt = nil
+	if req.Cadence == nil {
+		return nil
+	}
+	if !req.Cadence.Valid() {
+		return ErrInvalidCadence
+	}
+
+	next := req.Cadence.Next(now)
+	if req.StartAt != nil {
+		if !req.StartAt.After(now) {
+			return errors.New("начало повторов должно быть в будущем")
+		}
+		next = *req.StartAt
+	}
+	template.Cadence = req.Cadence
+	template.NextRunAt = &next
+	return nil
+}
+
+// catalogSources проверяет товары шаблона по каталогу и фиксирует текущие цены.
+// Товары шаблона должны относиться к одному магазину.
+func (s *OrderService) catalogSources(ctx context.Context, items []CreateOrderItemRequest) ([]reorderSource, error) {
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	productMap := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	var storeID *uuid.UUID
+	sources := make([]reorderSource, 0, len(items))
+	index := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		product, ok := productMap[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("товар не найден: %s", item.ProductID)
+		}
+		if storeID == nil {
+			storeID = &product.StoreID
+		} else if *storeID != product.StoreID {
+			return nil, errors.New("шаблон может содержать товары только одного магазина")
+		}
+
+		if i, ok := index[item.ProductID]; ok {
+			sources[i].quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(sources)
+		sources = append(sources, reorderSource{
+			productID: product.ID,
+			quantity:  item.Quantity,
+			price:     product.Price,
+		})
+	}
+	return sources, nil
+}
+
+// templateOrderRequest собирает запрос на создание заказа из полей шаблона.
+func templateOrderRequest(template *models.OrderTemplate) CreateOrderRequest {
+	userID := template.UserID
+	return CreateOrderRequest{
+		UserID:          &userID,
+		Comment:         template.Comment,
+		PaymentMethod:   template.PaymentMethod,
+		DeliveryAddress: template.DeliveryAddress,
+		Distance:        template.Distance,
+	}
+}
+
+// templateSources возвращает товары шаблона с ценами на момент сохранения.
+func templateSources(template *models.OrderTemplate) []reorderSource {
+	sources := make([]reorderSource, 0, len(template.Items))
+	for _, item := range template.Items {
+		sources = append(sources, reorderSource{
+			productID: item.ProductID,
+			quantity:  item.Quantity,
+			price:     item.Price,
+		})
+	}
+	return sources
+}
+
+// nextTemplateRun возвращает первый повтор после now, пропуская
+// повторы, пропущенные во время простоя.
+func nextTemplateRun(template models.OrderTemplate, now time.Time) *time.Time {
+	if template.Cadence == nil || template.NextRunAt == nil {
+		return nil
+	}
+	next := template.Cadence.Next(*template.NextRunAt)
+	for !next.After(now) {
+		next = template.Cadence.Next(next)
+	}
+	return &next
+}
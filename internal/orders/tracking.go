@@ -0,0 +1,397 @@
+package orders
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// trackingAudience отличает ссылки отслеживания от токенов авторизации.
+const trackingAudience = "order-tracking"
+
+// checkoutSubjectPrefix отличает ссылки чекаута от ссылок заказа, чтобы
+// одну нельзя было использовать вместо другой.
+const checkoutSubjectPrefix = "checkout:"
+
+// ErrInvalidTrackingToken возвращается для поддельной или истекшей ссылки отслеживания.
+var ErrInvalidTrackingToken = errors.New("ссылка отслеживания недействительна или истекла")
+
+// TrackingSigner выпускает и проверяет подписанные ссылки отслеживания заказа.
+// Ключ подписи выводится из секрета приложения, поэтому ссылку нельзя
+// использовать как токен авторизации и наоборот.
+type TrackingSigner struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewTrackingSigner создает подписчик ссылок отслеживания со сроком действия ttl.
+func NewTrackingSigner(secret string, ttl time.Duration) *TrackingSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(trackingAudience))
+	return &TrackingSigner{key: mac.Sum(nil), ttl: ttl}
+}
+
+// Issue выпускает ссылку отслеживания заказа.
+func (s *TrackingSigner) Issue(orderID uuid.UUID, now time.Time) (*models.TrackingLink, error) {
+	return s.issue(orderID.String(), now)
+}
+
+// IssueCheckout выпускает ссылку отслеживания чекаута.
+func (s *TrackingSigner) IssueCheckout(groupID uuid.UUID, now time.Time) (*models.TrackingLink, error) {
+	return s.issue(checkoutSubjectPrefix+groupID.String(), now)
+}
+
+func (s *TrackingSigner) issue(subject string, now time.Time) (*models.TrackingLink, error) {
+	expiresAt := now.Add(s.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{trackingAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TrackingLink{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Parse проверяет подпись и срок действия ссылки и возвращает ID заказа.
+// Ссылка чекаута здесь недействительна.
+func (s *TrackingSigner) Parse(token string) (uuid.UUID, error) {
+	subject, err := s.parse(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	orderID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	return orderID, nil
+}
+
+// ParseCheckout проверяет ссылку отслеживания чекаута и возвращает ID чекаута.
+func (s *TrackingSigner) ParseCheckout(token string) (uuid.UUID, error) {
+	subject, err := s.parse(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	value, ok := strings.CutPrefix(subject, checkoutSubjectPrefix)
+	if !ok {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	groupID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	return groupID, nil
+}
+
+// parse проверяет подпись и срок действия ссылки и возвращает ее subject.
+func (s *TrackingSigner) parse(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(trackingAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", ErrInvalidTrackingToken
+	}
+	return claims.Subject, nil
+}
+
+// TrackingView представляет заказ для публичной страницы отслеживания.
+// Содержит только то, что нужно получателю: без полного ID заказа,
+// адреса, телефона и участников смены статусов.
+type TrackingView struct {
+	Number      string                 `json:"number"`
+	Status      models.OrderStatus     `json:"status"`
+	Customer    string                 `json:"customer,omitempty"`
+	Phone       string                 `json:"phone,omitempty"`
+	Items       []TrackingItem         `json:"items"`
+	ItemsTotal  models.Money           `json:"items_total"`
+	ServiceFee  models.Money           `json:"service_fee"`
+	DeliveryFee models.Money           `json:"delivery_fee"`
+	FinalTotal  models.Money           `json:"final_total"`
+	ETA         *TrackingETA           `json:"eta,omitempty"`
+	History     []TrackingStatusChange `json:"history"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// TrackingItem представляет позицию заказа на странице отслеживания.
+type TrackingItem struct {
+	Name     string                 `json:"name"`
+	Quantity int                    `json:"quantity"`
+	Price    models.Money           `json:"price"`
+	Status   models.OrderItemStatus `json:"status"`
+}
+
+// TrackingETA представляет ожидаемое время доставки: интервал слота
+// или оценку для заказа «как можно скорее».
+type TrackingETA struct {
+	From      *time.Time `json:"from,omitempty"`
+	To        time.Time  `json:"to"`
+	Estimated bool       `json:"estimated"`
+}
+
+// TrackingStatusChange представляет переход статуса без данных об участнике.
+type TrackingStatusChange struct {
+	Status models.OrderStatus `json:"status"`
+	At     time.Time          `json:"at"`
+}
+
+// TrackOrder возвращает публичное представление заказа по ссылке отслеживания.
+func (s *OrderService) TrackOrder(ctx context.Context, token string) (*TrackingView, error) {
+	if s.tracking == nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	orderID, err := s.tracking.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	return s.trackingView(ctx, order)
+}
+
+// CheckoutTrackingView представляет чекаут для публичной страницы отслеживания:
+// сводный статус и суммы, а подзаказы — в том же виде, что и по ссылке заказа.
+type CheckoutTrackingView struct {
+	Status      models.OrderStatus `json:"status"`
+	ItemsTotal  models.Money       `json:"items_total"`
+	ServiceFee  models.Money       `json:"service_fee"`
+	DeliveryFee models.Money       `json:"delivery_fee"`
+	FinalTotal  models.Money       `json:"final_total"`
+	Orders      []TrackingView     `json:"orders"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// TrackCheckout возвращает публичное представление чекаута по ссылке отслеживания.
+func (s *OrderService) TrackCheckout(ctx context.Context, token string) (*CheckoutTrackingView, error) {
+	if s.tracking == nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	groupID, err := s.tracking.ParseCheckout(token)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.checkoutRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	orders, err := s.orderRepo.GetByCheckoutGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить подзаказы: %w", err)
+	}
+
+	view := &CheckoutTrackingView{
+		Status:      checkoutStatus(orders),
+		ItemsTotal:  group.ItemsTotal,
+		ServiceFee:  group.ServiceFee,
+		DeliveryFee: group.DeliveryFee,
+		FinalTotal:  group.FinalTotal,
+		Orders:      make([]TrackingView, 0, len(orders)),
+		CreatedAt:   group.CreatedAt,
+	}
+	for i := range orders {
+		orderView, err := s.trackingView(ctx, &orders[i])
+		if err != nil {
+			return nil, err
+		}
+		view.Orders = append(view.Orders, *orderView)
+	}
+	return view, nil
+}
+
+// trackingView собирает публичное представление заказа.
+func (s *OrderService) trackingView(ctx context.Context, order *models.Order) (*TrackingView, error) {
+	orderID := order.ID
+	items, err := s.orderItemRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары заказа: %w", err)
+	}
+	delivery, err := s.deliveryRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить доставку: %w", err)
+	}
+	history, err := s.eventRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю статусов: %w", err)
+	}
+
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	names := make(map[uuid.UUID]string, len(products))
+	for _, product := range products {
+		names[product.ID] = product.Name
+	}
+
+	view := &TrackingView{
+		Number:      shortUUID(order.ID),
+		Status:      order.Status,
+		Items:       make([]TrackingItem, 0, len(items)),
+		ItemsTotal:  order.ItemsTotal,
+		ServiceFee:  order.ServiceFee,
+		DeliveryFee: order.DeliveryFee,
+		FinalTotal:  order.FinalTotal,
+		ETA:         s.trackingETA(order, delivery, history),
+		History:     make([]TrackingStatusChange, 0, len(history)),
+		CreatedAt:   order.CreatedAt,
+	}
+	if order.GuestName != nil {
+		view.Customer = firstName(*order.GuestName)
+	}
+	if order.GuestPhone != nil {
+		view.Phone = maskPhone(*order.GuestPhone)
+	}
+	for _, item := range items {
+		view.Items = append(view.Items, TrackingItem{
+			Name:     names[item.ProductID],
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Status:   item.Status,
+		})
+	}
+	for _, event := range history {
+		view.History = append(view.History, TrackingStatusChange{Status: event.ToStatus, At: event.CreatedAt})
+	}
+	return view, nil
+}
+
+// trackingETA возвращает интервал выбранного слота или оценку: время
+// попадания заказа в очередь плюс типовое время доставки. Для завершенных
+// заказов ETA не показывается.
+func (s *OrderService) trackingETA(order *models.Order, delivery *models.Delivery, history []models.OrderStatusEvent) *TrackingETA {
+	if order.Status == models.OrderStatusDelivered || order.Status == models.OrderStatusCancelled {
+		return nil
+	}
+	if delivery.SlotStart != nil && delivery.SlotEnd != nil {
+		return &TrackingETA{From: delivery.SlotStart, To: *delivery.SlotEnd}
+	}
+	if s.deliveryETA <= 0 {
+		return nil
+	}
+
+	queuedAt := order.CreatedAt
+	for _, event := range history {
+		if event.ToStatus == models.OrderStatusNew {
+			queuedAt = event.CreatedAt
+		}
+	}
+	return &TrackingETA{To: queuedAt.Add(s.deliveryETA), Estimated: true}
+}
+
+// issueTracking добавляет к созданному заказу ссылку отслеживания.
+// Ошибка подписи не мешает оформлению: заказ уже создан.
+func (s *OrderService) issueTracking(result *models.OrderWithItems) {
+	if s.tracking == nil || result == nil {
+		return
+	}
+	link, err := s.tracking.Issue(result.ID, time.Now())
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Warn("Не удалось выпустить ссылку отслеживания", zap.Error(err))
+		}
+		return
+	}
+	result.Tracking = link
+}
+
+// issueCheckoutTracking добавляет к созданному чекауту ссылку отслеживания.
+func (s *OrderService) issueCheckoutTracking(result *models.CheckoutGroupWithOrders) {
+	if s.tracking == nil || result == nil {
+		return
+	}
+	link, err := s.tracking.IssueCheckout(result.ID, time.Now())
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Warn("Не удалось выпустить ссылку отслеживания чекаута", zap.Error(err))
+		}
+		return
+	}
+	result.Tracking = link
+}
+
+// ClaimGuestOrders привязывает к пользователю гостевые заказы, оформленные
+// на его подтвержденный телефон, вместе с их чекаутами. Возвращает число
+// привязанных заказов.
+func (s *OrderService) ClaimGuestOrders(ctx context.Context, userID uuid.UUID, phone string) (int, error) {
+	digits := normalizePhone(phone)
+	if digits == "" {
+		return 0, nil
+	}
+
+	var claimed int
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		claimed, err = s.orderRepo.ClaimGuestOrders(ctx, userID, digits)
+		if err != nil {
+			return err
+		}
+		if claimed == 0 {
+			return nil
+		}
+		return s.checkoutRepo.ClaimForUser(ctx, userID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось привязать гостевые заказы: %w", err)
+	}
+	return claimed, nil
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// normalizePhone оставляет в номере только цифры и приводит российский
+// номер с 8 в начале к формату с 7, как при поиске гостевых заказов.
+func normalizePhone(phone string) string {
+	digits := nonDigits.ReplaceAllString(phone, "")
+	if len(digits) == 11 && strings.HasPrefix(digits, "8") {
+		digits = "7" + digits[1:]
+	}
+	return digits
+}
+
+// maskPhone скрывает номер, оставляя две последние цифры.
+func maskPhone(phone string) string {
+	digits := nonDigits.ReplaceAllString(phone, "")
+	if len(digits) <= 2 {
+		return "***"
+	}
+	return "***" + digits[len(digits)-2:]
+}
+
+// firstName оставляет от имени получателя только первое слово.
+func firstName(name string) string {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -24,14 +24,14 @@ func (r *postgresPaymentRepository) Create(ctx context.Context, payment *models.
 		INSERT INTO payments (id, order_id, method, status, amount, created_at, updated_at)
 		VALUES (:id, :order_id, :method, :status, :amount, :created_at, :updated_at)
 	`
-	_, err := r.db.NamedExecContext(ctx, query, payment)
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, payment)
 	return err
 }
 
 func (r *postgresPaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
 	query := `SELECT id, order_id, method, status, amount, created_at, updated_at FROM payments WHERE id = $1`
-	err := r.db.GetContext(ctx, &payment, query, id)
+	err := r.db.Conn(ctx).GetContext(ctx, &payment, query, id)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("оплата не найдена")
 	}
@@ -44,7 +44,7 @@ func (r *postgresPaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (
 func (r *postgresPaymentRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
 	query := `SELECT id, order_id, method, status, amount, created_at, updated_at FROM payments WHERE order_id = $1`
-	err := r.db.GetContext(ctx, &payment, query, orderID)
+	err := r.db.Conn(ctx).GetContext(ctx, &payment, query, orderID)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("оплата не найдена")
 	}
@@ -56,6 +56,12 @@ func (r *postgresPaymentRepository) GetByOrderID(ctx context.Context, orderID uu
 
 func (r *postgresPaymentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.PaymentStatus) error {
 	query := `UPDATE payments SET status = $1, updated_at = NOW() WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, status, id)
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, status, id)
+	return err
+}
+
+func (r *postgresPaymentRepository) UpdateAmount(ctx context.Context, id uuid.UUID, amount models.Money) error {
+	query := `UPDATE payments SET amount = $1, updated_at = NOW() WHERE id = $2`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, amount, id)
 	return err
 }
@@ -19,4 +19,7 @@ type PaymentRepository interface {
 	
 	// UpdateStatus обновляет статус оплаты.
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.PaymentStatus) error
+	
+	// UpdateAmount обновляет сумму оплаты.
+	UpdateAmount(ctx context.Context, id uuid.UUID, amount models.Money) error
 }
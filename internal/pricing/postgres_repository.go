@@ -0,0 +1,45 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// postgresRuleRepository реализует RuleRepository используя PostgreSQL.
+type postgresRuleRepository struct {
+	db *database.DB
+}
+
+// NewPostgresRuleRepository создает новый PostgreSQL репозиторий правил ценообразования.
+func NewPostgresRuleRepository(db *database.DB) RuleRepository {
+	return &postgresRuleRepository{db: db}
+}
+
+func (r *postgresRuleRepository) FindForStore(ctx context.Context, storeID uuid.UUID, categoryType models.StoreCategoryType) (*models.PricingRule, error) {
+	var rule models.PricingRule
+	query := `
+		SELECT id, name, store_id, store_category_type, service_fee_percent, delivery_fee,
+		       free_delivery_threshold, min_order_amount, weight_included_kg, weight_surcharge_per_kg,
+		       distance_included_km, distance_fee_per_km, is_active, created_at, updated_at
+		FROM pricing_rules
+		WHERE is_active
+		  AND (store_id = $1 OR store_id IS NULL)
+		  AND (store_category_type = $2 OR store_category_type IS NULL)
+		ORDER BY (store_id IS NOT NULL) DESC, (store_category_type IS NOT NULL) DESC, created_at DESC
+		LIMIT 1
+	`
+	err := r.db.Conn(ctx).GetContext(ctx, &rule, query, storeID, categoryType)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrRuleNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
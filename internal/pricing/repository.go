@@ -0,0 +1,22 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRuleNotFound        = errors.New("pricing rule not found")
+	ErrBelowMinOrderAmount = errors.New("минимальная сумма заказа в этом магазине")
+)
+
+// RuleRepository определяет интерфейс для доступа к правилам ценообразования.
+type RuleRepository interface {
+	// FindForStore получает наиболее специфичное активное правило:
+	// сначала правило магазина, затем правило типа магазина, затем правило по умолчанию.
+	FindForStore(ctx context.Context, storeID uuid.UUID, categoryType models.StoreCategoryType) (*models.PricingRule, error)
+}
@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PricingService рассчитывает сервисный сбор и стоимость доставки заказа
+// по правилам магазина, типа магазина или правилу по умолчанию.
+type PricingService struct {
+	ruleRepo  RuleRepository
+	storeRepo StoreRepository
+}
+
+// StoreRepository определяет интерфейс, необходимый из модуля catalog.
+type StoreRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Store, error)
+}
+
+// NewPricingService создает новый сервис ценообразования.
+func NewPricingService(ruleRepo RuleRepository, storeRepo StoreRepository) *PricingService {
+	return &PricingService{
+		ruleRepo:  ruleRepo,
+		storeRepo: storeRepo,
+	}
+}
+
+// Input содержит параметры заказа, влияющие на расчет сборов.
+type Input struct {
+	StoreID     uuid.UUID
+	ItemsTotal  models.Money
+	TotalWeight float64  // кг
+	Distance    *float64 // км, если известно
+}
+
+// Quote представляет результат расчета с разбивкой стоимости доставки.
+type Quote struct {
+	RuleID            uuid.UUID    `json:"rule_id"`
+	RuleName          string       `json:"rule_name"`
+	ServiceFeePercent float64      `json:"service_fee_percent"`
+	ServiceFee        models.Money `json:"service_fee"`
+	BaseDeliveryFee   models.Money `json:"base_delivery_fee"`
+	WeightSurcharge   models.Money `json:"weight_surcharge"`
+	DistanceFee       models.Money `json:"distance_fee"`
+	DeliveryFee       models.Money `json:"delivery_fee"`
+	FreeDelivery      bool         `json:"free_delivery"`
+	FinalTotal        models.Money `json:"final_total"`
+}
+
+// Calculate подбирает правило для магазина и рассчитывает сборы.
+// Бесплатная доставка отменяет базовую стоимость и доплату за расстояние,
+// доплата за вес сохраняется.
+func (s *PricingService) Calculate(ctx context.Context, in Input) (*Quote, error) {
+	store, err := s.storeRepo.GetByID(ctx, in.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить магазин: %w", err)
+	}
+
+	rule, err := s.ruleRepo.FindForStore(ctx, store.ID, store.CategoryType)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подобрать правило ценообразования: %w", err)
+	}
+
+	if rule.MinOrderAmount != nil && in.ItemsTotal < *rule.MinOrderAmount {
+		return nil, fmt.Errorf("%w: %s", ErrBelowMinOrderAmount, *rule.MinOrderAmount)
+	}
+
+	quote := &Quote{
+		RuleID:            rule.ID,
+		RuleName:          rule.Name,
+		ServiceFeePercent: rule.ServiceFeePercent,
+		ServiceFee:        in.ItemsTotal.MulPercent(rule.ServiceFeePercent),
+		BaseDeliveryFee:   rule.DeliveryFee,
+	}
+
+	if rule.WeightSurchargePerKg != nil && in.TotalWeight > valueOrZero(rule.WeightIncludedKg) {
+		extra := in.TotalWeight - valueOrZero(rule.WeightIncludedKg)
+		quote.WeightSurcharge = rule.WeightSurchargePerKg.MulFloat(extra)
+	}
+
+	if rule.DistanceFeePerKm != nil && in.Distance != nil && *in.Distance > valueOrZero(rule.DistanceIncludedKm) {
+		extra := *in.Distance - valueOrZero(rule.DistanceIncludedKm)
+		quote.DistanceFee = rule.DistanceFeePerKm.MulFloat(extra)
+	}
+
+	if rule.FreeDeliveryThreshold != nil && in.ItemsTotal >= *rule.FreeDeliveryThreshold {
+		quote.FreeDelivery = true
+		quote.BaseDeliveryFee = 0
+		quote.DistanceFee = 0
+	}
+
+	quote.DeliveryFee = quote.BaseDeliveryFee + quote.WeightSurcharge + quote.DistanceFee
+	quote.FinalTotal = in.ItemsTotal + quote.ServiceFee + quote.DeliveryFee
+
+	return quote, nil
+}
+
+func valueOrZero(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
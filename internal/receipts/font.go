@@ -0,0 +1,394 @@
+package receipts
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// receiptFontData — моноширинный шрифт с кириллицей, который встраивается в PDF.
+// Лицензия шрифта лежит в fonts/LICENSE.
+//
+//go:embed fonts/DejaVuSansMono.ttf
+var receiptFontData []byte
+
+var (
+	receiptFontOnce sync.Once
+	receiptFont     *trueTypeFont
+	receiptFontErr  error
+)
+
+// loadReceiptFont разбирает встроенный шрифт один раз за время работы процесса.
+func loadReceiptFont() (*trueTypeFont, error) {
+	receiptFontOnce.Do(func() {
+		receiptFont, receiptFontErr = parseTrueType(receiptFontData)
+	})
+	return receiptFont, receiptFontErr
+}
+
+// cmapSegment — диапазон кодов Unicode из таблицы cmap формата 4.
+type cmapSegment struct {
+	start, end    uint16
+	delta         uint16
+	rangeOffset   uint16
+	rangeOffsetAt int
+}
+
+// trueTypeFont содержит метрики TrueType шрифта, нужные для встраивания в PDF.
+type trueTypeFont struct {
+	tables     map[string][]byte
+	numGlyphs  int
+	longLoca   bool
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []uint16
+	cmap       []byte
+	segments   []cmapSegment
+}
+
+// parseTrueType читает таблицы head, hhea, hmtx и cmap (формат 4, Unicode BMP)
+// и запоминает таблицы глифов для построения подмножества шрифта.
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("шрифт поврежден")
+	}
+
+	tables := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			return nil, errors.New("шрифт поврежден")
+		}
+		tag := string(data[entry : entry+4])
+		offset := int(binary.BigEndian.Uint32(data[entry+8:]))
+		length := int(binary.BigEndian.Uint32(data[entry+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("таблица %s шрифта повреждена", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap", "maxp", "loca", "glyf"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("в шрифте нет таблицы %s", tag)
+		}
+	}
+
+	font := &trueTypeFont{tables: tables}
+
+	head := tables["head"]
+	if len(head) < 54 || len(tables["hhea"]) < 36 || len(tables["maxp"]) < 6 {
+		return nil, errors.New("шрифт поврежден")
+	}
+	font.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	font.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+
+	font.numGlyphs = int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	locaSize := 2
+	if font.longLoca {
+		locaSize = 4
+	}
+	if len(tables["loca"]) < (font.numGlyphs+1)*locaSize {
+		return nil, errors.New("таблица loca шрифта повреждена")
+	}
+
+	hhea := tables["hhea"]
+	font.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	font.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	hmtx := tables["hmtx"]
+	if len(hmtx) < metrics*4 {
+		return nil, errors.New("таблица hmtx шрифта повреждена")
+	}
+	font.advances = make([]uint16, metrics)
+	for i := range font.advances {
+		font.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	segments, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	font.cmap = tables["cmap"]
+	font.segments = segments
+	return font, nil
+}
+
+func parseCmap(cmap []byte) ([]cmapSegment, error) {
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count; i++ {
+		record := 4 + 8*i
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if !(platform == 3 && encoding == 1) && platform != 0 {
+			continue
+		}
+		if offset+4 > len(cmap) || binary.BigEndian.Uint16(cmap[offset:]) != 4 {
+			continue
+		}
+
+		table := cmap[offset:]
+		segCount := int(binary.BigEndian.Uint16(table[6:])) / 2
+		ends := 14
+		starts := ends + 2*segCount + 2
+		deltas := starts + 2*segCount
+		rangeOffsets := deltas + 2*segCount
+		if rangeOffsets+2*segCount > len(table) {
+			return nil, errors.New("таблица cmap шрифта повреждена")
+		}
+
+		segments := make([]cmapSegment, segCount)
+		for s := range segments {
+			segments[s] = cmapSegment{
+				end:           binary.BigEndian.Uint16(table[ends+2*s:]),
+				start:         binary.BigEndian.Uint16(table[starts+2*s:]),
+				delta:         binary.BigEndian.Uint16(table[deltas+2*s:]),
+				rangeOffset:   binary.BigEndian.Uint16(table[rangeOffsets+2*s:]),
+				rangeOffsetAt: offset + rangeOffsets + 2*s,
+			}
+		}
+		return segments, nil
+	}
+	return nil, errors.New("в шрифте нет таблицы символов Unicode")
+}
+
+// glyph возвращает номер глифа для символа. Символы без глифа отображаются
+// глифом 0 (.notdef).
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	if r < 0 || r > 0xFFFF {
+		return 0
+	}
+	code := uint16(r)
+	for _, seg := range f.segments {
+		if code > seg.end {
+			continue
+		}
+		if code < seg.start {
+			return 0
+		}
+		if seg.rangeOffset == 0 {
+			return code + seg.delta
+		}
+		at := seg.rangeOffsetAt + int(seg.rangeOffset) + 2*int(code-seg.start)
+		if at+2 > len(f.cmap) {
+			return 0
+		}
+		gid := binary.BigEndian.Uint16(f.cmap[at:])
+		if gid == 0 {
+			return 0
+		}
+		return gid + seg.delta
+	}
+	return 0
+}
+
+// advance возвращает ширину глифа в тысячных долях кегля, как ее ожидает PDF.
+func (f *trueTypeFont) advance(gid uint16) int {
+	width := f.advances[len(f.advances)-1]
+	if int(gid) < len(f.advances) {
+		width = f.advances[gid]
+	}
+	return int(width) * 1000 / f.unitsPerEm
+}
+
+// scale переводит величину из единиц шрифта в тысячные доли кегля.
+func (f *trueTypeFont) scale(value int) int {
+	return value * 1000 / f.unitsPerEm
+}
+
+// subsetTables — таблицы, которые остаются во встроенном шрифте. PDF берет
+// глифы по номерам через CIDToGIDMap, поэтому cmap, имена и таблицы
+// типографики не нужны.
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// subset возвращает шрифт, в котором остались только контуры глифов gids,
+// .notdef и составляющих составных глифов. Номера глифов не меняются:
+// контуры остальных глифов становятся пустыми, поэтому текст страницы
+// и ширины в /W не требуют перекодировки.
+func (f *trueTypeFont) subset(gids []uint16) ([]byte, error) {
+	keep := map[uint16]bool{0: true}
+	queue := append([]uint16{0}, gids...)
+	for len(queue) > 0 {
+		gid := queue[0]
+		queue = queue[1:]
+		keep[gid] = true
+		outline, err := f.outline(gid)
+		if err != nil {
+			return nil, err
+		}
+		for _, component := range glyphComponents(outline) {
+			if !keep[component] {
+				queue = append(queue, component)
+			}
+		}
+	}
+
+	var glyf bytes.Buffer
+	offsets := make([]int, f.numGlyphs+1)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		offsets[gid] = glyf.Len()
+		if !keep[uint16(gid)] {
+			continue
+		}
+		outline, err := f.outline(uint16(gid))
+		if err != nil {
+			return nil, err
+		}
+		glyf.Write(outline)
+		// Короткая таблица loca хранит смещения, деленные на 2
+		for glyf.Len()%4 != 0 {
+			glyf.WriteByte(0)
+		}
+	}
+	offsets[f.numGlyphs] = glyf.Len()
+
+	var loca bytes.Buffer
+	for _, offset := range offsets {
+		if f.longLoca {
+			binary.Write(&loca, binary.BigEndian, uint32(offset))
+		} else {
+			binary.Write(&loca, binary.BigEndian, uint16(offset/2))
+		}
+	}
+
+	tables := make(map[string][]byte, len(subsetTables))
+	for _, tag := range subsetTables {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	tables["glyf"] = glyf.Bytes()
+	tables["loca"] = loca.Bytes()
+	return buildTrueType(tables), nil
+}
+
+// outline возвращает контур глифа из таблицы glyf; у пустого глифа он пуст.
+func (f *trueTypeFont) outline(gid uint16) ([]byte, error) {
+	if int(gid) >= f.numGlyphs {
+		return nil, nil
+	}
+	loca := f.tables["loca"]
+	var start, end int
+	if f.longLoca {
+		start = int(binary.BigEndian.Uint32(loca[4*int(gid):]))
+		end = int(binary.BigEndian.Uint32(loca[4*int(gid)+4:]))
+	} else {
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid):]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*int(gid)+2:]))
+	}
+	if start > end || end > len(f.tables["glyf"]) {
+		return nil, fmt.Errorf("глиф %d шрифта поврежден", gid)
+	}
+	return f.tables["glyf"][start:end], nil
+}
+
+// Флаги компонентов составного глифа.
+const (
+	componentArgsAreWords = 0x0001
+	componentHasScale     = 0x0008
+	componentMore         = 0x0020
+	componentHasXYScale   = 0x0040
+	componentHas2x2       = 0x0080
+)
+
+// glyphComponents возвращает номера глифов, из которых собран составной глиф.
+func glyphComponents(outline []byte) []uint16 {
+	if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+		return nil
+	}
+
+	var components []uint16
+	for at := 10; at+4 <= len(outline); {
+		flags := binary.BigEndian.Uint16(outline[at:])
+		components = append(components, binary.BigEndian.Uint16(outline[at+2:]))
+		at += 4
+		if flags&componentArgsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&componentHasScale != 0:
+			at += 2
+		case flags&componentHasXYScale != 0:
+			at += 4
+		case flags&componentHas2x2 != 0:
+			at += 8
+		}
+		if flags&componentMore == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// buildTrueType собирает файл шрифта из таблиц с пересчитанными контрольными
+// суммами.
+func buildTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	// Поля двоичного поиска в заголовке каталога таблиц
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []uint16{
+		0x0001, 0x0000, uint16(len(tags)), uint16(searchRange), uint16(entrySelector), uint16(16*len(tags) - searchRange),
+	})
+
+	offset := 12 + 16*len(tags)
+	headAt := 0
+	for _, tag := range tags {
+		table := tables[tag]
+		if tag == "head" {
+			// checkSumAdjustment считается по файлу с обнуленным полем
+			table = append([]byte(nil), table...)
+			binary.BigEndian.PutUint32(table[8:], 0)
+			tables[tag] = table
+			headAt = offset
+		}
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{tableChecksum(table), uint32(offset), uint32(len(table))})
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+
+	data := out.Bytes()
+	binary.BigEndian.PutUint32(data[headAt+8:], 0xB1B0AFBA-tableChecksum(data))
+	return data
+}
+
+// tableChecksum считает контрольную сумму таблицы TrueType: сумму
+// 32-битных слов с дополнением нулями до целого слова.
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
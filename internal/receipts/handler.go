@@ -0,0 +1,125 @@
+package receipts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"Laman/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler обрабатывает HTTP запросы для чеков заказов.
+type Handler struct {
+	receiptService *ReceiptService
+	authService    AuthService
+	userLoader     middleware.UserLoader
+}
+
+// AuthService определяет интерфейс, необходимый из модуля auth.
+type AuthService interface {
+	ValidateToken(token string) (uuid.UUID, error)
+}
+
+// NewHandler создает новый обработчик чеков.
+func NewHandler(receiptService *ReceiptService, authService AuthService, userLoader middleware.UserLoader) *Handler {
+	return &Handler{
+		receiptService: receiptService,
+		authService:    authService,
+		userLoader:     userLoader,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты чеков.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	orders := router.Group("/orders")
+	orders.Use(middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader))
+	{
+		orders.GET("/:id/receipt", h.GetReceipt)
+	}
+
+	// Публичный маршрут: доступ дает подписанная ссылка отслеживания
+	router.GET("/track/:token/receipt", h.GetTrackedReceipt)
+}
+
+// GetReceipt обрабатывает GET /orders/:id/receipt?format=json|pdf
+// Без format формат выбирается по заголовку Accept, по умолчанию JSON.
+func (h *Handler) GetReceipt(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	format, ok := receiptFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр format"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	receipt, err := h.receiptService.GetReceipt(c.Request.Context(), orderID, actor)
+	respondReceipt(c, receipt, format, err)
+}
+
+// GetTrackedReceipt обрабатывает GET /track/:token/receipt?format=json|pdf
+// Чек заказа по ссылке отслеживания, без авторизации.
+func (h *Handler) GetTrackedReceipt(c *gin.Context) {
+	format, ok := receiptFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр format"})
+		return
+	}
+
+	receipt, err := h.receiptService.GetReceiptByTracking(c.Request.Context(), c.Param("token"))
+	respondReceipt(c, receipt, format, err)
+}
+
+// receiptFormat возвращает запрошенный формат чека. Без format формат
+// выбирается по заголовку Accept.
+func receiptFormat(c *gin.Context) (string, bool) {
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+		format = "pdf"
+	}
+	return format, format == "" || format == "json" || format == "pdf"
+}
+
+// respondReceipt отвечает чеком в запрошенном формате или ошибкой его получения.
+func respondReceipt(c *gin.Context, receipt *Receipt, format string, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrOrderNotFound), errors.Is(err, ErrInvalidTrackingToken):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrReceiptNotAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format != "pdf" {
+		c.JSON(http.StatusOK, receipt)
+		return
+	}
+
+	document, err := RenderPDF(receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, receipt.Number))
+	c.Data(http.StatusOK, "application/pdf", document)
+}
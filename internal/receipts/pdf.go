@@ -0,0 +1,350 @@
+package receipts
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"Laman/internal/models"
+)
+
+// Разметка PDF: чек печатается моноширинным шрифтом колонкой посередине листа A4.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 56
+	fontSize     = 10
+	lineHeight   = 13
+	receiptWidth = 48 // символов в строке
+)
+
+// RenderPDF отрисовывает чек в PDF документ.
+func RenderPDF(receipt *Receipt) ([]byte, error) {
+	font, err := loadReceiptFont()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить шрифт чека: %w", err)
+	}
+	return renderPDF(font, receiptLines(receipt))
+}
+
+// receiptLines раскладывает чек по строкам фиксированной ширины.
+func receiptLines(receipt *Receipt) []string {
+	separator := strings.Repeat("-", receiptWidth)
+
+	lines := centerLines(receipt.Seller.Name)
+	lines = append(lines, centerLines(receipt.Seller.Address)...)
+	if receipt.Seller.Phone != nil {
+		lines = append(lines, centerLines("Тел.: "+*receipt.Seller.Phone)...)
+	}
+	lines = append(lines,
+		"",
+		center("ТОВАРНЫЙ ЧЕК № "+receipt.Number),
+		center(receipt.IssuedAt.Format("02.01.2006 15:04")),
+		"ПРИХОД",
+		separator,
+	)
+
+	for _, item := range receipt.Items {
+		lines = append(lines, wrap(item.Name, receiptWidth)...)
+		lines = append(lines, columns(
+			fmt.Sprintf("  %d %s × %s", item.Quantity, item.Measure, item.Price),
+			"= "+item.Sum.String(),
+		))
+	}
+
+	lines = append(lines, separator, columns("ИТОГО", "= "+receipt.Total.String()))
+	for _, payment := range receipt.Payments {
+		lines = append(lines, columns(paymentTypeTitle(payment.Type), "= "+payment.Sum.String()))
+	}
+	lines = append(lines, columns("Без НДС", "= "+models.Money(0).String()))
+
+	if receipt.Client.Name != nil || receipt.Client.Phone != nil {
+		lines = append(lines, separator)
+		if receipt.Client.Name != nil {
+			lines = append(lines, wrap("Покупатель: "+*receipt.Client.Name, receiptWidth)...)
+		}
+		if receipt.Client.Phone != nil {
+			lines = append(lines, "Телефон: "+*receipt.Client.Phone)
+		}
+	}
+
+	lines = append(lines, separator)
+	lines = append(lines, centerLines("Документ не является кассовым чеком")...)
+	return lines
+}
+
+func paymentTypeTitle(paymentType PaymentType) string {
+	if paymentType == PaymentTypeCash {
+		return "НАЛИЧНЫМИ"
+	}
+	return "БЕЗНАЛИЧНЫМИ"
+}
+
+// columns выравнивает left по левому краю, а right — по правому.
+func columns(left, right string) string {
+	gap := receiptWidth - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+func center(text string) string {
+	pad := (receiptWidth - utf8.RuneCountInString(text)) / 2
+	if pad <= 0 {
+		return text
+	}
+	return strings.Repeat(" ", pad) + text
+}
+
+func centerLines(text string) []string {
+	lines := wrap(text, receiptWidth)
+	for i, line := range lines {
+		lines[i] = center(line)
+	}
+	return lines
+}
+
+// wrap разбивает текст на строки не длиннее width символов по словам;
+// слишком длинные слова режутся.
+func wrap(text string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		switch {
+		case len(line) == 0:
+			line = runes
+		case len(line)+1+len(runes) <= width:
+			line = append(append(line, ' '), runes...)
+		default:
+			lines = append(lines, string(line))
+			line = runes
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// renderPDF собирает PDF 1.4 из строк текста. Шрифт встраивается подмножеством
+// использованных глифов как CIDFontType2 с кодировкой Identity-H: текст
+// кодируется номерами глифов, а таблица ToUnicode позволяет копировать и искать
+// текст в документе.
+func renderPDF(font *trueTypeFont, lines []string) ([]byte, error) {
+	perPage := (pageHeight - 2*pageMargin) / lineHeight
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	used := make(map[uint16]rune)
+	contents := make([][]byte, len(pages))
+	for i, page := range pages {
+		contents[i] = pageContent(font, page, used)
+	}
+
+	fontFile, err := font.subset(sortedGlyphs(used))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось построить подмножество шрифта: %w", err)
+	}
+	baseFont := subsetTag(used) + "+DejaVuSansMono"
+
+	doc := &pdfDocument{}
+	const (
+		catalogID = iota + 1
+		pagesID
+		fontID
+		cidFontID
+		descriptorID
+		toUnicodeID
+		fontFileID
+		firstPageID
+	)
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+
+	doc.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	doc.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	doc.object(fontID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, cidFontID, toUnicodeID,
+	))
+	doc.object(cidFontID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+		baseFont, descriptorID, font.advance(font.glyph(' ')), glyphWidths(font, used),
+	))
+	doc.object(descriptorID, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 33 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseFont, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+		font.scale(font.ascent), font.scale(font.descent), font.scale(font.ascent), fontFileID,
+	))
+	if err := doc.stream(toUnicodeID, "", toUnicodeCMap(used)); err != nil {
+		return nil, err
+	}
+	if err := doc.stream(fontFileID, fmt.Sprintf("/Length1 %d", len(fontFile)), fontFile); err != nil {
+		return nil, err
+	}
+	for i, content := range contents {
+		pageID := firstPageID + 2*i
+		doc.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesID, pageWidth, pageHeight, fontID, pageID+1,
+		))
+		if err := doc.stream(pageID+1, "", content); err != nil {
+			return nil, err
+		}
+	}
+	return doc.bytes(catalogID), nil
+}
+
+// pageContent формирует поток команд страницы и отмечает использованные глифы.
+func pageContent(font *trueTypeFont, lines []string, used map[uint16]rune) []byte {
+	left := (pageWidth - receiptWidth*fontSize*font.advance(font.glyph(' '))/1000) / 2
+	top := pageHeight - pageMargin - fontSize
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, left, top)
+	for _, line := range lines {
+		b.WriteByte('<')
+		for _, r := range line {
+			gid := font.glyph(r)
+			if _, ok := used[gid]; !ok {
+				used[gid] = r
+			}
+			fmt.Fprintf(&b, "%04X", gid)
+		}
+		b.WriteString("> Tj T*\n")
+	}
+	b.WriteString("ET\n")
+	return b.Bytes()
+}
+
+// glyphWidths возвращает массив /W с ширинами использованных глифов.
+func glyphWidths(font *trueTypeFont, used map[uint16]rune) string {
+	gids := sortedGlyphs(used)
+	parts := make([]string, 0, len(gids))
+	for _, gid := range gids {
+		parts = append(parts, fmt.Sprintf("%d [%d]", gid, font.advance(gid)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// toUnicodeCMap сопоставляет глифы символам Unicode.
+func toUnicodeCMap(used map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	gids := sortedGlyphs(used)
+	// В одном блоке bfchar допускается не больше 100 записей
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <%04X>\n", gid, used[gid])
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// subsetTag возвращает метку подмножества шрифта из шести заглавных латинских
+// букв, как требует PDF. Метка зависит только от набора глифов.
+func subsetTag(used map[uint16]rune) string {
+	hash := crc32.NewIEEE()
+	for _, gid := range sortedGlyphs(used) {
+		binary.Write(hash, binary.BigEndian, gid)
+	}
+	sum := hash.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+func sortedGlyphs(used map[uint16]rune) []uint16 {
+	gids := make([]uint16, 0, len(used))
+	for gid := range used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+// pdfDocument накапливает объекты PDF и их смещения для таблицы xref.
+type pdfDocument struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (d *pdfDocument) start() {
+	if d.offsets == nil {
+		d.offsets = make(map[int]int)
+		// Двоичный комментарий во второй строке помечает файл как бинарный
+		d.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	}
+}
+
+func (d *pdfDocument) object(id int, body string) {
+	d.start()
+	d.offsets[id] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream добавляет объект-поток, сжатый FlateDecode.
+func (d *pdfDocument) stream(id int, extra string, data []byte) error {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	d.start()
+	d.offsets[id] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s >>\nstream\n", id, compressed.Len(), extra)
+	d.buf.Write(compressed.Bytes())
+	d.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (d *pdfDocument) bytes(rootID int) []byte {
+	size := len(d.offsets) + 1
+	xref := d.buf.Len()
+	fmt.Fprintf(&d.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		fmt.Fprintf(&d.buf, "%010d 00000 n \n", d.offsets[id])
+	}
+	fmt.Fprintf(&d.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, rootID, xref)
+	return d.buf.Bytes()
+}
@@ -0,0 +1,143 @@
+package receipts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы в testdata")
+
+// maxReceiptPDFSize — верхняя граница размера PDF чека. Целиком встроенный
+// шрифт весит больше 300 КБ, поэтому превышение означает, что подмножество
+// шрифта перестало строиться.
+const maxReceiptPDFSize = 64 << 10
+
+func testReceipt() *Receipt {
+	phone := "+7 900 123-45-67"
+	name := "Иван Петров"
+	return &Receipt{
+		Number:    "1a2b3c4d",
+		OrderID:   uuid.MustParse("1a2b3c4d-0000-4000-8000-000000000001"),
+		Operation: OperationSell,
+		IssuedAt:  time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC),
+		Seller: ReceiptSeller{
+			StoreID: uuid.MustParse("00000000-0000-4000-8000-000000000002"),
+			Name:    "Магазин «Йогурт и Ёлка»",
+			Address: "г. Махачкала, ул. Ленина, д. 1",
+			Phone:   &phone,
+		},
+		Client: ReceiptClient{Name: &name, Phone: &phone},
+		Items: []ReceiptItem{
+			newReceiptItem("Молоко 3,2% пастеризованное, бутылка 930 мл", 8990, 2, PaymentObjectCommodity),
+			newReceiptItem("Хлеб", 4500, 1, PaymentObjectCommodity),
+			newReceiptItem("Сервисный сбор", 2900, 1, PaymentObjectService),
+		},
+		Payments: []ReceiptPayment{{Type: PaymentTypeCash, Sum: 29370}},
+		Total:    models.Money(29370),
+	}
+}
+
+func TestRenderPDFGolden(t *testing.T) {
+	got, err := RenderPDF(testReceipt())
+	if err != nil {
+		t.Fatalf("RenderPDF: неожиданная ошибка %v", err)
+	}
+	if len(got) > maxReceiptPDFSize {
+		t.Errorf("размер PDF %d байт, ожидалось не больше %d", len(got), maxReceiptPDFSize)
+	}
+
+	golden := filepath.Join("testdata", "receipt.pdf")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("не удалось прочитать эталон (go test -update): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("PDF отличается от эталона %s; если изменение ожидаемое, обновите его: go test ./internal/receipts -update", golden)
+	}
+}
+
+func TestFontSubset(t *testing.T) {
+	font, err := loadReceiptFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Й — составной глиф: его составляющие должны попасть в подмножество
+	composite := font.glyph('Й')
+	outline, err := font.outline(composite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	components := glyphComponents(outline)
+	if len(components) == 0 {
+		t.Fatal("глиф Й должен быть составным")
+	}
+
+	used := []uint16{font.glyph('A'), composite}
+	data, err := font.subset(used)
+	if err != nil {
+		t.Fatalf("subset: неожиданная ошибка %v", err)
+	}
+	if tableChecksum(data) != 0xB1B0AFBA {
+		t.Error("неверная контрольная сумма шрифта")
+	}
+
+	subset := parseTables(t, data)
+	keep := map[uint16]bool{0: true}
+	for _, gid := range append(used, components...) {
+		keep[gid] = true
+	}
+	for gid := 0; gid < subset.numGlyphs; gid++ {
+		want, err := font.outline(uint16(gid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := subset.outline(uint16(gid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keep[uint16(gid)] {
+			want = nil
+		}
+		// Контур в подмножестве может быть дополнен нулями до границы слова
+		if len(got) < len(want) || !bytes.Equal(got[:len(want)], want) || (len(want) == 0 && len(got) != 0) {
+			t.Errorf("глиф %d: контур подмножества не совпадает с исходным", gid)
+		}
+	}
+}
+
+// parseTables разбирает таблицы glyf и loca подмножества шрифта, в котором
+// нет cmap и поэтому не подходит parseTrueType.
+func parseTables(t *testing.T, data []byte) *trueTypeFont {
+	t.Helper()
+	font := &trueTypeFont{tables: make(map[string][]byte)}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		entry := 12 + 16*i
+		offset := int(binary.BigEndian.Uint32(data[entry+8:]))
+		length := int(binary.BigEndian.Uint32(data[entry+12:]))
+		font.tables[string(data[entry:entry+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "maxp", "loca", "glyf"} {
+		if _, ok := font.tables[tag]; !ok {
+			t.Fatalf("в подмножестве нет таблицы %s", tag)
+		}
+	}
+	font.longLoca = binary.BigEndian.Uint16(font.tables["head"][50:]) == 1
+	font.numGlyphs = int(binary.BigEndian.Uint16(font.tables["maxp"][4:]))
+	return font
+}
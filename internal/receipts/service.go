@@ -0,0 +1,362 @@
+package receipts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/models"
+	"Laman/internal/orders"
+
+	"github.com/google/uuid"
+)
+
+// Ошибки чеков.
+var (
+	ErrForbidden           = errors.New("недостаточно прав для получения чека")
+	ErrOrderNotFound       = errors.New("заказ не найден")
+	ErrReceiptNotAvailable = errors.New("чек доступен только для доставленного заказа")
+	// ErrInvalidTrackingToken возвращается для поддельной или истекшей ссылки отслеживания.
+	ErrInvalidTrackingToken = errors.New("ссылка отслеживания недействительна или истекла")
+)
+
+// Operation — признак расчета (тег 1054).
+type Operation string
+
+// OperationSell — приход.
+const OperationSell Operation = "sell"
+
+// PaymentObject — признак предмета расчета (тег 1212).
+type PaymentObject string
+
+const (
+	PaymentObjectCommodity PaymentObject = "commodity"
+	PaymentObjectService   PaymentObject = "service"
+)
+
+// PaymentMethodSign — признак способа расчета (тег 1214).
+type PaymentMethodSign string
+
+// PaymentMethodFullPayment — полный расчет.
+const PaymentMethodFullPayment PaymentMethodSign = "full_payment"
+
+// VAT — ставка НДС (тег 1199).
+type VAT string
+
+// VATNone — без НДС.
+const VATNone VAT = "none"
+
+// PaymentType — вид оплаты: наличными (тег 1031) или безналичными (тег 1081).
+type PaymentType string
+
+const (
+	PaymentTypeCash       PaymentType = "cash"
+	PaymentTypeElectronic PaymentType = "electronic"
+)
+
+// Receipt представляет чек доставленного заказа. Структура повторяет
+// реквизиты кассового чека по 54-ФЗ, чтобы чек можно было передать
+// в онлайн-кассу без преобразований; сам документ кассовым чеком не является.
+type Receipt struct {
+	Number    string           `json:"number"`
+	OrderID   uuid.UUID        `json:"order_id"`
+	Operation Operation        `json:"operation"`
+	IssuedAt  time.Time        `json:"issued_at"`
+	Seller    ReceiptSeller    `json:"seller"`
+	Client    ReceiptClient    `json:"client"`
+	Items     []ReceiptItem    `json:"items"`
+	Payments  []ReceiptPayment `json:"payments"`
+	Total     models.Money     `json:"total"`
+}
+
+// ReceiptSeller представляет магазин, продавший товары.
+type ReceiptSeller struct {
+	StoreID uuid.UUID `json:"store_id"`
+	Name    string    `json:"name"`
+	Address string    `json:"address"`
+	Phone   *string   `json:"phone,omitempty"`
+}
+
+// ReceiptClient представляет покупателя (теги 1227 и 1008).
+type ReceiptClient struct {
+	Name  *string `json:"name,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+}
+
+// ReceiptItem представляет предмет расчета: товар или услугу.
+type ReceiptItem struct {
+	Name          string            `json:"name"`
+	Price         models.Money      `json:"price"`
+	Quantity      int               `json:"quantity"`
+	Measure       string            `json:"measure"`
+	Sum           models.Money      `json:"sum"`
+	PaymentObject PaymentObject     `json:"payment_object"`
+	PaymentMethod PaymentMethodSign `json:"payment_method"`
+	VAT           VAT               `json:"vat"`
+}
+
+// ReceiptPayment представляет сумму оплаты одним видом.
+type ReceiptPayment struct {
+	Type PaymentType  `json:"type"`
+	Sum  models.Money `json:"sum"`
+}
+
+// OrderRepository определяет интерфейс, необходимый из модуля orders.
+type OrderRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+}
+
+// OrderItemRepository определяет интерфейс, необходимый из модуля orders.
+type OrderItemRepository interface {
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+}
+
+// OrderStatusEventRepository определяет интерфейс, необходимый из модуля orders.
+type OrderStatusEventRepository interface {
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusEvent, error)
+}
+
+// ProductRepository определяет интерфейс, необходимый из модуля catalog.
+type ProductRepository interface {
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Product, error)
+}
+
+// StoreRepository определяет интерфейс, необходимый из модуля catalog.
+type StoreRepository interface {
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.Store, error)
+}
+
+// UserRepository определяет интерфейс, необходимый из модуля users.
+type UserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// TrackingParser определяет интерфейс, необходимый из модуля orders.
+type TrackingParser interface {
+	Parse(token string) (uuid.UUID, error)
+}
+
+// ReceiptService собирает чеки доставленных заказов.
+type ReceiptService struct {
+	orderRepo     OrderRepository
+	orderItemRepo OrderItemRepository
+	eventRepo     OrderStatusEventRepository
+	productRepo   ProductRepository
+	storeRepo     StoreRepository
+	userRepo      UserRepository
+	tracking      TrackingParser
+}
+
+// NewReceiptService создает новый сервис чеков.
+func NewReceiptService(
+	orderRepo OrderRepository,
+	orderItemRepo OrderItemRepository,
+	eventRepo OrderStatusEventRepository,
+	productRepo ProductRepository,
+	storeRepo StoreRepository,
+	userRepo UserRepository,
+	tracking TrackingParser,
+) *ReceiptService {
+	return &ReceiptService{
+		orderRepo:     orderRepo,
+		orderItemRepo: orderItemRepo,
+		eventRepo:     eventRepo,
+		productRepo:   productRepo,
+		storeRepo:     storeRepo,
+		userRepo:      userRepo,
+		tracking:      tracking,
+	}
+}
+
+// GetReceipt собирает чек доставленного заказа. Чек доступен покупателю,
+// магазину заказа и администратору.
+func (s *ReceiptService) GetReceipt(ctx context.Context, orderID uuid.UUID, actor models.Actor) (*Receipt, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить заказ: %w", err)
+	}
+	if !canGetReceipt(actor, order) {
+		return nil, ErrForbidden
+	}
+	return s.buildReceipt(ctx, order)
+}
+
+// GetReceiptByTracking собирает чек доставленного заказа по ссылке
+// отслеживания. Так чек получает гость, у которого нет аккаунта.
+func (s *ReceiptService) GetReceiptByTracking(ctx context.Context, token string) (*Receipt, error) {
+	if s.tracking == nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	orderID, err := s.tracking.Parse(token)
+	if err != nil {
+		return nil, ErrInvalidTrackingToken
+	}
+
+	// Подписанная ссылка на удаленный заказ так же недействительна
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		return nil, ErrInvalidTrackingToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить заказ: %w", err)
+	}
+	return s.buildReceipt(ctx, order)
+}
+
+// buildReceipt собирает чек заказа, доступ к которому уже проверен.
+func (s *ReceiptService) buildReceipt(ctx context.Context, order *models.Order) (*Receipt, error) {
+	if order.Status != models.OrderStatusDelivered {
+		return nil, ErrReceiptNotAvailable
+	}
+
+	// Магазин мог быть удален после доставки, а чек по заказу остается доступен
+	store, err := s.storeRepo.GetByIDIncludingDeleted(ctx, order.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить магазин: %w", err)
+	}
+	items, err := s.receiptItems(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	issuedAt, err := s.deliveredAt(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.client(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentType := PaymentTypeElectronic
+	if order.PaymentMethod == models.PaymentMethodCash {
+		paymentType = PaymentTypeCash
+	}
+
+	return &Receipt{
+		Number:    shortUUID(order.ID),
+		OrderID:   order.ID,
+		Operation: OperationSell,
+		IssuedAt:  issuedAt,
+		Seller: ReceiptSeller{
+			StoreID: store.ID,
+			Name:    store.Name,
+			Address: store.Address,
+			Phone:   store.Phone,
+		},
+		Client:   client,
+		Items:    items,
+		Payments: []ReceiptPayment{{Type: paymentType, Sum: order.FinalTotal}},
+		Total:    order.FinalTotal,
+	}, nil
+}
+
+// receiptItems возвращает собранные позиции заказа с названиями товаров
+// и сборы заказа отдельными услугами.
+func (s *ReceiptService) receiptItems(ctx context.Context, order *models.Order) ([]ReceiptItem, error) {
+	orderItems, err := s.orderItemRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары заказа: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(orderItems))
+	seen := make(map[uuid.UUID]struct{}, len(orderItems))
+	for _, item := range orderItems {
+		if _, ok := seen[item.ProductID]; ok {
+			continue
+		}
+		seen[item.ProductID] = struct{}{}
+		ids = append(ids, item.ProductID)
+	}
+	products, err := s.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	nameByID := make(map[uuid.UUID]string, len(products))
+	for _, product := range products {
+		nameByID[product.ID] = product.Name
+	}
+
+	items := make([]ReceiptItem, 0, len(orderItems)+2)
+	for _, item := range orderItems {
+		if !item.IsActive() {
+			continue
+		}
+		name := nameByID[item.ProductID]
+		if name == "" {
+			name = shortUUID(item.ProductID)
+		}
+		items = append(items, newReceiptItem(name, item.Price, item.Quantity, PaymentObjectCommodity))
+	}
+	if order.ServiceFee > 0 {
+		items = append(items, newReceiptItem("Сервисный сбор", order.ServiceFee, 1, PaymentObjectService))
+	}
+	if order.DeliveryFee > 0 {
+		items = append(items, newReceiptItem("Доставка", order.DeliveryFee, 1, PaymentObjectService))
+	}
+	return items, nil
+}
+
+// deliveredAt возвращает время доставки заказа из истории статусов.
+func (s *ReceiptService) deliveredAt(ctx context.Context, order *models.Order) (time.Time, error) {
+	history, err := s.eventRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("не удалось получить историю заказа: %w", err)
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ToStatus == models.OrderStatusDelivered {
+			return history[i].CreatedAt, nil
+		}
+	}
+	return order.UpdatedAt, nil
+}
+
+// client возвращает контакты покупателя: гостя из заказа или
+// зарегистрированного пользователя.
+func (s *ReceiptService) client(ctx context.Context, order *models.Order) (ReceiptClient, error) {
+	if order.UserID == nil {
+		return ReceiptClient{Name: order.GuestName, Phone: order.GuestPhone}, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, *order.UserID)
+	if err != nil {
+		return ReceiptClient{}, fmt.Errorf("не удалось получить покупателя: %w", err)
+	}
+	phone := user.Phone
+	return ReceiptClient{Phone: &phone}, nil
+}
+
+func newReceiptItem(name string, price models.Money, quantity int, object PaymentObject) ReceiptItem {
+	return ReceiptItem{
+		Name:          name,
+		Price:         price,
+		Quantity:      quantity,
+		Measure:       "шт",
+		Sum:           price.Mul(quantity),
+		PaymentObject: object,
+		PaymentMethod: PaymentMethodFullPayment,
+		VAT:           VATNone,
+	}
+}
+
+// canGetReceipt проверяет, может ли участник получить чек заказа:
+// покупатель — своего, магазин — своего магазина, администратор — любого.
+// Гость получает чек по ссылке отслеживания через GetReceiptByTracking.
+func canGetReceipt(actor models.Actor, order *models.Order) bool {
+	switch actor.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleStore:
+		return actor.StoreID != nil && *actor.StoreID == order.StoreID
+	case models.UserRoleCustomer:
+		return actor.UserID != nil && order.UserID != nil && *actor.UserID == *order.UserID
+	default:
+		return false
+	}
+}
+
+func shortUUID(id uuid.UUID) string {
+	return id.String()[:8]
+}
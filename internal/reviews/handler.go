@@ -0,0 +1,253 @@
+package reviews
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Laman/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler обрабатывает HTTP запросы для отзывов и модерации.
+type Handler struct {
+	reviewService *ReviewService
+	authService   AuthService
+	userLoader    middleware.UserLoader
+}
+
+// AuthService определяет интерфейс, необходимый из модуля auth.
+type AuthService interface {
+	ValidateToken(token string) (uuid.UUID, error)
+}
+
+// NewHandler создает новый обработчик отзывов.
+func NewHandler(reviewService *ReviewService, authService AuthService, userLoader middleware.UserLoader) *Handler {
+	return &Handler{
+		reviewService: reviewService,
+		authService:   authService,
+		userLoader:    userLoader,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты отзывов.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	// Одобренные отзывы доступны всем
+	router.GET("/stores/:id/reviews", h.GetStoreReviews)
+	router.GET("/catalog/products/:id/reviews", h.GetProductReviews)
+
+	auth := []gin.HandlerFunc{middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader)}
+
+	// Оценить заказ может только покупатель, который его оформил
+	orders := router.Group("/orders", auth...)
+	{
+		orders.POST("/:id/reviews", h.CreateReviews)
+		orders.GET("/:id/reviews", h.GetOrderReviews)
+	}
+
+	// Модерация отзывов доступна только администратору
+	moderation := router.Group("/reviews", auth...)
+	{
+		moderation.GET("/pending", h.GetPendingReviews)
+		moderation.PUT("/:id/moderation", h.ModerateReview)
+	}
+}
+
+// CreateReviews обрабатывает POST /orders/:id/reviews
+func (h *Handler) CreateReviews(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	var req CreateReviewsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.reviewService.CreateReviews(c.Request.Context(), orderID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reviews)
+}
+
+// GetOrderReviews обрабатывает GET /orders/:id/reviews
+func (h *Handler) GetOrderReviews(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID заказа"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	reviews, err := h.reviewService.GetOrderReviews(c.Request.Context(), orderID, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetStoreReviews обрабатывает GET /stores/:id/reviews?limit=&offset=
+func (h *Handler) GetStoreReviews(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.reviewService.GetStoreReviews(c.Request.Context(), storeID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetProductReviews обрабатывает GET /catalog/products/:id/reviews?limit=&offset=
+func (h *Handler) GetProductReviews(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+		return
+	}
+
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.reviewService.GetProductReviews(c.Request.Context(), productID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetPendingReviews обрабатывает GET /reviews/pending?product_id=&limit=&offset=
+// Без product_id возвращаются отзывы о магазинах.
+func (h *Handler) GetPendingReviews(c *gin.Context) {
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	var productID *uuid.UUID
+	if value := c.Query("product_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID товара"})
+			return
+		}
+		productID = &id
+	}
+
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.reviewService.GetPendingReviews(c.Request.Context(), productID, limit, offset, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ModerateReview обрабатывает PUT /reviews/:id/moderation
+func (h *Handler) ModerateReview(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID отзыва"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewService.ModerateReview(c.Request.Context(), id, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrOrderNotFound), errors.Is(err, ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAlreadyReviewed), errors.Is(err, ErrOrderNotDelivered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrProductNotInOrder), errors.Is(err, ErrNothingToReview),
+		errors.Is(err, ErrDuplicateProduct), errors.Is(err, ErrInvalidRating),
+		errors.Is(err, ErrReviewTooLong), errors.Is(err, ErrInvalidModeration):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func parsePage(c *gin.Context) (int, int, error) {
+	var limit, offset int
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, errors.New("неверный параметр limit")
+		}
+		limit = n
+	}
+	if value := c.Query("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, errors.New("неверный параметр offset")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,147 @@
+package reviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const reviewColumns = `id, order_id, user_id, store_id, product_id, rating, text, status,
+	moderation_comment, moderated_by, moderated_at, created_at, updated_at`
+
+// postgresReviewRepository реализует ReviewRepository используя PostgreSQL.
+type postgresReviewRepository struct {
+	db *database.DB
+}
+
+// NewPostgresReviewRepository создает новый PostgreSQL репозиторий отзывов.
+func NewPostgresReviewRepository(db *database.DB) ReviewRepository {
+	return &postgresReviewRepository{db: db}
+}
+
+func (r *postgresReviewRepository) CreateBatch(ctx context.Context, reviews []models.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO reviews (id, order_id, user_id, store_id, product_id, rating, text, status,
+			moderation_comment, moderated_by, moderated_at, created_at, updated_at)
+		VALUES (:id, :order_id, :user_id, :store_id, :product_id, :rating, :text, :status,
+			:moderation_comment, :moderated_by, :moderated_at, :created_at, :updated_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, reviews)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *postgresReviewRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+	var review models.Review
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE id = $1 FOR UPDATE`
+	err := r.db.Conn(ctx).GetContext(ctx, &review, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrReviewNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *postgresReviewRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.Review, error) {
+	var reviews []models.Review
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE order_id = $1 ORDER BY product_id NULLS FIRST, created_at`
+	err := r.db.Conn(ctx).SelectContext(ctx, &reviews, query, orderID)
+	return reviews, err
+}
+
+func (r *postgresReviewRepository) List(ctx context.Context, filter ReviewFilter) ([]models.Review, error) {
+	reviews := []models.Review{}
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.ProductID != nil {
+		args = append(args, *filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	} else {
+		conditions = append(conditions, "product_id IS NULL")
+	}
+	if filter.StoreID != nil {
+		args = append(args, *filter.StoreID)
+		conditions = append(conditions, fmt.Sprintf("store_id = $%d", len(args)))
+	}
+	if filter.Status != nil {
+		args = append(args, *filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(
+		`SELECT %s FROM reviews WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`,
+		reviewColumns, strings.Join(conditions, " AND "), len(args)-1, len(args),
+	)
+	err := r.db.Conn(ctx).SelectContext(ctx, &reviews, query, args...)
+	return reviews, err
+}
+
+func (r *postgresReviewRepository) UpdateModeration(ctx context.Context, review *models.Review) error {
+	query := `
+		UPDATE reviews
+		SET status = :status, moderation_comment = :moderation_comment,
+		    moderated_by = :moderated_by, moderated_at = :moderated_at, updated_at = :updated_at
+		WHERE id = :id
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, review)
+	return err
+}
+
+func (r *postgresReviewRepository) RecomputeStoreRating(ctx context.Context, storeID uuid.UUID) error {
+	// Строка магазина блокируется отдельным запросом: следующий запрос
+	// получает новый снимок и видит отзывы, одобренные параллельно.
+	if _, err := r.db.Conn(ctx).ExecContext(ctx, `SELECT id FROM stores WHERE id = $1 FOR UPDATE`, storeID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE stores s
+		SET rating = COALESCE(a.rating, 0), rating_count = a.count, updated_at = NOW()
+		FROM (
+			SELECT ROUND(AVG(rating), 2) AS rating, COUNT(*) AS count
+			FROM reviews
+			WHERE store_id = $1 AND product_id IS NULL AND status = 'APPROVED'
+		) a
+		WHERE s.id = $1
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, storeID)
+	return err
+}
+
+func (r *postgresReviewRepository) RecomputeProductRating(ctx context.Context, productID uuid.UUID) error {
+	if _, err := r.db.Conn(ctx).ExecContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE products p
+		SET rating = COALESCE(a.rating, 0), rating_count = a.count, updated_at = NOW()
+		FROM (
+			SELECT ROUND(AVG(rating), 2) AS rating, COUNT(*) AS count
+			FROM reviews
+			WHERE product_id = $1 AND status = 'APPROVED'
+		) a
+		WHERE p.id = $1
+	`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, productID)
+	return err
+}
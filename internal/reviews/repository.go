@@ -0,0 +1,50 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReviewNotFound  = errors.New("отзыв не найден")
+	ErrAlreadyReviewed = errors.New("отзыв по этому заказу уже оставлен")
+)
+
+// ReviewRepository определяет интерфейс для доступа к отзывам.
+type ReviewRepository interface {
+	// CreateBatch создает несколько отзывов. Возвращает ErrAlreadyReviewed,
+	// если магазин или товар по заказу уже оценен.
+	CreateBatch(ctx context.Context, reviews []models.Review) error
+
+	// GetByIDForUpdate получает отзыв по ID и блокирует его строку до конца транзакции.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Review, error)
+
+	// GetByOrderID получает отзывы по заказу.
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.Review, error)
+
+	// List получает отзывы по фильтру от новых к старым.
+	List(ctx context.Context, filter ReviewFilter) ([]models.Review, error)
+
+	// UpdateModeration сохраняет результат модерации отзыва.
+	UpdateModeration(ctx context.Context, review *models.Review) error
+
+	// RecomputeStoreRating пересчитывает рейтинг магазина по одобренным отзывам о нем.
+	RecomputeStoreRating(ctx context.Context, storeID uuid.UUID) error
+
+	// RecomputeProductRating пересчитывает рейтинг товара по одобренным отзывам о нем.
+	RecomputeProductRating(ctx context.Context, productID uuid.UUID) error
+}
+
+// ReviewFilter задает условия выборки отзывов. Без ProductID выбираются
+// отзывы о магазинах, пустой StoreID не ограничивает выборку.
+type ReviewFilter struct {
+	StoreID   *uuid.UUID
+	ProductID *uuid.UUID
+	Status    *models.ReviewStatus
+	Limit     int
+	Offset    int
+}
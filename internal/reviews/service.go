@@ -0,0 +1,334 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+	"Laman/internal/orders"
+
+	"github.com/google/uuid"
+)
+
+// Ошибки отзывов.
+var (
+	ErrForbidden         = errors.New("недостаточно прав для работы с отзывом")
+	ErrOrderNotFound     = errors.New("заказ не найден")
+	ErrOrderNotDelivered = errors.New("оценить можно только доставленный заказ")
+	ErrProductNotInOrder = errors.New("товара нет в доставленном заказе")
+	ErrNothingToReview   = errors.New("нужно оценить магазин или хотя бы один товар")
+	ErrDuplicateProduct  = errors.New("товар указан дважды")
+	ErrInvalidRating     = errors.New("оценка должна быть от 1 до 5")
+	ErrReviewTooLong     = errors.New("текст отзыва слишком длинный")
+	ErrInvalidModeration = errors.New("недопустимый статус модерации")
+)
+
+const (
+	// maxReviewText — максимальная длина текста отзыва в символах.
+	maxReviewText = 2000
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// OrderRepository определяет интерфейс, необходимый из модуля orders.
+type OrderRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+}
+
+// OrderItemRepository определяет интерфейс, необходимый из модуля orders.
+type OrderItemRepository interface {
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+}
+
+// ReviewService обрабатывает бизнес-логику отзывов и рейтингов.
+type ReviewService struct {
+	uow           database.UnitOfWork
+	reviewRepo    ReviewRepository
+	orderRepo     OrderRepository
+	orderItemRepo OrderItemRepository
+}
+
+// NewReviewService создает новый сервис отзывов.
+func NewReviewService(
+	uow database.UnitOfWork,
+	reviewRepo ReviewRepository,
+	orderRepo OrderRepository,
+	orderItemRepo OrderItemRepository,
+) *ReviewService {
+	return &ReviewService{
+		uow:           uow,
+		reviewRepo:    reviewRepo,
+		orderRepo:     orderRepo,
+		orderItemRepo: orderItemRepo,
+	}
+}
+
+// CreateReviewsRequest представляет оценку доставленного заказа.
+// Rating оценивает магазин, Products — отдельные товары заказа.
+type CreateReviewsRequest struct {
+	Rating   *int                 `json:"rating"`
+	Text     *string              `json:"text"`
+	Products []ProductReviewEntry `json:"products"`
+}
+
+// ProductReviewEntry представляет оценку товара из заказа.
+type ProductReviewEntry struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Rating    int       `json:"rating" binding:"required"`
+	Text      *string   `json:"text"`
+}
+
+// ModerateReviewRequest представляет решение модератора по отзыву.
+type ModerateReviewRequest struct {
+	Status  models.ReviewStatus `json:"status" binding:"required"`
+	Comment *string             `json:"comment"`
+}
+
+// CreateReviews сохраняет оценки магазина и товаров доставленного заказа.
+// Оценки без текста учитываются в рейтинге сразу, отзывы с текстом — после модерации.
+func (s *ReviewService) CreateReviews(ctx context.Context, orderID uuid.UUID, req CreateReviewsRequest, actor models.Actor) ([]models.Review, error) {
+	if req.Rating == nil && len(req.Products) == 0 {
+		return nil, ErrNothingToReview
+	}
+
+	var created []models.Review
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := s.orderRepo.GetByID(ctx, orderID)
+		if errors.Is(err, orders.ErrOrderNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("не удалось получить заказ: %w", err)
+		}
+		if !canReview(actor, order) {
+			return ErrForbidden
+		}
+		if order.Status != models.OrderStatusDelivered {
+			return ErrOrderNotDelivered
+		}
+
+		items, err := s.orderItemRepo.GetByOrderID(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("не удалось получить товары заказа: %w", err)
+		}
+		delivered := make(map[uuid.UUID]struct{}, len(items))
+		for _, item := range items {
+			if item.IsActive() {
+				delivered[item.ProductID] = struct{}{}
+			}
+		}
+
+		now := time.Now()
+		reviews := make([]models.Review, 0, len(req.Products)+1)
+		if req.Rating != nil {
+			review, err := newReview(order, nil, *req.Rating, req.Text, now)
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, *review)
+		}
+		seen := make(map[uuid.UUID]struct{}, len(req.Products))
+		for _, entry := range req.Products {
+			if _, ok := delivered[entry.ProductID]; !ok {
+				return fmt.Errorf("%w: %s", ErrProductNotInOrder, entry.ProductID)
+			}
+			if _, ok := seen[entry.ProductID]; ok {
+				return fmt.Errorf("%w: %s", ErrDuplicateProduct, entry.ProductID)
+			}
+			seen[entry.ProductID] = struct{}{}
+
+			productID := entry.ProductID
+			review, err := newReview(order, &productID, entry.Rating, entry.Text, now)
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, *review)
+		}
+
+		if err := s.reviewRepo.CreateBatch(ctx, reviews); err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			if review.Status != models.ReviewStatusApproved {
+				continue
+			}
+			if err := s.recomputeRating(ctx, &review); err != nil {
+				return err
+			}
+		}
+
+		created = reviews
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetOrderReviews получает отзывы покупателя по заказу, включая ожидающие модерации.
+func (s *ReviewService) GetOrderReviews(ctx context.Context, orderID uuid.UUID, actor models.Actor) ([]models.Review, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить заказ: %w", err)
+	}
+	if !canReview(actor, order) && actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	reviews, err := s.reviewRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить отзывы: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetStoreReviews получает одобренные отзывы о магазине.
+func (s *ReviewService) GetStoreReviews(ctx context.Context, storeID uuid.UUID, limit, offset int) ([]models.Review, error) {
+	status := models.ReviewStatusApproved
+	return s.list(ctx, ReviewFilter{StoreID: &storeID, Status: &status}, limit, offset)
+}
+
+// GetProductReviews получает одобренные отзывы о товаре.
+func (s *ReviewService) GetProductReviews(ctx context.Context, productID uuid.UUID, limit, offset int) ([]models.Review, error) {
+	status := models.ReviewStatusApproved
+	return s.list(ctx, ReviewFilter{ProductID: &productID, Status: &status}, limit, offset)
+}
+
+// GetPendingReviews получает очередь модерации: отзывы о магазинах или,
+// если указан productID, о товаре. Доступно только администратору.
+func (s *ReviewService) GetPendingReviews(ctx context.Context, productID *uuid.UUID, limit, offset int, actor models.Actor) ([]models.Review, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	status := models.ReviewStatusPending
+	return s.list(ctx, ReviewFilter{ProductID: productID, Status: &status}, limit, offset)
+}
+
+// ModerateReview одобряет или отклоняет отзыв и пересчитывает рейтинг.
+// Одобренный ранее отзыв можно отклонить, например по жалобе.
+func (s *ReviewService) ModerateReview(ctx context.Context, id uuid.UUID, req ModerateReviewRequest, actor models.Actor) (*models.Review, error) {
+	if actor.Role != models.UserRoleAdmin {
+		return nil, ErrForbidden
+	}
+	if req.Status != models.ReviewStatusApproved && req.Status != models.ReviewStatusRejected {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidModeration, req.Status)
+	}
+
+	var moderated *models.Review
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		review, err := s.reviewRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		wasApproved := review.Status == models.ReviewStatusApproved
+		review.Status = req.Status
+		review.ModerationComment = req.Comment
+		review.ModeratedBy = actor.UserID
+		review.ModeratedAt = &now
+		review.UpdatedAt = now
+		if err := s.reviewRepo.UpdateModeration(ctx, review); err != nil {
+			return fmt.Errorf("не удалось сохранить модерацию: %w", err)
+		}
+
+		if wasApproved != (review.Status == models.ReviewStatusApproved) {
+			if err := s.recomputeRating(ctx, review); err != nil {
+				return err
+			}
+		}
+
+		moderated = review
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moderated, nil
+}
+
+func (s *ReviewService) list(ctx context.Context, filter ReviewFilter, limit, offset int) ([]models.Review, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	reviews, err := s.reviewRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить отзывы: %w", err)
+	}
+	return reviews, nil
+}
+
+// recomputeRating пересчитывает рейтинг магазина или товара, к которому относится отзыв.
+func (s *ReviewService) recomputeRating(ctx context.Context, review *models.Review) error {
+	if review.ProductID != nil {
+		if err := s.reviewRepo.RecomputeProductRating(ctx, *review.ProductID); err != nil {
+			return fmt.Errorf("не удалось пересчитать рейтинг товара: %w", err)
+		}
+		return nil
+	}
+	if err := s.reviewRepo.RecomputeStoreRating(ctx, review.StoreID); err != nil {
+		return fmt.Errorf("не удалось пересчитать рейтинг магазина: %w", err)
+	}
+	return nil
+}
+
+// canReview проверяет, что заказ оформлен самим покупателем.
+func canReview(actor models.Actor, order *models.Order) bool {
+	return actor.Role == models.UserRoleCustomer &&
+		actor.UserID != nil && order.UserID != nil && *actor.UserID == *order.UserID
+}
+
+// newReview проверяет оценку и текст и создает отзыв. Оценка без текста
+// не требует модерации и сразу учитывается в рейтинге.
+func newReview(order *models.Order, productID *uuid.UUID, rating int, text *string, now time.Time) (*models.Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrInvalidRating
+	}
+
+	var body *string
+	if text != nil {
+		if trimmed := strings.TrimSpace(*text); trimmed != "" {
+			if utf8.RuneCountInString(trimmed) > maxReviewText {
+				return nil, fmt.Errorf("%w: больше %d символов", ErrReviewTooLong, maxReviewText)
+			}
+			body = &trimmed
+		}
+	}
+
+	status := models.ReviewStatusApproved
+	if body != nil {
+		status = models.ReviewStatusPending
+	}
+
+	return &models.Review{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		UserID:    *order.UserID,
+		StoreID:   order.StoreID,
+		ProductID: productID,
+		Rating:    rating,
+		Text:      body,
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
@@ -0,0 +1,174 @@
+package scheduling
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"Laman/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler обрабатывает HTTP запросы для часов работы и слотов доставки.
+type Handler struct {
+	slotService *SlotService
+	authService AuthService
+	userLoader  middleware.UserLoader
+}
+
+// AuthService определяет интерфейс, необходимый из модуля auth.
+type AuthService interface {
+	ValidateToken(token string) (uuid.UUID, error)
+}
+
+// NewHandler создает новый обработчик слотов доставки.
+func NewHandler(slotService *SlotService, authService AuthService, userLoader middleware.UserLoader) *Handler {
+	return &Handler{
+		slotService: slotService,
+		authService: authService,
+		userLoader:  userLoader,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты расписания магазинов.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	stores := router.Group("/stores")
+	{
+		stores.GET("/:id/hours", h.GetHours)
+		stores.GET("/:id/slots", h.GetSlots)
+	}
+
+	// Менять расписание может только сам магазин или администратор
+	protected := stores.Group("")
+	protected.Use(middleware.AuthMiddleware(h.authService), middleware.ActorMiddleware(h.userLoader))
+	{
+		protected.PUT("/:id/hours", h.SetHours)
+		protected.POST("/:id/slots", h.CreateSlots)
+	}
+}
+
+// GetHours обрабатывает GET /stores/:id/hours
+func (h *Handler) GetHours(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	hours, err := h.slotService.GetHours(c.Request.Context(), storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// SetHours обрабатывает PUT /stores/:id/hours
+func (h *Handler) SetHours(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	var req SetHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hours, err := h.slotService.SetHours(c.Request.Context(), storeID, req, actor)
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// GetSlots обрабатывает GET /stores/:id/slots?from=&to=
+// Границы периода передаются в формате RFC 3339.
+func (h *Handler) GetSlots(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр from"})
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр to"})
+		return
+	}
+
+	slots, err := h.slotService.GetAvailableSlots(c.Request.Context(), storeID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, slots)
+}
+
+// CreateSlots обрабатывает POST /stores/:id/slots
+func (h *Handler) CreateSlots(c *gin.Context) {
+	storeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID магазина"})
+		return
+	}
+
+	actor, ok := middleware.ActorFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не аутентифицирован"})
+		return
+	}
+
+	var req CreateSlotsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slots, err := h.slotService.CreateSlots(c.Request.Context(), storeID, req, actor)
+	if errors.Is(err, ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, slots)
+}
+
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
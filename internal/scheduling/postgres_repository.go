@@ -0,0 +1,120 @@
+package scheduling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// postgresHoursRepository реализует HoursRepository используя PostgreSQL.
+type postgresHoursRepository struct {
+	db *database.DB
+}
+
+// NewPostgresHoursRepository создает новый PostgreSQL репозиторий часов работы.
+func NewPostgresHoursRepository(db *database.DB) HoursRepository {
+	return &postgresHoursRepository{db: db}
+}
+
+func (r *postgresHoursRepository) GetByStoreID(ctx context.Context, storeID uuid.UUID) ([]models.StoreHours, error) {
+	var hours []models.StoreHours
+	query := `
+		SELECT store_id, weekday, to_char(opens_at, 'HH24:MI') AS opens_at, to_char(closes_at, 'HH24:MI') AS closes_at
+		FROM store_hours WHERE store_id = $1 ORDER BY weekday
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &hours, query, storeID)
+	return hours, err
+}
+
+func (r *postgresHoursRepository) ReplaceForStore(ctx context.Context, storeID uuid.UUID, hours []models.StoreHours) error {
+	if _, err := r.db.Conn(ctx).ExecContext(ctx, `DELETE FROM store_hours WHERE store_id = $1`, storeID); err != nil {
+		return err
+	}
+	if len(hours) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO store_hours (store_id, weekday, opens_at, closes_at)
+		VALUES (:store_id, :weekday, :opens_at, :closes_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, hours)
+	return err
+}
+
+// postgresSlotRepository реализует SlotRepository используя PostgreSQL.
+type postgresSlotRepository struct {
+	db *database.DB
+}
+
+// NewPostgresSlotRepository создает новый PostgreSQL репозиторий слотов доставки.
+func NewPostgresSlotRepository(db *database.DB) SlotRepository {
+	return &postgresSlotRepository{db: db}
+}
+
+func (r *postgresSlotRepository) CreateBatch(ctx context.Context, slots []models.DeliverySlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO delivery_slots (id, store_id, starts_at, ends_at, capacity, reserved, created_at)
+		VALUES (:id, :store_id, :starts_at, :ends_at, :capacity, :reserved, :created_at)
+	`
+	_, err := r.db.Conn(ctx).NamedExecContext(ctx, query, slots)
+	return err
+}
+
+func (r *postgresSlotRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeliverySlot, error) {
+	var slot models.DeliverySlot
+	query := `SELECT id, store_id, starts_at, ends_at, capacity, reserved, created_at FROM delivery_slots WHERE id = $1`
+	err := r.db.Conn(ctx).GetContext(ctx, &slot, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", ErrSlotNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func (r *postgresSlotRepository) GetByStoreID(ctx context.Context, storeID uuid.UUID, from, to time.Time) ([]models.DeliverySlot, error) {
+	var slots []models.DeliverySlot
+	query := `
+		SELECT id, store_id, starts_at, ends_at, capacity, reserved, created_at
+		FROM delivery_slots
+		WHERE store_id = $1 AND starts_at >= $2 AND starts_at < $3
+		ORDER BY starts_at
+	`
+	err := r.db.Conn(ctx).SelectContext(ctx, &slots, query, storeID, from, to)
+	return slots, err
+}
+
+func (r *postgresSlotRepository) Reserve(ctx context.Context, id uuid.UUID) error {
+	// Условное обновление атомарно: параллельные заказы не превысят вместимость
+	query := `UPDATE delivery_slots SET reserved = reserved + 1 WHERE id = $1 AND reserved < capacity`
+	result, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSlotFull
+	}
+	return nil
+}
+
+func (r *postgresSlotRepository) Release(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE delivery_slots SET reserved = reserved - 1 WHERE id = $1 AND reserved > 0`
+	_, err := r.db.Conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
@@ -0,0 +1,43 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSlotNotFound = errors.New("слот доставки не найден")
+	ErrSlotFull     = errors.New("в слоте доставки нет свободных мест")
+)
+
+// HoursRepository определяет интерфейс для доступа к часам работы магазинов.
+type HoursRepository interface {
+	// GetByStoreID получает часы работы магазина по дням недели.
+	GetByStoreID(ctx context.Context, storeID uuid.UUID) ([]models.StoreHours, error)
+
+	// ReplaceForStore заменяет расписание магазина целиком.
+	ReplaceForStore(ctx context.Context, storeID uuid.UUID, hours []models.StoreHours) error
+}
+
+// SlotRepository определяет интерфейс для доступа к слотам доставки.
+type SlotRepository interface {
+	// CreateBatch создает несколько слотов.
+	CreateBatch(ctx context.Context, slots []models.DeliverySlot) error
+
+	// GetByID получает слот по ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeliverySlot, error)
+
+	// GetByStoreID получает слоты магазина, начинающиеся в интервале [from, to).
+	GetByStoreID(ctx context.Context, storeID uuid.UUID, from, to time.Time) ([]models.DeliverySlot, error)
+
+	// Reserve занимает место в слоте. Возвращает ErrSlotFull, если мест нет.
+	Reserve(ctx context.Context, id uuid.UUID) error
+
+	// Release освобождает место в слоте.
+	Release(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,276 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrForbidden возвращается, когда участник не управляет расписанием магазина.
+var ErrForbidden = errors.New("недостаточно прав для управления расписанием магазина")
+
+// defaultSlotsRange — период, за который отдаются слоты, если он не указан.
+const defaultSlotsRange = 7 * 24 * time.Hour
+
+// SlotService обрабатывает бизнес-логику часов работы и слотов доставки.
+type SlotService struct {
+	uow       database.UnitOfWork
+	hoursRepo HoursRepository
+	slotRepo  SlotRepository
+}
+
+// NewSlotService создает новый сервис слотов доставки.
+func NewSlotService(uow database.UnitOfWork, hoursRepo HoursRepository, slotRepo SlotRepository) *SlotService {
+	return &SlotService{
+		uow:       uow,
+		hoursRepo: hoursRepo,
+		slotRepo:  slotRepo,
+	}
+}
+
+// SetHoursRequest представляет запрос на установку часов работы магазина.
+// Дни недели, отсутствующие в запросе, считаются выходными.
+type SetHoursRequest struct {
+	Hours []HoursEntry `json:"hours" binding:"required,dive"`
+}
+
+// HoursEntry представляет часы работы в один день недели.
+type HoursEntry struct {
+	Weekday  int    `json:"weekday" binding:"min=0,max=6"`
+	OpensAt  string `json:"opens_at" binding:"required"`
+	ClosesAt string `json:"closes_at" binding:"required"`
+}
+
+// CreateSlotsRequest представляет запрос на публикацию слотов доставки.
+type CreateSlotsRequest struct {
+	Slots []SlotEntry `json:"slots" binding:"required,min=1,dive"`
+}
+
+// SlotEntry представляет публикуемый слот доставки.
+type SlotEntry struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Capacity int       `json:"capacity" binding:"required,min=1"`
+}
+
+// AvailableSlot представляет слот со свободными местами.
+type AvailableSlot struct {
+	models.DeliverySlot
+	Remaining int `json:"remaining"`
+}
+
+// GetHours получает часы работы магазина.
+func (s *SlotService) GetHours(ctx context.Context, storeID uuid.UUID) ([]models.StoreHours, error) {
+	hours, err := s.hoursRepo.GetByStoreID(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить часы работы: %w", err)
+	}
+	return hours, nil
+}
+
+// SetHours заменяет часы работы магазина.
+func (s *SlotService) SetHours(ctx context.Context, storeID uuid.UUID, req SetHoursRequest, actor models.Actor) ([]models.StoreHours, error) {
+	if !canManageStore(actor, storeID) {
+		return nil, ErrForbidden
+	}
+
+	hours := make([]models.StoreHours, 0, len(req.Hours))
+	seen := make(map[int]struct{}, len(req.Hours))
+	for _, entry := range req.Hours {
+		if _, ok := seen[entry.Weekday]; ok {
+			return nil, fmt.Errorf("день недели %d указан дважды", entry.Weekday)
+		}
+		seen[entry.Weekday] = struct{}{}
+
+		opens, err := parseClock(entry.OpensAt)
+		if err != nil {
+			return nil, err
+		}
+		closes, err := parseClock(entry.ClosesAt)
+		if err != nil {
+			return nil, err
+		}
+		if opens >= closes {
+			return nil, fmt.Errorf("время открытия должно быть раньше закрытия: день %d", entry.Weekday)
+		}
+
+		hours = append(hours, models.StoreHours{
+			StoreID:  storeID,
+			Weekday:  entry.Weekday,
+			OpensAt:  entry.OpensAt,
+			ClosesAt: entry.ClosesAt,
+		})
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.hoursRepo.ReplaceForStore(ctx, storeID, hours)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сохранить часы работы: %w", err)
+	}
+	return hours, nil
+}
+
+// CreateSlots публикует слоты доставки магазина. Каждый слот должен
+// целиком попадать в часы работы магазина.
+func (s *SlotService) CreateSlots(ctx context.Context, storeID uuid.UUID, req CreateSlotsRequest, actor models.Actor) ([]models.DeliverySlot, error) {
+	if !canManageStore(actor, storeID) {
+		return nil, ErrForbidden
+	}
+
+	hours, err := s.hoursRepo.GetByStoreID(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить часы работы: %w", err)
+	}
+
+	now := time.Now()
+	slots := make([]models.DeliverySlot, 0, len(req.Slots))
+	for _, entry := range req.Slots {
+		if !entry.StartsAt.Before(entry.EndsAt) {
+			return nil, errors.New("начало слота должно быть раньше конца")
+		}
+		if !entry.StartsAt.After(now) {
+			return nil, errors.New("нельзя публиковать слоты в прошлом")
+		}
+		if !withinHours(hours, entry.StartsAt, entry.EndsAt) {
+			return nil, fmt.Errorf("слот %s вне часов работы магазина", entry.StartsAt.Format("02.01 15:04"))
+		}
+
+		slots = append(slots, models.DeliverySlot{
+			ID:        uuid.New(),
+			StoreID:   storeID,
+			StartsAt:  entry.StartsAt,
+			EndsAt:    entry.EndsAt,
+			Capacity:  entry.Capacity,
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.slotRepo.CreateBatch(ctx, slots); err != nil {
+		return nil, fmt.Errorf("не удалось создать слоты: %w", err)
+	}
+	return slots, nil
+}
+
+// GetAvailableSlots получает будущие слоты магазина со свободными местами.
+// Без указания периода возвращаются слоты на ближайшую неделю.
+func (s *SlotService) GetAvailableSlots(ctx context.Context, storeID uuid.UUID, from, to *time.Time) ([]AvailableSlot, error) {
+	now := time.Now()
+	start := now
+	if from != nil && from.After(now) {
+		start = *from
+	}
+	end := start.Add(defaultSlotsRange)
+	if to != nil {
+		end = *to
+	}
+
+	slots, err := s.slotRepo.GetByStoreID(ctx, storeID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить слоты: %w", err)
+	}
+
+	available := make([]AvailableSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.Remaining() == 0 {
+			continue
+		}
+		available = append(available, AvailableSlot{DeliverySlot: slot, Remaining: slot.Remaining()})
+	}
+	return available, nil
+}
+
+// ReserveSlot занимает место в слоте для заказа магазина. Слот проверяется
+// повторно: он должен быть в будущем и в текущих часах работы магазина.
+// Вызывается внутри транзакции создания заказа.
+func (s *SlotService) ReserveSlot(ctx context.Context, storeID, slotID uuid.UUID, now time.Time) (*models.DeliverySlot, error) {
+	slot, err := s.slotRepo.GetByID(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+	if slot.StoreID != storeID {
+		return nil, errors.New("слот доставки относится к другому магазину")
+	}
+	if !slot.StartsAt.After(now) {
+		return nil, errors.New("слот доставки уже начался")
+	}
+
+	hours, err := s.hoursRepo.GetByStoreID(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить часы работы: %w", err)
+	}
+	if !withinHours(hours, slot.StartsAt, slot.EndsAt) {
+		return nil, errors.New("слот доставки вне часов работы магазина")
+	}
+
+	if err := s.slotRepo.Reserve(ctx, slotID); err != nil {
+		return nil, err
+	}
+	slot.Reserved++
+	return slot, nil
+}
+
+// ReleaseSlot освобождает место в слоте отмененного заказа.
+func (s *SlotService) ReleaseSlot(ctx context.Context, slotID uuid.UUID) error {
+	if err := s.slotRepo.Release(ctx, slotID); err != nil {
+		return fmt.Errorf("не удалось освободить слот доставки: %w", err)
+	}
+	return nil
+}
+
+// canManageStore проверяет, может ли участник менять расписание магазина.
+func canManageStore(actor models.Actor, storeID uuid.UUID) bool {
+	switch actor.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleStore:
+		return actor.StoreID != nil && *actor.StoreID == storeID
+	default:
+		return false
+	}
+}
+
+// withinHours проверяет, что интервал лежит внутри одного рабочего дня магазина.
+func withinHours(hours []models.StoreHours, start, end time.Time) bool {
+	if start.Year() != end.Year() || start.YearDay() != end.YearDay() {
+		return false
+	}
+
+	for _, h := range hours {
+		if h.Weekday != int(start.Weekday()) {
+			continue
+		}
+		opens, err := parseClock(h.OpensAt)
+		if err != nil {
+			return false
+		}
+		closes, err := parseClock(h.ClosesAt)
+		if err != nil {
+			return false
+		}
+		return minuteOfDay(start) >= opens && minuteOfDay(end) <= closes
+	}
+	return false
+}
+
+// parseClock переводит время ЧЧ:ММ в минуты от начала суток.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		t, err = time.Parse("15:04:05", value)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("неверный формат времени %q, ожидается ЧЧ:ММ", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler обрабатывает HTTP запросы поисковых подсказок.
+type Handler struct {
+	suggestService *SuggestService
+}
+
+// NewHandler создает новый обработчик поиска.
+func NewHandler(suggestService *SuggestService) *Handler {
+	return &Handler{
+		suggestService: suggestService,
+	}
+}
+
+// RegisterRoutes регистрирует маршруты поиска.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	search := router.Group("/search")
+	{
+		search.GET("/suggest", h.Suggest)
+	}
+}
+
+// Suggest обрабатывает GET /search/suggest?q=&limit=
+func (h *Handler) Suggest(c *gin.Context) {
+	limit := DefaultSuggestLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > MaxSuggestLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр limit"})
+			return
+		}
+		limit = value
+	}
+
+	suggestions, err := h.suggestService.Suggest(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Подсказки одинаковы для всех, поэтому их можно кэшировать на клиенте и в прокси
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"Laman/internal/database"
+	"Laman/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// postgresSearchRepository реализует SearchRepository используя PostgreSQL.
+type postgresSearchRepository struct {
+	db *database.DB
+}
+
+// NewPostgresSearchRepository создает новый PostgreSQL репозиторий поиска.
+func NewPostgresSearchRepository(db *database.DB) SearchRepository {
+	return &postgresSearchRepository{db: db}
+}
+
+func (r *postgresSearchRepository) PopularQueries(ctx context.Context, prefix string, suggestWeight float64, limit int) ([]models.SearchQueryStat, error) {
+	var stats []models.SearchQueryStat
+	query := `
+		SELECT query, search_count, suggest_count
+		FROM search_queries
+		WHERE query LIKE $1 || '%' AND search_count > 0
+		ORDER BY search_count + suggest_count * $2::float8 DESC, query
+		LIMIT $3
+	`
+	err := r.db.SelectContext(ctx, &stats, query, prefix, suggestWeight, limit)
+	return stats, err
+}
+
+// nameMatch возвращает условие совпадения названия с префиксом $1. Префикс
+// названия обслуживает индекс по LOWER(name), начало слова — триграммный индекс.
+func nameMatch(wordStart bool) string {
+	if wordStart {
+		return `(LOWER(name) LIKE $1 || '%' OR name ILIKE '% ' || $1 || '%')`
+	}
+	return `LOWER(name) LIKE $1 || '%'`
+}
+
+func (r *postgresSearchRepository) Products(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Product, error) {
+	var products []models.Product
+	query := fmt.Sprintf(`
+		SELECT id, store_id, name
+		FROM products
+		WHERE deleted_at IS NULL AND is_available AND %s
+		ORDER BY LOWER(name) LIKE $1 || '%%' DESC, rating_count DESC, name
+		LIMIT $2
+	`, nameMatch(wordStart))
+	err := r.db.SelectContext(ctx, &products, query, prefix, limit)
+	return products, err
+}
+
+func (r *postgresSearchRepository) Subcategories(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Subcategory, error) {
+	var subcategories []models.Subcategory
+	query := fmt.Sprintf(`
+		SELECT id, category_id, name
+		FROM subcategories
+		WHERE deleted_at IS NULL AND %s
+		ORDER BY LOWER(name) LIKE $1 || '%%' DESC, name
+		LIMIT $2
+	`, nameMatch(wordStart))
+	err := r.db.SelectContext(ctx, &subcategories, query, prefix, limit)
+	return subcategories, err
+}
+
+func (r *postgresSearchRepository) Stores(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Store, error) {
+	var stores []models.Store
+	query := fmt.Sprintf(`
+		SELECT id, name
+		FROM stores
+		WHERE deleted_at IS NULL AND %s
+		ORDER BY LOWER(name) LIKE $1 || '%%' DESC, rating DESC, name
+		LIMIT $2
+	`, nameMatch(wordStart))
+	err := r.db.SelectContext(ctx, &stores, query, prefix, limit)
+	return stores, err
+}
+
+func (r *postgresSearchRepository) IncrementQueries(ctx context.Context, stats []models.SearchQueryStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	queries := make([]string, len(stats))
+	searches := make([]int64, len(stats))
+	suggests := make([]int64, len(stats))
+	for i, stat := range stats {
+		queries[i] = stat.Query
+		searches[i] = stat.SearchCount
+		suggests[i] = stat.SuggestCount
+	}
+
+	// Подсказки запрашиваются на каждый набранный префикс, поэтому запрос,
+	// который только подсказывался, не создает строку журнала: иначе таблица
+	// копила бы все недописанные префиксы. Строки не удаляются, поэтому
+	// проверка существования не гонится с другими сбросами.
+	query := `
+		INSERT INTO search_queries (query, search_count, suggest_count, last_searched_at)
+		SELECT t.query, t.search_count, t.suggest_count, NOW()
+		FROM unnest($1::text[], $2::bigint[], $3::bigint[]) AS t(query, search_count, suggest_count)
+		WHERE t.search_count > 0
+		   OR EXISTS (SELECT 1 FROM search_queries q WHERE q.query = t.query AND q.search_count > 0)
+		ON CONFLICT (query) DO UPDATE
+		SET search_count = search_queries.search_count + EXCLUDED.search_count,
+		    suggest_count = search_queries.suggest_count + EXCLUDED.suggest_count,
+		    last_searched_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(queries), pq.Array(searches), pq.Array(suggests))
+	return err
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"Laman/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// maxQueryLength — длина столбца search_queries.query.
+const maxQueryLength = 100
+
+// maxPendingQueries ограничивает число разных запросов между сбросами журнала.
+const maxPendingQueries = 10000
+
+// NormalizeQuery приводит запрос к виду, в котором он хранится в журнале:
+// нижний регистр, одиночные пробелы, не длиннее maxQueryLength символов.
+func NormalizeQuery(query string) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		query = strings.TrimSpace(string([]rune(query)[:maxQueryLength]))
+	}
+	return query
+}
+
+// QueryLog накапливает поисковые запросы в памяти и периодически сбрасывает
+// счетчики в базу одним запросом. Подсказки вызываются на каждое нажатие
+// клавиши, поэтому запись в базу на каждый вызов была бы дороже самой подсказки.
+// Сброс прибавляет счетчики, поэтому журнал корректен при нескольких репликах.
+type QueryLog struct {
+	repo     SearchRepository
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	pending map[string]*models.SearchQueryStat
+}
+
+// NewQueryLog создает журнал поисковых запросов.
+func NewQueryLog(repo SearchRepository, interval time.Duration, logger *zap.Logger) *QueryLog {
+	return &QueryLog{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+		pending:  make(map[string]*models.SearchQueryStat),
+	}
+}
+
+// LogSearch учитывает поиск, который нашел результаты.
+func (l *QueryLog) LogSearch(query string) {
+	l.add(query, 1, 0)
+}
+
+// LogSuggest учитывает запрос подсказок.
+func (l *QueryLog) LogSuggest(query string) {
+	l.add(query, 0, 1)
+}
+
+func (l *QueryLog) add(query string, searches, suggests int64) {
+	query = NormalizeQuery(query)
+	if query == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stat, ok := l.pending[query]
+	if !ok {
+		// Если сброс не успевает, новые запросы теряются, а память не растет
+		if len(l.pending) >= maxPendingQueries {
+			return
+		}
+		stat = &models.SearchQueryStat{Query: query}
+		l.pending[query] = stat
+	}
+	stat.SearchCount += searches
+	stat.SuggestCount += suggests
+}
+
+// Run сбрасывает журнал с периодом interval, пока не будет отменен ctx,
+// и делает последний сброс при остановке.
+func (l *QueryLog) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			l.flush(flushCtx)
+			return
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+}
+
+func (l *QueryLog) flush(ctx context.Context) {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[string]*models.SearchQueryStat)
+	l.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	// Строки обновляются в одном порядке, чтобы сбросы реплик не ждали друг друга по кругу
+	stats := make([]models.SearchQueryStat, 0, len(pending))
+	for _, stat := range pending {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Query < stats[j].Query })
+
+	if err := l.repo.IncrementQueries(ctx, stats); err != nil {
+		l.logger.Warn("Не удалось сохранить журнал поисковых запросов",
+			zap.Int("queries", len(stats)), zap.Error(err))
+	}
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"context"
+
+	"Laman/internal/models"
+)
+
+// SearchRepository определяет интерфейс для подсказок и журнала запросов.
+// Префиксы передаются в нижнем регистре с экранированными символами LIKE.
+type SearchRepository interface {
+	// PopularQueries получает запросы из журнала, которые начинаются с prefix
+	// и хотя бы раз искались, по убыванию популярности: числа поисков плюс
+	// числа подсказок с весом suggestWeight.
+	PopularQueries(ctx context.Context, prefix string, suggestWeight float64, limit int) ([]models.SearchQueryStat, error)
+
+	// Products получает доступные товары, название которых начинается с prefix,
+	// а при wordStart — и товары, в названии которых с prefix начинается слово.
+	Products(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Product, error)
+
+	// Subcategories получает подкатегории по префиксу названия или слова в нем.
+	Subcategories(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Subcategory, error)
+
+	// Stores получает магазины по префиксу названия или слова в нем.
+	Stores(ctx context.Context, prefix string, wordStart bool, limit int) ([]models.Store, error)
+
+	// IncrementQueries прибавляет счетчики поисков и подсказок к журналу запросов.
+	// Подсказки учитываются только у запросов, которые хотя бы раз искались.
+	IncrementQueries(ctx context.Context, stats []models.SearchQueryStat) error
+}
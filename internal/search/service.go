@@ -0,0 +1,205 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"Laman/internal/models"
+)
+
+const (
+	// DefaultSuggestLimit и MaxSuggestLimit ограничивают число подсказок.
+	DefaultSuggestLimit = 8
+	MaxSuggestLimit     = 20
+
+	// minSuggestLength — с какой длины запроса показываются подсказки.
+	minSuggestLength = 2
+	// minWordStartLength — с какой длины ищется начало слова внутри названия:
+	// на коротких префиксах триграммный индекс не помогает.
+	minWordStartLength = 3
+	// maxQuerySuggestions ограничивает число популярных запросов в подсказках.
+	maxQuerySuggestions = 3
+	// popularQueriesLimit — сколько популярных запросов учитывается при ранжировании.
+	popularQueriesLimit = 50
+	// suggestQueryWeight — вес запроса подсказок относительно поиска в популярности:
+	// подсказки запрашиваются на каждый набранный символ, поэтому значат меньше.
+	suggestQueryWeight = 0.1
+)
+
+// typeOrder задает порядок типов подсказок с одинаковой оценкой.
+var typeOrder = map[models.SuggestionType]int{
+	models.SuggestionTypeQuery:       0,
+	models.SuggestionTypeSubcategory: 1,
+	models.SuggestionTypeStore:       2,
+	models.SuggestionTypeProduct:     3,
+}
+
+// SuggestService подбирает подсказки для поисковой строки.
+type SuggestService struct {
+	repo     SearchRepository
+	queryLog *QueryLog
+}
+
+// NewSuggestService создает новый сервис подсказок.
+func NewSuggestService(repo SearchRepository, queryLog *QueryLog) *SuggestService {
+	return &SuggestService{
+		repo:     repo,
+		queryLog: queryLog,
+	}
+}
+
+type candidate struct {
+	suggestion models.Suggestion
+	score      float64
+}
+
+// Suggest возвращает до limit подсказок вперемешку: популярные запросы,
+// подкатегории, магазины и товары, название которых начинается с запроса
+// (с трех символов — и название, в котором с запроса начинается слово).
+// Совпадение с началом названия весит больше совпадения со словом внутри,
+// а популярность в журнале запросов поднимает подсказку выше.
+func (s *SuggestService) Suggest(ctx context.Context, query string, limit int) ([]models.Suggestion, error) {
+	query = NormalizeQuery(query)
+	if utf8.RuneCountInString(query) < minSuggestLength {
+		return []models.Suggestion{}, nil
+	}
+	if limit <= 0 || limit > MaxSuggestLimit {
+		limit = DefaultSuggestLimit
+	}
+	s.queryLog.LogSuggest(query)
+
+	prefix := escapeLike(query)
+	wordStart := utf8.RuneCountInString(query) >= minWordStartLength
+
+	popular, err := s.repo.PopularQueries(ctx, prefix, suggestQueryWeight, popularQueriesLimit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить популярные запросы: %w", err)
+	}
+	products, err := s.repo.Products(ctx, prefix, wordStart, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить товары: %w", err)
+	}
+	subcategories, err := s.repo.Subcategories(ctx, prefix, wordStart, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить подкатегории: %w", err)
+	}
+	stores, err := s.repo.Stores(ctx, prefix, wordStart, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить магазины: %w", err)
+	}
+
+	var candidates []candidate
+	for i, stat := range popular {
+		if i == maxQuerySuggestions {
+			break
+		}
+		if stat.Query == query {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			suggestion: models.Suggestion{Type: models.SuggestionTypeQuery, Text: stat.Query},
+			score:      2 + math.Log1p(popularity(stat)),
+		})
+	}
+
+	seen := make(map[string]bool, len(products))
+	for _, product := range products {
+		key := strings.ToLower(product.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		id, storeID := product.ID, product.StoreID
+		candidates = append(candidates, candidate{
+			suggestion: models.Suggestion{Type: models.SuggestionTypeProduct, Text: product.Name, ID: &id, StoreID: &storeID},
+			score:      nameScore(product.Name, query, popular),
+		})
+	}
+	for _, subcategory := range subcategories {
+		id, categoryID := subcategory.ID, subcategory.CategoryID
+		candidates = append(candidates, candidate{
+			suggestion: models.Suggestion{Type: models.SuggestionTypeSubcategory, Text: subcategory.Name, ID: &id, CategoryID: &categoryID},
+			score:      nameScore(subcategory.Name, query, popular),
+		})
+	}
+	for _, store := range stores {
+		id := store.ID
+		candidates = append(candidates, candidate{
+			suggestion: models.Suggestion{Type: models.SuggestionTypeStore, Text: store.Name, ID: &id},
+			score:      nameScore(store.Name, query, popular),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if typeOrder[a.suggestion.Type] != typeOrder[b.suggestion.Type] {
+			return typeOrder[a.suggestion.Type] < typeOrder[b.suggestion.Type]
+		}
+		return a.suggestion.Text < b.suggestion.Text
+	})
+
+	// Подсказки одного типа занимают не больше половины списка, чтобы
+	// десяток одноименных товаров не вытеснил магазины и подкатегории
+	perType := (limit + 1) / 2
+	if perType < 2 {
+		perType = 2
+	}
+	counts := make(map[models.SuggestionType]int)
+	suggestions := make([]models.Suggestion, 0, limit)
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		if counts[c.suggestion.Type] == perType {
+			continue
+		}
+		counts[c.suggestion.Type]++
+		suggestions = append(suggestions, c.suggestion)
+	}
+	return suggestions, nil
+}
+
+// nameScore оценивает совпадение названия с запросом: 2 за начало названия,
+// 1 за начало слова внутри. К оценке прибавляется популярность запросов,
+// продолжающих текущий и совпадающих с началом названия или слова.
+func nameScore(name, query string, popular []models.SearchQueryStat) float64 {
+	lower := strings.ToLower(name)
+	score := 1.0
+	if strings.HasPrefix(lower, query) {
+		score = 2
+	}
+
+	var total float64
+	for _, stat := range popular {
+		if matchesWordStart(lower, stat.Query) {
+			total += popularity(stat)
+		}
+	}
+	return score + math.Log1p(total)
+}
+
+// popularity возвращает популярность запроса: поиски и подсказки с весом suggestQueryWeight.
+func popularity(stat models.SearchQueryStat) float64 {
+	return float64(stat.SearchCount) + suggestQueryWeight*float64(stat.SuggestCount)
+}
+
+// matchesWordStart проверяет, начинается ли text или одно из его слов с prefix.
+func matchesWordStart(text, prefix string) bool {
+	if strings.HasPrefix(text, prefix) {
+		return true
+	}
+	return strings.Contains(text, " "+prefix)
+}
+
+// escapeLike экранирует символы шаблона LIKE, чтобы запрос искался буквально.
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
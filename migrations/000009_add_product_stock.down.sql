@@ -0,0 +1,5 @@
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS chk_products_stock_non_negative;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS stock;
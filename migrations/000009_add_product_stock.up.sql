@@ -0,0 +1,9 @@
+-- Остатки товаров. NULL означает, что учет остатков для товара не ведется.
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS stock INTEGER;
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS chk_products_stock_non_negative;
+
+ALTER TABLE products
+    ADD CONSTRAINT chk_products_stock_non_negative CHECK (stock IS NULL OR stock >= 0);
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS pricing_rule_id;
+
+DROP TABLE IF EXISTS pricing_rules;
@@ -0,0 +1,33 @@
+-- Правила расчета сборов. Правило магазина приоритетнее правила типа магазина,
+-- правило без магазина и типа действует по умолчанию.
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    store_id UUID REFERENCES stores(id) ON DELETE CASCADE,
+    store_category_type store_category_type,
+    service_fee_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    delivery_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    free_delivery_threshold DECIMAL(10, 2),
+    min_order_amount DECIMAL(10, 2),
+    weight_included_kg DECIMAL(10, 2),
+    weight_surcharge_per_kg DECIMAL(10, 2),
+    distance_included_km DECIMAL(10, 2),
+    distance_fee_per_km DECIMAL(10, 2),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_rules_store_id ON pricing_rules(store_id) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_pricing_rules_category ON pricing_rules(store_category_type) WHERE is_active;
+
+-- Правило по умолчанию повторяет прежние константы: 5% сервисный сбор и 200 руб. доставка
+INSERT INTO pricing_rules (id, name, service_fee_percent, delivery_fee)
+SELECT uuid_generate_v4(), 'По умолчанию', 5.00, 200.00
+WHERE NOT EXISTS (
+    SELECT 1 FROM pricing_rules WHERE store_id IS NULL AND store_category_type IS NULL
+);
+
+-- Примененное правило сохраняется в заказе для аудита
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS pricing_rule_id UUID REFERENCES pricing_rules(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS chk_orders_final_total;
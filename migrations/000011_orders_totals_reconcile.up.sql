@@ -0,0 +1,9 @@
+-- Суммы заказа считаются в копейках без погрешности, поэтому итог обязан
+-- точно совпадать с суммой слагаемых. NOT VALID: проверяются только новые
+-- и изменяемые строки, исторические заказы с погрешностью float не блокируют миграцию.
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS chk_orders_final_total;
+
+ALTER TABLE orders
+    ADD CONSTRAINT chk_orders_final_total
+    CHECK (final_total = items_total + service_fee + delivery_fee) NOT VALID;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_users_store_id;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_store_staff,
+    DROP CONSTRAINT IF EXISTS chk_users_role;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS store_id,
+    DROP COLUMN IF EXISTS role;
@@ -0,0 +1,18 @@
+-- Роли пользователей. Сотрудник магазина привязан к своему магазину.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'CUSTOMER',
+    ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id) ON DELETE SET NULL;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_role;
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_role CHECK (role IN ('CUSTOMER', 'STORE', 'COURIER', 'ADMIN'));
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_store_staff;
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_store_staff CHECK (role <> 'STORE' OR store_id IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_users_store_id ON users(store_id);
@@ -0,0 +1,21 @@
+-- История статусов заказа: каждый переход с участником и причиной
+CREATE TABLE IF NOT EXISTS order_status_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_events_order_id ON order_status_events(order_id, created_at);
+
+-- Для существующих заказов восстанавливается только начальное событие
+INSERT INTO order_status_events (id, order_id, from_status, to_status, actor_user_id, actor_role, reason, created_at)
+SELECT uuid_generate_v4(), o.id, NULL, 'NEW', o.user_id,
+       CASE WHEN o.user_id IS NULL THEN 'GUEST' ELSE 'CUSTOMER' END,
+       NULL, o.created_at
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_status_events e WHERE e.order_id = o.id);
@@ -0,0 +1,4 @@
+ALTER TABLE order_status_events DROP COLUMN IF EXISTS reason_code;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS cancellation_comment;
+ALTER TABLE orders DROP COLUMN IF EXISTS cancellation_reason;
@@ -0,0 +1,5 @@
+-- Причина отмены заказа: код из фиксированного списка и свободный комментарий
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancellation_reason VARCHAR(50);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancellation_comment TEXT;
+
+ALTER TABLE order_status_events ADD COLUMN IF NOT EXISTS reason_code VARCHAR(50);
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS awaiting_approval;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS substitute_for;
+ALTER TABLE order_items DROP COLUMN IF EXISTS original_quantity;
+ALTER TABLE order_items DROP COLUMN IF EXISTS status;
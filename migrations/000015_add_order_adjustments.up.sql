@@ -0,0 +1,7 @@
+-- Частичная сборка: магазин может исключить позицию, изменить количество
+-- или предложить замену, после чего заказ ждет согласия покупателя
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS original_quantity INTEGER;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS substitute_for UUID REFERENCES order_items(id) ON DELETE SET NULL;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS awaiting_approval BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_checkout_group_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS checkout_group_id;
+
+DROP TABLE IF EXISTS checkout_groups;
@@ -0,0 +1,19 @@
+-- Чекаут из нескольких магазинов: один запрос покупателя разбивается
+-- на подзаказы по магазинам, связанные общей группой
+CREATE TABLE IF NOT EXISTS checkout_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    delivery_mode VARCHAR(20) NOT NULL DEFAULT 'PER_ORDER',
+    items_total DECIMAL(10, 2) NOT NULL,
+    service_fee DECIMAL(10, 2) NOT NULL,
+    delivery_fee DECIMAL(10, 2) NOT NULL,
+    final_total DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkout_groups_user_id ON checkout_groups(user_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS checkout_group_id UUID REFERENCES checkout_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_checkout_group_id ON orders(checkout_group_id);
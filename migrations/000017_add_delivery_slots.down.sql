@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_deliveries_slot_start;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS slot_end;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS slot_start;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS slot_id;
+
+DROP TABLE IF EXISTS delivery_slots;
+DROP TABLE IF EXISTS store_hours;
@@ -0,0 +1,33 @@
+-- Часы работы магазинов по дням недели (0 — воскресенье)
+CREATE TABLE IF NOT EXISTS store_hours (
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL,
+    PRIMARY KEY (store_id, weekday),
+    CONSTRAINT chk_store_hours_range CHECK (opens_at < closes_at)
+);
+
+-- Слоты доставки с ограничением числа заказов
+CREATE TABLE IF NOT EXISTS delivery_slots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_delivery_slots_range CHECK (starts_at < ends_at),
+    CONSTRAINT chk_delivery_slots_capacity CHECK (reserved <= capacity),
+    CONSTRAINT uq_delivery_slots_store_start UNIQUE (store_id, starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_slots_store_starts_at ON delivery_slots(store_id, starts_at);
+
+-- Выбранный слот хранится в доставке вместе с интервалом для отображения
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS slot_id UUID REFERENCES delivery_slots(id) ON DELETE SET NULL;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS slot_start TIMESTAMP;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS slot_end TIMESTAMP;
+
+-- Планировщик ищет запланированные заказы по началу слота
+CREATE INDEX IF NOT EXISTS idx_deliveries_slot_start ON deliveries(slot_start) WHERE slot_start IS NOT NULL;
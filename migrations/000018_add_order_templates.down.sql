@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_template_items;
+DROP TABLE IF EXISTS order_templates;
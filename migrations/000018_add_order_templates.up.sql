@@ -0,0 +1,32 @@
+-- Шаблоны заказов для повторных покупок
+CREATE TABLE IF NOT EXISTS order_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    delivery_address TEXT NOT NULL,
+    distance DECIMAL(10, 2),
+    comment TEXT,
+    cadence VARCHAR(20) CHECK (cadence IN ('WEEKLY', 'BIWEEKLY', 'MONTHLY')),
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_order_templates_user_name UNIQUE (user_id, name),
+    CONSTRAINT chk_order_templates_schedule CHECK ((cadence IS NULL) = (next_run_at IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_templates_user_id ON order_templates(user_id);
+
+-- Планировщик выбирает шаблоны, время повтора которых наступило
+CREATE INDEX IF NOT EXISTS idx_order_templates_next_run_at ON order_templates(next_run_at) WHERE next_run_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS order_template_items (
+    template_id UUID NOT NULL REFERENCES order_templates(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (template_id, product_id)
+);
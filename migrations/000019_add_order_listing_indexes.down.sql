@@ -0,0 +1,11 @@
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_store_id ON orders(store_id);
+
+DROP INDEX IF EXISTS idx_orders_guest_phone_created;
+DROP INDEX IF EXISTS idx_orders_created_id;
+DROP INDEX IF EXISTS idx_orders_status_created;
+DROP INDEX IF EXISTS idx_orders_store_status_created;
+DROP INDEX IF EXISTS idx_orders_store_created;
+DROP INDEX IF EXISTS idx_orders_user_created;
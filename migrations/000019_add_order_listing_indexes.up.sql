@@ -0,0 +1,14 @@
+-- Составные индексы для постраничного списка заказов: фильтр по владельцу,
+-- магазину или статусу и сортировка по (created_at, id) от новых к старым
+CREATE INDEX IF NOT EXISTS idx_orders_user_created ON orders(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_store_created ON orders(store_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_store_status_created ON orders(store_id, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created ON orders(status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_created_id ON orders(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_guest_phone_created ON orders(guest_phone, created_at DESC) WHERE guest_phone IS NOT NULL;
+
+-- Одноколоночные индексы покрываются составными
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP INDEX IF EXISTS idx_orders_status;
+DROP INDEX IF EXISTS idx_orders_created_at;
+DROP INDEX IF EXISTS idx_orders_store_id;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_status_events_order_to_status;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS escalated_at;
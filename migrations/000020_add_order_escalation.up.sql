@@ -0,0 +1,5 @@
+-- Момент эскалации заказа, который магазин не подтвердил вовремя
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP;
+
+-- Поиск времени попадания заказа в очередь (перехода в NEW)
+CREATE INDEX IF NOT EXISTS idx_order_status_events_order_to_status ON order_status_events(order_id, to_status, created_at);
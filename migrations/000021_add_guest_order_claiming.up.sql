@@ -0,0 +1,4 @@
+-- Поиск гостевых заказов по нормализованному телефону при привязке к пользователю
+CREATE INDEX IF NOT EXISTS idx_orders_guest_phone_normalized
+    ON orders ((regexp_replace(regexp_replace(guest_phone, '\D', '', 'g'), '^8(\d{10})$', '7\1')))
+    WHERE user_id IS NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_stores_rating;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating;
+-- Демо-значения stores.rating, обнуленные миграцией, не восстанавливаются
+ALTER TABLE stores DROP COLUMN IF EXISTS rating_count;
+
+DROP TABLE IF EXISTS reviews;
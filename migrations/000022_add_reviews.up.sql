@@ -0,0 +1,35 @@
+-- Отзывы о магазинах и товарах из доставленных заказов
+CREATE TABLE IF NOT EXISTS reviews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    moderation_comment TEXT,
+    moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Один отзыв о магазине и по одному о каждом товаре на заказ
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_order_store ON reviews(order_id) WHERE product_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_order_product ON reviews(order_id, product_id) WHERE product_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reviews_store_status ON reviews(store_id, status, created_at DESC) WHERE product_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_reviews_product_status ON reviews(product_id, status, created_at DESC) WHERE product_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_reviews_pending ON reviews(created_at) WHERE status = 'PENDING';
+
+-- Рейтинг пересчитывается по одобренным отзывам
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+-- Рейтинг магазинов до отзывов был заполнен демо-данными. Без оценок рейтинг
+-- равен нулю, иначе сортировка по рейтингу смешивает их со средними по отзывам
+UPDATE stores SET rating = 0 WHERE rating_count = 0;
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS rating NUMERIC(3,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_stores_rating ON stores(rating DESC, rating_count DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_products_store_active;
+DROP INDEX IF EXISTS idx_products_category_active;
+
+ALTER TABLE stores DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE subcategories DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- Мягкое удаление каталога: строки остаются, потому что на товары и магазины
+-- ссылаются заказы (ON DELETE RESTRICT)
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE subcategories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Проверка, остались ли у категории и магазина неудаленные товары
+CREATE INDEX IF NOT EXISTS idx_products_category_active ON products(category_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_store_active ON products(store_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_products_store_sku;
+
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,7 @@
+-- Артикул товара внутри магазина: по нему импорт прайс-листа находит товар
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+-- Артикул уникален среди неудаленных товаров магазина; удаленный товар
+-- освобождает артикул для нового
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_store_sku ON products(store_id, sku)
+    WHERE sku IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Полнотекстовый поиск товаров с русской морфологией и поиск с опечатками
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Название весит больше описания, поэтому совпадения в названии выше в выдаче
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('russian', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+
+-- Нечеткое совпадение с названием (оператор <%) для запросов с опечатками
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_stores_name_prefix;
+DROP INDEX IF EXISTS idx_products_name_prefix;
+
+DROP TABLE IF EXISTS search_queries;
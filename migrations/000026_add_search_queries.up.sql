@@ -0,0 +1,12 @@
+-- Журнал поисковых запросов для подсказок: популярные запросы поднимаются выше
+CREATE TABLE IF NOT EXISTS search_queries (
+    query VARCHAR(100) PRIMARY KEY,
+    search_count BIGINT NOT NULL DEFAULT 0,
+    suggest_count BIGINT NOT NULL DEFAULT 0,
+    last_searched_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Поиск по префиксу (LIKE 'мол%') независимо от правил сортировки базы
+CREATE INDEX IF NOT EXISTS idx_search_queries_prefix ON search_queries(query text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_products_name_prefix ON products(LOWER(name) text_pattern_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_stores_name_prefix ON stores(LOWER(name) text_pattern_ops) WHERE deleted_at IS NULL;
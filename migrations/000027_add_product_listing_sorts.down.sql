@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_products_name_id;
+DROP INDEX IF EXISTS idx_products_rating_id;
+DROP INDEX IF EXISTS idx_products_sold_id;
+DROP INDEX IF EXISTS idx_products_created_id;
+DROP INDEX IF EXISTS idx_products_price_id;
+
+ALTER TABLE products DROP COLUMN IF EXISTS sold_count;
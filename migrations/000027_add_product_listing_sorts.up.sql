@@ -0,0 +1,21 @@
+-- Число единиц товара в неотмененных заказах для сортировки по популярности.
+-- Поддерживается при резервировании и возврате остатков.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sold_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE products p
+SET sold_count = sold.quantity
+FROM (
+    SELECT oi.product_id, SUM(oi.quantity) AS quantity
+    FROM order_items oi
+    JOIN orders o ON o.id = oi.order_id
+    WHERE o.status <> 'CANCELLED' AND oi.status <> 'UNAVAILABLE'
+    GROUP BY oi.product_id
+) sold
+WHERE sold.product_id = p.id;
+
+-- Индексы под постраничную выдачу каталога: курсор сравнивает кортеж (ключ, id)
+CREATE INDEX IF NOT EXISTS idx_products_price_id ON products(price, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_created_id ON products(created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_sold_id ON products(sold_count DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_rating_id ON products(rating DESC, rating_count DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_name_id ON products(name, id) WHERE deleted_at IS NULL;